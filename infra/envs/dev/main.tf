@@ -11,6 +11,7 @@ module "backups" {
   source        = "../../modules/s3_backups"
   name          = "${var.name}-backups"
   force_destroy = true
+  trash_prefix  = "${var.backup_prefix}/trash/"
 }
 
 module "ecr" {
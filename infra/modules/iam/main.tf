@@ -37,8 +37,11 @@ data "aws_iam_policy_document" "controller" {
   }
 
   statement {
-    sid     = "S3Backups"
-    actions = ["s3:PutObject", "s3:GetObject", "s3:ListBucket"]
+    sid = "S3Backups"
+    actions = [
+      "s3:PutObject", "s3:GetObject", "s3:ListBucket", "s3:DeleteObject",
+      "s3:GetObjectTagging", "s3:PutObjectTagging", "s3:RestoreObject"
+    ]
     resources = [
       var.backup_bucket_arn,
       "${var.backup_bucket_arn}/*"
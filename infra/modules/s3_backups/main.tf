@@ -22,3 +22,19 @@ resource "aws_s3_bucket_public_access_block" "this" {
   ignore_public_acls      = true
   restrict_public_buckets = true
 }
+
+# Soft-deleted backups are moved under the trash prefix by the controller and
+# purged here once the recovery window has passed.
+resource "aws_s3_bucket_lifecycle_configuration" "this" {
+  bucket = aws_s3_bucket.this.id
+
+  rule {
+    id     = "expire-trash"
+    status = "Enabled"
+
+    filter { prefix = var.trash_prefix }
+
+    expiration { days = var.trash_expiration_days }
+    noncurrent_version_expiration { noncurrent_days = 1 }
+  }
+}
@@ -4,3 +4,13 @@ variable "force_destroy" {
   type    = bool
   default = false
 }
+
+variable "trash_prefix" {
+  type    = string
+  default = "backups/trash/"
+}
+
+variable "trash_expiration_days" {
+  type    = number
+  default = 7
+}
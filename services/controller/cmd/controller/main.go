@@ -1,41 +1,147 @@
 package main
 
 import (
+	"context"
 	"log/slog"
+	"net"
 	"os"
+	"time"
 
-	"github.com/esuEdu/game-infra/controller/internal/adapters/hytale"
-	"github.com/esuEdu/game-infra/controller/internal/adapters/minecraft"
+	"github.com/esuEdu/game-infra/controller/internal/adapters"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
 	"github.com/esuEdu/game-infra/controller/internal/api"
 	"github.com/esuEdu/game-infra/controller/internal/app"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/events"
+	"github.com/esuEdu/game-infra/controller/internal/rpc"
 	"github.com/esuEdu/game-infra/controller/internal/service"
+	"github.com/esuEdu/game-infra/controller/internal/tracing"
 )
 
 func main() {
-	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-
 	cfg := app.LoadConfig()
+	// An invalid LOG_LEVEL leaves info in place until Validate reports it.
+	logLevel := new(slog.LevelVar)
+	if lvl, err := app.ParseLogLevel(cfg.LogLevel); err == nil {
+		logLevel.Set(lvl)
+	}
+	log := app.NewLogger(os.Stdout, cfg.LogFormat, logLevel)
+	if err := cfg.Validate(); err != nil {
+		log.Error("invalid config", "err", err)
+		os.Exit(1)
+	}
+
+	// Tracing is optional: a broken exporter config leaves it off rather
+	// than keeping the controller down.
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Warn("tracing disabled", "err", err)
+	} else if tracing.Enabled() {
+		log.Info("tracing enabled")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = shutdownTracing(ctx)
+	}()
+
+	registry := service.NewAdapterRegistry()
+	if err := adapters.RegisterAll(registry); err != nil {
+		log.Error("adapter registration failed", "err", err)
+		os.Exit(1)
+	}
+
+	var (
+		sinks     []domain.EventSink
+		eventsBuf *events.Buffer
+	)
+	if cfg.EventBufferSize > 0 {
+		eventsBuf = events.NewBuffer(cfg.EventBufferSize)
+		sinks = append(sinks, eventsBuf)
+	}
+	if cfg.EventLog {
+		sinks = append(sinks, events.LogSink{Log: log})
+	}
+	if cfg.EventWebhookURL != "" {
+		sinks = append(sinks, events.NewWebhookSink(log, cfg.EventWebhookURL))
+	}
+
+	// Validate has already parsed OPERATION_LIMITS.
+	opLimits := map[domain.EventKind]int{}
+	parsed, _ := cfg.OperationLimitMap()
+	for kind, n := range parsed {
+		opLimits[domain.EventKind(kind)] = n
+	}
 
-	mc := minecraft.NewAdapter(log)
-	hy := hytale.NewAdapter(log)
+	var ecsTargets []domain.ECSTarget
+	allowed, _ := cfg.ECSTargetList()
+	for _, t := range allowed {
+		ecsTargets = append(ecsTargets, domain.ECSTarget{Cluster: t[0], Service: t[1]})
+	}
+
+	// An invalid ENV is reported by the adapters' validation.
+	backupPrefix, _ := env.BackupPrefix()
 
 	controllerSvc := service.NewControllerService(
 		log,
 		service.NewMemoryState(),
-		map[string]service.Adapter{
-			"minecraft": mc,
-			"hytale":    hy,
-		},
+		registry.Build(log),
+		service.WithCommandHistory(cfg.CommandHistorySize),
+		service.WithStopBackupOrder(cfg.StopBackupOrder),
+		service.WithEventSinks(sinks...),
+		service.WithOperationLimits(opLimits),
+		service.WithOperationQueueMax(cfg.OperationQueueMax),
+		service.WithSwitchCooldown(cfg.SwitchCooldown),
+		service.WithStatusCacheTTL(cfg.StatusCacheTTL),
+		service.WithUnsafeHotRestore(cfg.UnsafeHotRestore),
+		service.WithECSTargets(ecsTargets),
+		service.WithBackupPrefix(backupPrefix),
+		service.WithRetention(domain.RetentionPolicy{KeepLast: cfg.RetentionKeep, MaxAge: cfg.RetentionMaxAge}),
+		service.WithPreRestoreRetention(domain.RetentionPolicy{KeepLast: cfg.PreRestoreRetentionKeep, MaxAge: cfg.PreRestoreRetentionMaxAge}),
 	)
 
+	if err := controllerSvc.ValidateAdapters(); err != nil && cfg.StrictStartup {
+		log.Error("adapter validation failed", "err", err)
+		os.Exit(1)
+	}
+
+	hostname, _ := os.Hostname()
+	go controllerSvc.RunBackupScheduler(context.Background(), service.BackupSchedule{
+		Interval:   cfg.BackupInterval,
+		Jitter:     cfg.BackupJitter,
+		JitterMode: cfg.BackupJitterMode,
+		InstanceID: hostname,
+	})
+
 	a := app.New(log, cfg, controllerSvc)
+	a.Events = eventsBuf
+	a.LogLevel = logLevel
+
+	if cfg.GRPCAddr != "" {
+		lis, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			log.Error("grpc listen failed", "addr", cfg.GRPCAddr, "err", err)
+			os.Exit(1)
+		}
+		grpcSrv := rpc.NewServer(a)
+		go func() {
+			log.Info("grpc listening", "addr", cfg.GRPCAddr)
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Error("grpc server stopped", "err", err)
+			}
+		}()
+	}
 
 	srv := api.NewServer(a)
 
-	log.Info("http listening", "addr", srv.Addr)
-	if err := srv.ListenAndServe(); err != nil {
+	if cfg.HTTP.TLS() {
+		log.Info("https listening", "addr", srv.Addr, "h2", true)
+		err = srv.ListenAndServeTLS(cfg.HTTP.TLSCertFile, cfg.HTTP.TLSKeyFile)
+	} else {
+		log.Info("http listening", "addr", srv.Addr, "h2c", cfg.HTTP.H2C)
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
 		log.Error("server stopped", "err", err)
 	}
 }
@@ -0,0 +1,47 @@
+// Package adapters wires the available game adapters into a registry. Add new
+// games here.
+package adapters
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/execadapter"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/hytale"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/minecraft"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/terraria"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/valheim"
+	"github.com/esuEdu/game-infra/controller/internal/service"
+)
+
+// RegisterAll registers every built-in game adapter, plus the exec adapters
+// declared in EXEC_ADAPTERS_FILE.
+func RegisterAll(r *service.AdapterRegistry) error {
+	return errors.Join(
+		r.Register("minecraft", func(log *slog.Logger) service.Adapter { return minecraft.NewAdapter(log) }),
+		r.Register("hytale", func(log *slog.Logger) service.Adapter { return hytale.NewAdapter(log) }),
+		r.Register("terraria", func(log *slog.Logger) service.Adapter { return terraria.NewAdapter(log) }),
+		r.Register("valheim", func(log *slog.Logger) service.Adapter { return valheim.NewAdapter(log) }),
+		registerExec(r),
+	)
+}
+
+func registerExec(r *service.AdapterRegistry) error {
+	path := strings.TrimSpace(os.Getenv("EXEC_ADAPTERS_FILE"))
+	if path == "" {
+		return nil
+	}
+	specs, err := execadapter.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, spec := range specs {
+		errs = append(errs, r.Register(spec.Name, func(log *slog.Logger) service.Adapter {
+			return execadapter.NewAdapter(log, spec)
+		}))
+	}
+	return errors.Join(errs...)
+}
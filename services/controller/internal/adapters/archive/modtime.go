@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// NewestModTime returns the latest modification time among the files under
+// srcDir that a backup with the same include filter would capture, or the
+// zero time when there are none (srcDir missing or empty).
+func NewestModTime(srcDir string, include func(rel string, d fs.DirEntry) bool) (time.Time, error) {
+	var newest time.Time
+	err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if path == srcDir && errors.Is(walkErr, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return walkErr
+		}
+		if path == srcDir {
+			return nil
+		}
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if include != nil && !include(filepath.ToSlash(relPath), d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return newest, nil
+}
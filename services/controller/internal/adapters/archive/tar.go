@@ -0,0 +1,484 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Format is a backup archive format, named by its file extension.
+type Format string
+
+const (
+	FormatZip   Format = "zip"
+	FormatTarGz Format = "tar.gz"
+)
+
+// ParseFormat parses a BACKUP_FORMAT value. The empty string is zip.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FormatZip, nil
+	case FormatZip, FormatTarGz:
+		return f, nil
+	case "tgz":
+		return FormatTarGz, nil
+	}
+	return "", fmt.Errorf("unknown archive format %q (want zip or tar.gz)", s)
+}
+
+// Ext is the file extension for f, including the leading dot.
+func (f Format) Ext() string { return "." + string(f) }
+
+// FormatOf detects the format of an archive from its name. Anything that is
+// not a tarball is treated as a zip, which is what older backups are.
+func FormatOf(name string) Format {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz") {
+		return FormatTarGz
+	}
+	return FormatZip
+}
+
+// Pack writes srcDir to dst in format f.
+func Pack(f Format, srcDir, dst string, include func(rel string, d fs.DirEntry) bool) (Stats, error) {
+	if f == FormatTarGz {
+		return TarGzDirectory(srcDir, dst, include)
+	}
+	return ZipDirectory(srcDir, dst, include)
+}
+
+// Unpack extracts src into dstDir, picking the format from src's name.
+func Unpack(src, dstDir string) error {
+	if FormatOf(src) == FormatTarGz {
+		return UntarGzToDirectory(src, dstDir)
+	}
+	return UnzipToDirectory(src, dstDir)
+}
+
+// TarGzDirectory writes the contents of srcDir to a gzipped tarball at dst.
+// Unlike a zip it keeps each entry's owner, group, mode and mtime, and stores
+// symlinks as links, so a restore puts back exactly what the server wrote.
+// include works as for ZipDirectory.
+func TarGzDirectory(srcDir, dst string, include func(rel string, d fs.DirEntry) bool) (Stats, error) {
+	var stats Stats
+	out, err := os.Create(dst)
+	if err != nil {
+		return stats, fmt.Errorf("create tarball %s: %w", dst, err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	walkErr := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if path == srcDir {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if include != nil && !include(relPath, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() && link == "" {
+			// Sockets, fifos and devices have no place in a world backup.
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = relPath
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		// FileInfoHeader looks up user and group names; restores go by id.
+		header.Uname, header.Gname = "", ""
+		header.Format = tar.FormatPAX
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyN(tw, f, header.Size)
+		closeErr := f.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+		stats.Files++
+		stats.Bytes += header.Size
+		return nil
+	})
+	if walkErr != nil {
+		return stats, fmt.Errorf("walk source dir for tarball: %w", walkErr)
+	}
+	if err := tw.Close(); err != nil {
+		return stats, fmt.Errorf("finish tarball %s: %w", dst, err)
+	}
+	if err := gz.Close(); err != nil {
+		return stats, fmt.Errorf("finish tarball %s: %w", dst, err)
+	}
+	if err := out.Close(); err != nil {
+		return stats, err
+	}
+	return stats, archiveSize(dst, &stats)
+}
+
+// UntarGzToDirectory extracts the tarball src into dstDir, restoring mode and
+// mtime, and owner and group where the process is allowed to. Entries that
+// would escape dstDir are rejected, as are links pointing outside it, so a
+// later entry can't be written through a link to somewhere else.
+func UntarGzToDirectory(src, dstDir string) error {
+	_, err := untar(src, dstDir, nil)
+	return err
+}
+
+// extractTarPaths is ExtractPaths for tarballs.
+func extractTarPaths(src, dstDir string, paths []string) ([]string, error) {
+	return untar(src, dstDir, func(name string) bool { return matchesPaths(name, paths) })
+}
+
+// untar extracts the entries of src accepted by match (all when nil) and
+// returns the names of the regular files it wrote.
+func untar(src, dstDir string, match func(name string) bool) ([]string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("open tarball %s: %w", src, err)
+	}
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("open tarball %s: %w", src, err)
+	}
+	defer gz.Close()
+
+	type dirMeta struct {
+		path  string
+		mode  fs.FileMode
+		mtime time.Time
+	}
+	var (
+		extracted []string
+		dirs      []dirMeta
+		links     []string
+	)
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return extracted, fmt.Errorf("read tarball %s: %w", src, err)
+		}
+		cleanName, err := SafePath(h.Name)
+		if err != nil {
+			return extracted, fmt.Errorf("tarball contains invalid path: %s", h.Name)
+		}
+		if cleanName == "." {
+			continue
+		}
+		if match != nil && (h.Typeflag == tar.TypeDir || !match(cleanName)) {
+			continue
+		}
+		outPath := filepath.Join(dstDir, cleanName)
+		// A link written by an earlier entry (or already in dstDir) must not
+		// carry this one outside it.
+		if err := noSymlinkIn(dstDir, ".", filepath.Dir(cleanName)); err != nil {
+			return extracted, fmt.Errorf("tarball entry %s: %w", h.Name, err)
+		}
+
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := noSymlinkIn(dstDir, ".", cleanName); err != nil {
+				return extracted, fmt.Errorf("tarball entry %s: %w", h.Name, err)
+			}
+			if err := os.MkdirAll(outPath, 0o755); err != nil {
+				return extracted, fmt.Errorf("mkdir %s: %w", outPath, err)
+			}
+			restoreOwner(outPath, h)
+			// Writing the contents bumps the dir's mtime and a read-only
+			// mode would block them, so both are set once everything is out.
+			dirs = append(dirs, dirMeta{outPath, fs.FileMode(h.Mode).Perm(), h.ModTime})
+			continue
+		case tar.TypeReg:
+			if err := writeTarFile(tr, h, outPath); err != nil {
+				return extracted, err
+			}
+			extracted = append(extracted, filepath.ToSlash(cleanName))
+		case tar.TypeSymlink:
+			target := filepath.Join(filepath.Dir(cleanName), filepath.FromSlash(h.Linkname))
+			if filepath.IsAbs(h.Linkname) || !insideDir(target) {
+				return extracted, fmt.Errorf("tarball link escapes the target dir: %s -> %s", h.Name, h.Linkname)
+			}
+			if err := noSymlinkIn(dstDir, filepath.Dir(cleanName), filepath.FromSlash(h.Linkname)); err != nil {
+				return extracted, fmt.Errorf("tarball link %s -> %s: %w", h.Name, h.Linkname, err)
+			}
+			if err := replaceWith(outPath, func(tmp string) error { return os.Symlink(h.Linkname, tmp) }); err != nil {
+				return extracted, err
+			}
+			links = append(links, outPath)
+			restoreOwner(outPath, h)
+			continue
+		case tar.TypeLink:
+			target, err := SafePath(h.Linkname)
+			if err != nil {
+				return extracted, fmt.Errorf("tarball link escapes the target dir: %s -> %s", h.Name, h.Linkname)
+			}
+			if err := noSymlinkIn(dstDir, ".", target); err != nil {
+				return extracted, fmt.Errorf("tarball link %s -> %s: %w", h.Name, h.Linkname, err)
+			}
+			if err := replaceWith(outPath, func(tmp string) error { return os.Link(filepath.Join(dstDir, target), tmp) }); err != nil {
+				return extracted, err
+			}
+			extracted = append(extracted, filepath.ToSlash(cleanName))
+			continue
+		default:
+			// Devices, fifos and the like are skipped, as when packing.
+			continue
+		}
+		restoreOwner(outPath, h)
+		if err := os.Chtimes(outPath, h.ModTime, h.ModTime); err != nil {
+			return extracted, fmt.Errorf("set mtime of %s: %w", outPath, err)
+		}
+	}
+
+	if err := checkLinksInside(dstDir, links); err != nil {
+		return extracted, err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := dirs[i]
+		if err := os.Chmod(d.path, d.mode); err != nil {
+			return extracted, fmt.Errorf("chmod %s: %w", d.path, err)
+		}
+		if err := os.Chtimes(d.path, d.mtime, d.mtime); err != nil {
+			return extracted, fmt.Errorf("set mtime of %s: %w", d.path, err)
+		}
+	}
+	return extracted, nil
+}
+
+// writeTarFile writes the current entry beside outPath and renames it over
+// the target, as extractFile does for zips.
+func writeTarFile(tr *tar.Reader, h *tar.Header, outPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return fmt.Errorf("mkdir parent for %s: %w", outPath, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), ".restore-*")
+	if err != nil {
+		return fmt.Errorf("create temp file for %s: %w", outPath, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, tr); err != nil {
+		tmp.Close()
+		return fmt.Errorf("extract %s: %w", h.Name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("extract %s: %w", h.Name, err)
+	}
+	if err := os.Chmod(tmp.Name(), fs.FileMode(h.Mode).Perm()); err != nil {
+		return fmt.Errorf("chmod %s: %w", outPath, err)
+	}
+	if err := os.Rename(tmp.Name(), outPath); err != nil {
+		return fmt.Errorf("replace %s: %w", outPath, err)
+	}
+	return nil
+}
+
+// replaceWith creates a link via create at a temp name beside outPath and
+// renames it over outPath.
+func replaceWith(outPath string, create func(tmp string) error) error {
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return fmt.Errorf("mkdir parent for %s: %w", outPath, err)
+	}
+	tmp := filepath.Join(filepath.Dir(outPath), ".restore-link-"+filepath.Base(outPath))
+	_ = os.Remove(tmp)
+	if err := create(tmp); err != nil {
+		return fmt.Errorf("link %s: %w", outPath, err)
+	}
+	if err := os.Rename(tmp, outPath); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replace %s: %w", outPath, err)
+	}
+	return nil
+}
+
+// restoreOwner puts back the entry's uid and gid. Only root may give files
+// away, so a controller running unprivileged keeps its own ownership rather
+// than failing the restore.
+func restoreOwner(path string, h *tar.Header) {
+	_ = os.Lchown(path, h.Uid, h.Gid)
+}
+
+// noSymlinkIn walks rel one component at a time from base (a dir under
+// dstDir already known to be free of links) and fails on the first component
+// that exists as a symlink. The path text alone can't show where a chain of
+// links such as d/b -> .. and d/e -> b/.. really leads, so extraction never
+// writes or links through one. Components that don't exist yet end the walk;
+// they will be created as plain dirs.
+func noSymlinkIn(dstDir, base, rel string) error {
+	cur := base
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		cur = filepath.Join(cur, part)
+		if part == ".." || cur == "." {
+			continue
+		}
+		if !insideDir(cur) {
+			return fmt.Errorf("path leaves the target dir: %s", rel)
+		}
+		info, err := os.Lstat(filepath.Join(dstDir, cur))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("path goes through the symlink %s", filepath.ToSlash(cur))
+		}
+	}
+	return nil
+}
+
+// checkLinksInside resolves the links an extraction wrote, now that every
+// entry is out, and removes any that leads outside dstDir: a link checked
+// before a later entry made its target path a link can still escape.
+// Dangling links are left alone.
+func checkLinksInside(dstDir string, links []string) error {
+	if len(links) == 0 {
+		return nil
+	}
+	root, err := filepath.EvalSymlinks(dstDir)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", dstDir, err)
+	}
+	for _, link := range links {
+		resolved, err := filepath.EvalSymlinks(link)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, resolved)
+		if err != nil || !insideDir(rel) {
+			_ = os.Remove(link)
+			return fmt.Errorf("tarball link escapes the target dir: %s", link)
+		}
+	}
+	return nil
+}
+
+func insideDir(rel string) bool {
+	_, err := SafePath(filepath.ToSlash(rel))
+	return err == nil
+}
+
+// validateTarGz is Validate for tarballs.
+func validateTarGz(src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open tarball: %w", err)
+	}
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return fmt.Errorf("open tarball: %w", err)
+	}
+	defer gz.Close()
+
+	files := 0
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read tarball: %w", err)
+		}
+		if _, err := SafePath(h.Name); err != nil {
+			return fmt.Errorf("tarball contains invalid path: %s", h.Name)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return fmt.Errorf("read tarball entry %s: %w", h.Name, err)
+		}
+		files++
+	}
+	if files == 0 {
+		return fmt.Errorf("tarball contains no files")
+	}
+	return nil
+}
+
+// tarEntries is Entries for tarballs.
+func tarEntries(src string) ([]string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("open tarball %s: %w", src, err)
+	}
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("open tarball %s: %w", src, err)
+	}
+	defer gz.Close()
+
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tarball %s: %w", src, err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			names = append(names, h.Name)
+		}
+	}
+}
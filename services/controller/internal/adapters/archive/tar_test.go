@@ -0,0 +1,129 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name string
+	link string // symlink target; "" for a regular file
+	body string
+}
+
+func writeTarGz(t *testing.T, path string, entries []tarEntry) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0o644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		if e.link != "" {
+			h = &tar.Header{Name: e.name, Mode: 0o777, Typeflag: tar.TypeSymlink, Linkname: e.link}
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUntarRejectsSymlinkChains(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{
+			name: "write through chained links",
+			entries: []tarEntry{
+				{name: "d/keep.txt", body: "x"},
+				{name: "d/b", link: ".."},
+				{name: "d/e", link: "b/.."},
+				{name: "d/e/escaped.txt", body: "escaped"},
+			},
+		},
+		{
+			name: "link through an existing link",
+			entries: []tarEntry{
+				{name: "d/b", link: ".."},
+				{name: "x", link: "d/b/.."},
+			},
+		},
+		{
+			name: "link made to escape by a later link",
+			entries: []tarEntry{
+				{name: "x", link: "d/y/../.."},
+				{name: "d/keep.txt", body: "x"},
+				{name: "d/y", link: ".."},
+			},
+		},
+		{
+			name: "absolute link",
+			entries: []tarEntry{
+				{name: "x", link: "/etc"},
+			},
+		},
+		{
+			name: "relative link out of the dir",
+			entries: []tarEntry{
+				{name: "d/x", link: "../../escaped.txt"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dst := filepath.Join(parent, "dst")
+			if err := os.Mkdir(dst, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			src := filepath.Join(t.TempDir(), "crafted.tar.gz")
+			writeTarGz(t, src, tt.entries)
+
+			if err := Unpack(src, dst); err == nil {
+				t.Fatal("Unpack accepted a crafted tarball")
+			}
+			if _, err := os.Stat(filepath.Join(parent, "escaped.txt")); err == nil {
+				t.Fatal("escaped.txt was written outside the target dir")
+			}
+			if target, err := os.Readlink(filepath.Join(dst, "x")); err == nil {
+				t.Fatalf("escaping link x -> %s was left behind", target)
+			}
+		})
+	}
+}
+
+func TestUntarKeepsLinksInside(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "world.tar.gz")
+	writeTarGz(t, src, []tarEntry{
+		{name: "world/level.dat", body: "level"},
+		{name: "current", link: "world"},
+		{name: "world/latest.dat", link: "level.dat"},
+	})
+	dst := t.TempDir()
+	if err := Unpack(src, dst); err != nil {
+		t.Fatalf("Unpack: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dst, "current", "level.dat"))
+	if err != nil || string(got) != "level" {
+		t.Fatalf("current/level.dat = %q, %v", got, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "world", "latest.dat")); err != nil || target != "level.dat" {
+		t.Fatalf("world/latest.dat -> %q, %v", target, err)
+	}
+}
@@ -0,0 +1,332 @@
+// Package archive packs and unpacks game data directories for backups, as
+// zips or gzipped tarballs.
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Stats counts what went into an archive: regular files, their total size
+// and the size of the archive written.
+type Stats struct {
+	Files        int
+	Bytes        int64
+	ArchiveBytes int64
+}
+
+// ZipDirectory writes the contents of srcDir to dstZip. When include is non-nil
+// only entries it accepts are written; rejecting a directory skips it.
+func ZipDirectory(srcDir, dstZip string, include func(rel string, d fs.DirEntry) bool) (Stats, error) {
+	var stats Stats
+	out, err := os.Create(dstZip)
+	if err != nil {
+		return stats, fmt.Errorf("create zip %s: %w", dstZip, err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	defer zw.Close()
+
+	if err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if path == srcDir {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if include != nil && !include(relPath, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			_, err := zw.Create(relPath + "/")
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = relPath
+		header.Method = zip.Deflate
+
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(w, f)
+		closeErr := f.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+		stats.Files++
+		stats.Bytes += n
+		return nil
+	}); err != nil {
+		return stats, fmt.Errorf("walk source dir for zip: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return stats, fmt.Errorf("finish zip %s: %w", dstZip, err)
+	}
+	if err := out.Close(); err != nil {
+		return stats, fmt.Errorf("finish zip %s: %w", dstZip, err)
+	}
+	return stats, archiveSize(dstZip, &stats)
+}
+
+// archiveSize records the size of the finished archive at path.
+func archiveSize(path string, stats *Stats) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat archive %s: %w", path, err)
+	}
+	stats.ArchiveBytes = info.Size()
+	return nil
+}
+
+// UnzipToDirectory extracts srcZip into dstDir, rejecting entries that would
+// escape it and archives zipEntries refuses. Empty files and directories are
+// kept.
+func UnzipToDirectory(srcZip, dstDir string) error {
+	r, err := zip.OpenReader(srcZip)
+	if err != nil {
+		return fmt.Errorf("open zip %s: %w", srcZip, err)
+	}
+	defer r.Close()
+
+	entries, err := zipEntries(r.File)
+	if err != nil {
+		return err
+	}
+
+	type dirMeta struct {
+		path string
+		mode fs.FileMode
+	}
+	var dirs []dirMeta
+	for _, e := range entries {
+		outPath := filepath.Join(dstDir, e.name)
+
+		if e.dir {
+			if err := os.MkdirAll(outPath, 0o755); err != nil {
+				return fmt.Errorf("mkdir %s: %w", outPath, err)
+			}
+			// A read-only mode would block the dir's contents, so it is set
+			// once everything is out, as for tarballs.
+			dirs = append(dirs, dirMeta{outPath, e.dirPerm()})
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return fmt.Errorf("mkdir parent for %s: %w", outPath, err)
+		}
+
+		in, err := e.Open()
+		if err != nil {
+			return fmt.Errorf("open zip entry %s: %w", e.Name, err)
+		}
+
+		out, err := os.OpenFile(outPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, e.filePerm())
+		if err != nil {
+			in.Close()
+			return fmt.Errorf("open output file %s: %w", outPath, err)
+		}
+
+		if _, err := io.Copy(out, in); err != nil {
+			in.Close()
+			out.Close()
+			return fmt.Errorf("extract %s: %w", e.Name, err)
+		}
+		in.Close()
+		if err := out.Close(); err != nil {
+			return fmt.Errorf("extract %s: %w", e.Name, err)
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].mode); err != nil {
+			return fmt.Errorf("chmod %s: %w", dirs[i].path, err)
+		}
+	}
+	return nil
+}
+
+// SafePath cleans a zip entry or requested path and rejects anything that
+// would land outside the extraction dir (absolute, or climbing out with ..).
+func SafePath(name string) (string, error) {
+	cleanName := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(cleanName) || cleanName == ".." || strings.HasPrefix(cleanName, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path escapes the target dir: %s", name)
+	}
+	return cleanName, nil
+}
+
+// ExtractPaths extracts only the files of srcZip (or a tarball, detected by
+// name) at or under paths into dstDir, leaving everything else in dstDir
+// alone, and returns the extracted names. Paths must already be SafePath-clean
+// and not the root. Each file is written beside its target and renamed over
+// it, so a reader never sees a half-written file.
+func ExtractPaths(srcZip, dstDir string, paths []string) ([]string, error) {
+	if FormatOf(srcZip) == FormatTarGz {
+		return extractTarPaths(srcZip, dstDir, paths)
+	}
+	r, err := zip.OpenReader(srcZip)
+	if err != nil {
+		return nil, fmt.Errorf("open zip %s: %w", srcZip, err)
+	}
+	defer r.Close()
+
+	entries, err := zipEntries(r.File)
+	if err != nil {
+		return nil, err
+	}
+	var extracted []string
+	for _, e := range entries {
+		if e.dir || !matchesPaths(e.name, paths) {
+			continue
+		}
+		if err := extractFile(e, filepath.Join(dstDir, e.name)); err != nil {
+			return extracted, err
+		}
+		extracted = append(extracted, filepath.ToSlash(e.name))
+	}
+	return extracted, nil
+}
+
+// matchesPaths reports whether the clean entry name is one of paths or
+// under one of them.
+func matchesPaths(name string, paths []string) bool {
+	for _, p := range paths {
+		if name == p || strings.HasPrefix(name, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func extractFile(f zipEntry, outPath string) error {
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return fmt.Errorf("mkdir parent for %s: %w", outPath, err)
+	}
+	in, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open zip entry %s: %w", f.Name, err)
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), ".restore-*")
+	if err != nil {
+		return fmt.Errorf("create temp file for %s: %w", outPath, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return fmt.Errorf("extract %s: %w", f.Name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("extract %s: %w", f.Name, err)
+	}
+	if err := os.Chmod(tmp.Name(), f.filePerm()); err != nil {
+		return fmt.Errorf("chmod %s: %w", outPath, err)
+	}
+	if err := os.Rename(tmp.Name(), outPath); err != nil {
+		return fmt.Errorf("replace %s: %w", outPath, err)
+	}
+	return nil
+}
+
+// Entries lists the file names in srcZip (or a tarball) without extracting
+// it.
+func Entries(srcZip string) ([]string, error) {
+	if FormatOf(srcZip) == FormatTarGz {
+		return tarEntries(srcZip)
+	}
+	r, err := zip.OpenReader(srcZip)
+	if err != nil {
+		return nil, fmt.Errorf("open zip %s: %w", srcZip, err)
+	}
+	defer r.Close()
+
+	entries, err := zipEntries(r.File)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.dir {
+			names = append(names, e.Name)
+		}
+	}
+	return names, nil
+}
+
+// Validate checks that srcZip is a well-formed, non-empty archive that
+// Unpack would accept: every path stays inside the target, no two entries
+// claim the same path, and every file decompresses with a matching CRC.
+// Tarballs, detected by name, get the path and CRC checks; a repeated tar
+// entry is an update by design and replaces the earlier one.
+func Validate(srcZip string) error {
+	if FormatOf(srcZip) == FormatTarGz {
+		return validateTarGz(srcZip)
+	}
+	r, err := zip.OpenReader(srcZip)
+	if err != nil {
+		return fmt.Errorf("open zip: %w", err)
+	}
+	defer r.Close()
+
+	entries, err := zipEntries(r.File)
+	if err != nil {
+		return err
+	}
+	files := 0
+	for _, e := range entries {
+		if e.dir {
+			continue
+		}
+		in, err := e.Open()
+		if err != nil {
+			return fmt.Errorf("open zip entry %s: %w", e.Name, err)
+		}
+		_, err = io.Copy(io.Discard, in)
+		in.Close()
+		if err != nil {
+			return fmt.Errorf("read zip entry %s: %w", e.Name, err)
+		}
+		files++
+	}
+	if files == 0 {
+		return fmt.Errorf("zip contains no files")
+	}
+	return nil
+}
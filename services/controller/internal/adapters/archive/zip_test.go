@@ -0,0 +1,143 @@
+package archive
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type zipFile struct {
+	name string // a trailing slash makes a directory entry
+	body string
+}
+
+func writeZip(t *testing.T, path string, files []zipFile) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range files {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnzipRejectsCraftedArchives(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []zipFile
+	}{
+		{
+			name: "duplicate file",
+			files: []zipFile{
+				{name: "world/level.dat", body: "first"},
+				{name: "world/level.dat", body: "second"},
+			},
+		},
+		{
+			name: "duplicate after cleaning",
+			files: []zipFile{
+				{name: "world/level.dat", body: "first"},
+				{name: "world/./region/../level.dat", body: "second"},
+			},
+		},
+		{
+			name: "file and directory at one path",
+			files: []zipFile{
+				{name: "world/"},
+				{name: "world", body: ""},
+			},
+		},
+		{
+			name: "non-empty file with entries under it",
+			files: []zipFile{
+				{name: "world", body: "not a dir"},
+				{name: "world/level.dat", body: "level"},
+			},
+		},
+		{
+			name: "path out of the dir",
+			files: []zipFile{
+				{name: "../escaped.txt", body: "escaped"},
+			},
+		},
+		{
+			name: "absolute path",
+			files: []zipFile{
+				{name: "/escaped.txt", body: "escaped"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "crafted.zip")
+			writeZip(t, src, tt.files)
+			parent := t.TempDir()
+			dst := filepath.Join(parent, "dst")
+			if err := os.Mkdir(dst, 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := Validate(src); err == nil {
+				t.Error("Validate accepted a crafted zip")
+			}
+			if err := UnzipToDirectory(src, dst); err == nil {
+				t.Error("UnzipToDirectory accepted a crafted zip")
+			}
+			if _, err := os.Stat(filepath.Join(parent, "escaped.txt")); err == nil {
+				t.Error("escaped.txt was written outside the target dir")
+			}
+		})
+	}
+}
+
+func TestUnzipEdgeCases(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "world.zip")
+	writeZip(t, src, []zipFile{
+		// A slashless, empty entry with others under it is a directory.
+		{name: "world", body: ""},
+		{name: "world/level.dat", body: "level"},
+		// Repeated directory entries are kept once.
+		{name: "world/region/"},
+		{name: "world/region/"},
+		{name: "world/region/r.0.0.mca", body: "region"},
+		{name: "world/empty.txt", body: ""},
+		{name: "world/datapacks/"},
+	})
+	if err := Validate(src); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	dst := t.TempDir()
+	if err := UnzipToDirectory(src, dst); err != nil {
+		t.Fatalf("UnzipToDirectory: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"world/level.dat":        "level",
+		"world/region/r.0.0.mca": "region",
+		"world/empty.txt":        "",
+	} {
+		got, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	for _, dir := range []string{"world", "world/region", "world/datapacks"} {
+		fi, err := os.Stat(filepath.Join(dst, filepath.FromSlash(dir)))
+		if err != nil || !fi.IsDir() {
+			t.Errorf("%s is not a directory: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// zipEntry is one entry of a zip as it will be extracted.
+type zipEntry struct {
+	*zip.File
+	name string // SafePath-clean
+	dir  bool
+}
+
+// zipEntries checks a zip's entries and decides which are directories before
+// anything is written. An entry is a directory when its name ends in a slash
+// or its mode says so (zips made on Windows), and also when it is empty and
+// other entries sit under it, which some tools emit without the slash. A
+// non-empty entry with others under it, or two file entries for the same
+// path, is rejected: extracting would silently let one overwrite the other.
+// Repeated directory entries are harmless and kept once.
+func zipEntries(files []*zip.File) ([]zipEntry, error) {
+	names := make([]string, len(files))
+	parents := map[string]bool{}
+	for i, f := range files {
+		cleanName, err := SafePath(f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("zip contains invalid path: %s", f.Name)
+		}
+		names[i] = cleanName
+		for p := filepath.Dir(cleanName); p != "."; p = filepath.Dir(p) {
+			parents[p] = true
+		}
+	}
+
+	entries := make([]zipEntry, 0, len(files))
+	seen := map[string]bool{} // clean name -> is a dir
+	for i, f := range files {
+		name := names[i]
+		if name == "." {
+			continue
+		}
+		dir := f.FileInfo().IsDir()
+		if !dir && parents[name] {
+			if f.UncompressedSize64 > 0 {
+				return nil, fmt.Errorf("zip entry %s is a file but other entries are under it", f.Name)
+			}
+			dir = true
+		}
+		if wasDir, ok := seen[name]; ok {
+			if wasDir && dir {
+				continue
+			}
+			return nil, fmt.Errorf("zip contains duplicate entry: %s", f.Name)
+		}
+		seen[name] = dir
+		entries = append(entries, zipEntry{File: f, name: name, dir: dir})
+	}
+	return entries, nil
+}
+
+// unixMode reports whether the entry's permissions came from a unix (or
+// macOS) zipper. Others, including Go's own zip.Writer, only record a
+// read-only flag, which archive/zip turns into 0666 or 0777 for every entry.
+func (e zipEntry) unixMode() bool {
+	creator := e.CreatorVersion >> 8
+	return (creator == 3 || creator == 19) && e.Mode().Perm() != 0
+}
+
+// dirPerm is the mode an extracted directory gets: its recorded unix mode,
+// or 0755.
+func (e zipEntry) dirPerm() fs.FileMode {
+	if e.unixMode() {
+		return e.Mode().Perm()
+	}
+	return 0o755
+}
+
+// filePerm is the mode an extracted file gets: its recorded unix mode, or
+// 0644.
+func (e zipEntry) filePerm() fs.FileMode {
+	if e.unixMode() {
+		return e.Mode().Perm()
+	}
+	return 0o644
+}
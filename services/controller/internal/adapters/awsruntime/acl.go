@@ -0,0 +1,76 @@
+package awsruntime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// When the backup bucket belongs to another account, objects written by the
+// task role stay owned by the writer unless they grant the bucket owner
+// control. BACKUP_OBJECT_ACL sets the canned ACL sent with every object
+// write (uploads, markers, copies), usually bucket-owner-full-control.
+// Buckets with object ownership "bucket owner enforced" have ACLs disabled
+// and refuse any ACL; set BACKUP_OBJECT_ACL=bucket-owner-enforced for those,
+// or leave it unset. A bucket that refuses the configured ACL anyway gets
+// the write retried without it, and later writes skip it.
+
+// ObjectACLBucketOwnerEnforced is the BACKUP_OBJECT_ACL value for buckets
+// with ACLs disabled: no ACL is sent.
+const ObjectACLBucketOwnerEnforced = "bucket-owner-enforced"
+
+// publicACLs would expose worlds (and whatever secrets their configs hold)
+// beyond the bucket's account.
+var publicACLs = []s3types.ObjectCannedACL{
+	s3types.ObjectCannedACLPublicRead,
+	s3types.ObjectCannedACLPublicReadWrite,
+	s3types.ObjectCannedACLAuthenticatedRead,
+}
+
+// ObjectACLFromEnv reads and validates BACKUP_OBJECT_ACL. "" means no ACL is
+// sent.
+func ObjectACLFromEnv() (s3types.ObjectCannedACL, error) {
+	return ParseObjectACL(os.Getenv("BACKUP_OBJECT_ACL"))
+}
+
+// ParseObjectACL validates a BACKUP_OBJECT_ACL value: unset,
+// bucket-owner-enforced, or a canned ACL that doesn't make backups public.
+func ParseObjectACL(s string) (s3types.ObjectCannedACL, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == ObjectACLBucketOwnerEnforced {
+		return "", nil
+	}
+	acl := s3types.ObjectCannedACL(s)
+	if !slices.Contains(acl.Values(), acl) {
+		return "", fmt.Errorf("unknown canned ACL %q", s)
+	}
+	if slices.Contains(publicACLs, acl) {
+		return "", fmt.Errorf("canned ACL %q would make backups readable outside the account", s)
+	}
+	return acl, nil
+}
+
+// putWithACL runs put with the configured ACL, or none once the bucket has
+// refused ACLs.
+func (c *Client) putWithACL(put func(acl s3types.ObjectCannedACL) error) error {
+	acl := c.objectACL
+	if c.aclsDisabled.Load() {
+		acl = ""
+	}
+	err := put(acl)
+	if acl != "" && isACLNotSupported(err) {
+		c.aclsDisabled.Store(true)
+		err = put("")
+	}
+	return err
+}
+
+func isACLNotSupported(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessControlListNotSupported"
+}
@@ -0,0 +1,70 @@
+package awsruntime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CapacityProvider is one entry of an ECS capacity provider strategy.
+type CapacityProvider struct {
+	CapacityProvider string `json:"capacityProvider"`
+	Weight           int32  `json:"weight"`
+	Base             int32  `json:"base,omitempty"`
+}
+
+// ParseCapacityProviderStrategy parses "provider:weight[:base],..." as used by
+// ECS_CAPACITY_PROVIDER_STRATEGY, e.g. "FARGATE_SPOT:4,FARGATE:1:1". It
+// enforces the ECS rules: weights 0-1000, base 0-100000, at most one provider
+// with a base, and at least one positive weight.
+func ParseCapacityProviderStrategy(raw string) ([]CapacityProvider, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var (
+		out       []CapacityProvider
+		seen      = map[string]bool{}
+		withBase  int
+		hasWeight bool
+	)
+	for _, entry := range strings.Split(raw, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || len(parts) > 3 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid capacity provider %q (want provider:weight[:base])", entry)
+		}
+		cp := CapacityProvider{CapacityProvider: strings.TrimSpace(parts[0])}
+		if seen[cp.CapacityProvider] {
+			return nil, fmt.Errorf("capacity provider %s listed twice", cp.CapacityProvider)
+		}
+		seen[cp.CapacityProvider] = true
+
+		weight, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || weight < 0 || weight > 1000 {
+			return nil, fmt.Errorf("capacity provider %s: weight must be 0-1000", cp.CapacityProvider)
+		}
+		cp.Weight = int32(weight)
+		hasWeight = hasWeight || weight > 0
+
+		if len(parts) == 3 {
+			base, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+			if err != nil || base < 0 || base > 100000 {
+				return nil, fmt.Errorf("capacity provider %s: base must be 0-100000", cp.CapacityProvider)
+			}
+			cp.Base = int32(base)
+			if base > 0 {
+				withBase++
+			}
+		}
+		out = append(out, cp)
+	}
+	if withBase > 1 {
+		return nil, errors.New("only one capacity provider may have a base")
+	}
+	if !hasWeight {
+		return nil, errors.New("at least one capacity provider needs a weight above 0")
+	}
+	return out, nil
+}
@@ -0,0 +1,44 @@
+package awsruntime
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// Uploads given PutOptions.SHA256 carry the digest in the object's metadata,
+// and DownloadFile re-hashes what it wrote against it, so a transfer that
+// was cut short or corrupted fails before the file is unpacked over a data
+// dir. S3_VERIFY_DOWNLOADS=false skips the check; objects without the
+// metadata (older backups, other tools) are never checked.
+
+const checksumMetadataKey = "sha256"
+
+// objectMetadata is the metadata written with an uploaded object.
+func objectMetadata(ctx context.Context, opts PutOptions) map[string]string {
+	meta := requestMetadata(ctx)
+	if opts.SHA256 != "" {
+		if meta == nil {
+			meta = map[string]string{}
+		}
+		meta[checksumMetadataKey] = strings.ToLower(opts.SHA256)
+	}
+	return meta
+}
+
+// verifyChecksum compares the digest of a downloaded object with the one
+// recorded in its metadata, if any.
+func (c *Client) verifyChecksum(bucket, key string, meta map[string]string, h hash.Hash) error {
+	want := strings.ToLower(strings.TrimSpace(meta[checksumMetadataKey]))
+	if !c.verifyDownloads || want == "" {
+		return nil
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: s3://%s/%s: downloaded sha256 %s, object metadata has %s", domain.ErrChecksumMismatch, bucket, key, got, want)
+	}
+	return nil
+}
@@ -10,17 +10,25 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/tracing"
 )
 
 const (
@@ -35,6 +43,13 @@ type Client struct {
 	httpClient  aws.HTTPClient
 	s3          *s3.Client
 	ecsEndpoint string
+	deploys     deployGuard
+
+	partConcurrency int
+	verifyDownloads bool // S3_VERIFY_DOWNLOADS, see checksum.go
+
+	objectACL    s3types.ObjectCannedACL // BACKUP_OBJECT_ACL, see acl.go
+	aclsDisabled atomic.Bool             // the bucket refused an ACL
 }
 
 func New(ctx context.Context, region string) (*Client, error) {
@@ -43,7 +58,17 @@ func New(ctx context.Context, region string) (*Client, error) {
 		return nil, errors.New("aws region is required")
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	objectACL, err := ObjectACLFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("BACKUP_OBJECT_ACL: %w", err)
+	}
+
+	transport := TransportOptionsFromEnv()
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(region),
+		config.WithHTTPClient(transport.httpClient()),
+		config.WithCredentialsCacheOptions(credentialCacheOptions),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("load aws config: %w", err)
 	}
@@ -53,17 +78,37 @@ func New(ctx context.Context, region string) (*Client, error) {
 		httpClient = http.DefaultClient
 	}
 
-	return &Client{
+	c := &Client{
 		region:      region,
 		cfg:         cfg,
 		signer:      v4.NewSigner(),
 		httpClient:  httpClient,
-		s3:          s3.NewFromConfig(cfg),
 		ecsEndpoint: strings.TrimSpace(os.Getenv("ECS_ENDPOINT_URL")),
-	}, nil
+
+		partConcurrency: transport.PartConcurrency,
+		verifyDownloads: env.Bool("S3_VERIFY_DOWNLOADS", true),
+		objectACL:       objectACL,
+	}
+	c.s3 = s3.NewFromConfig(cfg, c.s3Options)
+	return c, nil
+}
+
+// s3Options adds the controller's middleware to the S3 client: the request
+// id in the User-Agent, tracing, and the credential refresh on expiry.
+func (c *Client) s3Options(o *s3.Options) {
+	o.Retryer = retry.AddWithErrorCodes(o.Retryer, expiredCredentialCodes...)
+	o.APIOptions = append(o.APIOptions, addRequestIDUserAgent, addTracing, c.addCredentialRefresh)
+}
+
+// ScaleOptions tunes SetServiceDesiredCount.
+type ScaleOptions struct {
+	ForceNewDeployment bool
+	// CapacityProviders replaces the service's capacity provider strategy
+	// when non-empty (e.g. FARGATE_SPOT in dev, FARGATE in prod).
+	CapacityProviders []CapacityProvider
 }
 
-func (c *Client) SetServiceDesiredCount(ctx context.Context, cluster, service string, desired int32, forceNewDeployment bool) error {
+func (c *Client) SetServiceDesiredCount(ctx context.Context, cluster, service string, desired int32, opts ScaleOptions) error {
 	cluster = strings.TrimSpace(cluster)
 	service = strings.TrimSpace(service)
 	if cluster == "" || service == "" {
@@ -75,14 +120,38 @@ func (c *Client) SetServiceDesiredCount(ctx context.Context, cluster, service st
 		"service":      service,
 		"desiredCount": desired,
 	}
-	if forceNewDeployment {
+	if opts.ForceNewDeployment {
 		payload["forceNewDeployment"] = true
 	}
+	if len(opts.CapacityProviders) > 0 {
+		payload["capacityProviderStrategy"] = opts.CapacityProviders
+	}
 
-	if err := c.ecsJSONRPC(ctx, "UpdateService", payload, nil); err != nil {
-		return err
+	update := func() error { return c.ecsJSONRPC(ctx, "UpdateService", payload, nil) }
+	if !opts.ForceNewDeployment {
+		c.deploys.forget(cluster + "/" + service)
+		return update()
 	}
-	return nil
+	return c.deploys.once(ctx, cluster+"/"+service, desired, c.deployedSince(ctx, cluster, service, desired), update)
+}
+
+// ForceNewDeployment rolls the service's tasks without touching its desired
+// count, e.g. to pick up a re-pushed image tag.
+func (c *Client) ForceNewDeployment(ctx context.Context, cluster, service string) error {
+	cluster = strings.TrimSpace(cluster)
+	service = strings.TrimSpace(service)
+	if cluster == "" || service == "" {
+		return errors.New("cluster and service are required")
+	}
+
+	payload := map[string]any{
+		"cluster":            cluster,
+		"service":            service,
+		"forceNewDeployment": true,
+	}
+	return c.deploys.once(ctx, cluster+"/"+service, -1, c.deployedSince(ctx, cluster, service, -1), func() error {
+		return c.ecsJSONRPC(ctx, "UpdateService", payload, nil)
+	})
 }
 
 func (c *Client) WaitServiceStable(ctx context.Context, cluster, service string, timeout time.Duration) error {
@@ -94,6 +163,8 @@ func (c *Client) WaitServiceStable(ctx context.Context, cluster, service string,
 	if timeout <= 0 {
 		timeout = 10 * time.Minute
 	}
+	begin := time.Now()
+	defer func() { domain.RecordTiming(ctx, "ecs_wait_ms", time.Since(begin)) }()
 
 	deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -135,22 +206,45 @@ func (c *Client) DescribeService(ctx context.Context, cluster, service string) (
 	}
 
 	if len(out.Failures) > 0 {
-		fail := out.Failures[0]
-		msg := strings.TrimSpace(fail.Reason)
-		if msg == "" {
-			msg = "unknown ecs describe failure"
-		}
-		return ECSServiceState{}, fmt.Errorf("ecs describe service failure: %s", msg)
+		return ECSServiceState{}, ecsFailure("DescribeServices", out.Failures[0])
 	}
 
-	if len(out.Services) == 0 {
-		return ECSServiceState{}, fmt.Errorf("ecs service not found: %s", service)
+	if len(out.Services) == 0 || strings.EqualFold(out.Services[0].Status, "INACTIVE") {
+		return ECSServiceState{}, fmt.Errorf("%w: %s", ErrServiceNotFound, service)
 	}
 
 	return out.Services[0], nil
 }
 
-func (c *Client) UploadFile(ctx context.Context, bucket, key, path string) error {
+// PutOptions tunes how backup objects are written.
+type PutOptions struct {
+	StorageClass string // empty means the bucket default (STANDARD)
+	// SHA256 is the hex digest of the file, stored in the object's metadata
+	// so downloads can be verified. Empty skips it.
+	SHA256 string
+}
+
+// ValidStorageClass reports whether sc is a known S3 storage class.
+func ValidStorageClass(sc string) bool {
+	for _, v := range s3types.StorageClass("").Values() {
+		if string(v) == sc {
+			return true
+		}
+	}
+	return false
+}
+
+// IsArchivedStorageClass reports whether objects in sc must be restored
+// before they can be read. GLACIER_IR is instantly retrievable.
+func IsArchivedStorageClass(sc string) bool {
+	switch s3types.StorageClass(sc) {
+	case s3types.StorageClassGlacier, s3types.StorageClassDeepArchive:
+		return true
+	}
+	return false
+}
+
+func (c *Client) UploadFile(ctx context.Context, bucket, key, path string, opts PutOptions) error {
 	bucket = strings.TrimSpace(bucket)
 	key = strings.Trim(strings.TrimSpace(key), "/")
 	if bucket == "" || key == "" {
@@ -163,48 +257,107 @@ func (c *Client) UploadFile(ctx context.Context, bucket, key, path string) error
 	}
 	defer f.Close()
 
-	if _, err := c.s3.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-		Body:   f,
-	}); err != nil {
+	if info, err := f.Stat(); err == nil && info.Size() > multipartThreshold {
+		return c.uploadMultipart(ctx, bucket, key, f, info.Size(), opts)
+	}
+
+	in := &s3.PutObjectInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		Body:     f,
+		Metadata: objectMetadata(ctx, opts),
+	}
+	if opts.StorageClass != "" {
+		in.StorageClass = s3types.StorageClass(opts.StorageClass)
+	}
+
+	err = c.putWithACL(func(acl s3types.ObjectCannedACL) error {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		in.ACL = acl
+		_, err := c.s3.PutObject(ctx, in)
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("s3 put object s3://%s/%s: %w", bucket, key, err)
 	}
 
 	return nil
 }
 
-func (c *Client) DownloadFile(ctx context.Context, bucket, key, path string) error {
+type ObjectHead struct {
+	Size         int64
+	StorageClass string
+	LastModified time.Time
+	// Restore is the raw x-amz-restore header, e.g.
+	// `ongoing-request="false", expiry-date="..."`.
+	Restore  string
+	Metadata map[string]string
+}
+
+func (c *Client) HeadObject(ctx context.Context, bucket, key string) (ObjectHead, error) {
 	bucket = strings.TrimSpace(bucket)
 	key = strings.Trim(strings.TrimSpace(key), "/")
 	if bucket == "" || key == "" {
-		return errors.New("bucket and key are required")
+		return ObjectHead{}, errors.New("bucket and key are required")
 	}
 
-	out, err := c.s3.GetObject(ctx, &s3.GetObjectInput{
+	out, err := c.s3.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return fmt.Errorf("s3 get object s3://%s/%s: %w", bucket, key, err)
+		return ObjectHead{}, fmt.Errorf("s3 head object s3://%s/%s: %w", bucket, key, err)
 	}
-	defer out.Body.Close()
 
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return fmt.Errorf("create parent dir for %s: %w", path, err)
+	return ObjectHead{
+		Size:         aws.ToInt64(out.ContentLength),
+		StorageClass: string(out.StorageClass),
+		LastModified: aws.ToTime(out.LastModified),
+		Restore:      aws.ToString(out.Restore),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+// RestoreArchivedObject starts a Glacier restore that keeps a readable copy
+// for the given number of days.
+func (c *Client) RestoreArchivedObject(ctx context.Context, bucket, key string, days int32) error {
+	bucket = strings.TrimSpace(bucket)
+	key = strings.Trim(strings.TrimSpace(key), "/")
+	if bucket == "" || key == "" {
+		return errors.New("bucket and key are required")
 	}
 
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
-	if err != nil {
-		return fmt.Errorf("open destination file %s: %w", path, err)
+	if _, err := c.s3.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		RestoreRequest: &s3types.RestoreRequest{
+			Days:                 aws.Int32(days),
+			GlacierJobParameters: &s3types.GlacierJobParameters{Tier: s3types.TierStandard},
+		},
+	}); err != nil {
+		return fmt.Errorf("s3 restore object s3://%s/%s: %w", bucket, key, err)
 	}
-	defer f.Close()
+	return nil
+}
 
-	if _, err := io.Copy(f, out.Body); err != nil {
-		return fmt.Errorf("write destination file %s: %w", path, err)
+// OpenObject starts streaming an object; the caller must close the body.
+func (c *Client) OpenObject(ctx context.Context, bucket, key string) (io.ReadCloser, int64, error) {
+	bucket = strings.TrimSpace(bucket)
+	key = strings.Trim(strings.TrimSpace(key), "/")
+	if bucket == "" || key == "" {
+		return nil, 0, errors.New("bucket and key are required")
 	}
 
-	return nil
+	out, err := c.s3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("s3 get object s3://%s/%s: %w", bucket, key, err)
+	}
+	return out.Body, aws.ToInt64(out.ContentLength), nil
 }
 
 func (c *Client) PutString(ctx context.Context, bucket, key, value string) error {
@@ -214,11 +367,17 @@ func (c *Client) PutString(ctx context.Context, bucket, key, value string) error
 		return errors.New("bucket and key are required")
 	}
 
-	if _, err := c.s3.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-		Body:   strings.NewReader(value),
-	}); err != nil {
+	err := c.putWithACL(func(acl s3types.ObjectCannedACL) error {
+		_, err := c.s3.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			Body:     strings.NewReader(value),
+			Metadata: requestMetadata(ctx),
+			ACL:      acl,
+		})
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("s3 put object s3://%s/%s: %w", bucket, key, err)
 	}
 	return nil
@@ -248,6 +407,154 @@ func (c *Client) GetString(ctx context.Context, bucket, key string) (string, err
 	return string(body), nil
 }
 
+// CopyObject copies an object server-side, within or across buckets. Objects
+// over the 5 GiB CopyObject limit are copied in parts.
+func (c *Client) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, tags map[string]string) error {
+	srcBucket = strings.TrimSpace(srcBucket)
+	srcKey = strings.Trim(strings.TrimSpace(srcKey), "/")
+	dstBucket = strings.TrimSpace(dstBucket)
+	dstKey = strings.Trim(strings.TrimSpace(dstKey), "/")
+	if srcBucket == "" || srcKey == "" || dstBucket == "" || dstKey == "" {
+		return errors.New("source and destination bucket and key are required")
+	}
+
+	head, err := c.HeadObject(ctx, srcBucket, srcKey)
+	if err != nil {
+		return err
+	}
+	if head.Size > copyObjectLimit {
+		return c.copyMultipart(ctx, srcBucket, srcKey, dstBucket, dstKey, head, tags)
+	}
+
+	in := &s3.CopyObjectInput{
+		Bucket:     aws.String(dstBucket),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(url.PathEscape(srcBucket + "/" + srcKey)),
+	}
+	if len(tags) > 0 {
+		in.Tagging = aws.String(encodeTags(tags))
+		in.TaggingDirective = s3types.TaggingDirectiveReplace
+	}
+
+	err = c.putWithACL(func(acl s3types.ObjectCannedACL) error {
+		in.ACL = acl
+		_, err := c.s3.CopyObject(ctx, in)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("s3 copy object s3://%s/%s -> s3://%s/%s: %w", srcBucket, srcKey, dstBucket, dstKey, err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteObject(ctx context.Context, bucket, key string) error {
+	bucket = strings.TrimSpace(bucket)
+	key = strings.Trim(strings.TrimSpace(key), "/")
+	if bucket == "" || key == "" {
+		return errors.New("bucket and key are required")
+	}
+
+	if _, err := c.s3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return fmt.Errorf("s3 delete object s3://%s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+func (c *Client) GetObjectTags(ctx context.Context, bucket, key string) (map[string]string, error) {
+	bucket = strings.TrimSpace(bucket)
+	key = strings.Trim(strings.TrimSpace(key), "/")
+	if bucket == "" || key == "" {
+		return nil, errors.New("bucket and key are required")
+	}
+
+	out, err := c.s3.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3 get object tagging s3://%s/%s: %w", bucket, key, err)
+	}
+
+	tags := make(map[string]string, len(out.TagSet))
+	for _, t := range out.TagSet {
+		tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	return tags, nil
+}
+
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// ListObjects returns every object under prefix. When delimiter is set,
+// objects nested below the next delimiter are skipped.
+func (c *Client) ListObjects(ctx context.Context, bucket, prefix, delimiter string) ([]ObjectInfo, error) {
+	bucket = strings.TrimSpace(bucket)
+	if bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+
+	in := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}
+	if delimiter != "" {
+		in.Delimiter = aws.String(delimiter)
+	}
+
+	var objects []ObjectInfo
+	p := s3.NewListObjectsV2Paginator(c.s3, in)
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("s3 list objects s3://%s/%s: %w", bucket, prefix, err)
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// HeadBucket checks the bucket exists and the controller's credentials can
+// reach it.
+func (c *Client) HeadBucket(ctx context.Context, bucket string) error {
+	bucket = strings.TrimSpace(bucket)
+	if bucket == "" {
+		return errors.New("bucket is required")
+	}
+	if _, err := c.s3.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return fmt.Errorf("s3 head bucket %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// IsAccessDenied reports whether S3 refused a request for lack of
+// permissions. HEAD requests carry no error body, so a bare 403 counts too.
+func (c *Client) IsAccessDenied(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code := strings.TrimSpace(apiErr.ErrorCode())
+		if code == "AccessDenied" || code == "Forbidden" {
+			return true
+		}
+	}
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusForbidden
+}
+
 func (c *Client) IsObjectNotFound(err error) bool {
 	if err == nil {
 		return false
@@ -264,57 +571,76 @@ func (c *Client) IsObjectNotFound(err error) bool {
 	return false
 }
 
-func (c *Client) ecsJSONRPC(ctx context.Context, operation string, payload any, out any) error {
+func (c *Client) ecsJSONRPC(ctx context.Context, operation string, payload any, out any) (err error) {
+	ctx, span := tracing.Start(ctx, "ECS."+operation,
+		attribute.String("rpc.system", "aws-api"),
+		attribute.String("rpc.service", "ECS"),
+		attribute.String("rpc.method", operation),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("marshal ecs payload: %w", err)
 	}
 
+	respBody, err := c.ecsCall(ctx, operation, body)
+	if isExpiredCredentials(err) {
+		// Signed with credentials that expired in flight; retry once with
+		// fresh ones (see credentials.go).
+		c.invalidateCredentials()
+		respBody, err = c.ecsCall(ctx, operation, body)
+	}
+	if err != nil {
+		return err
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("decode ecs response %s: %w", operation, err)
+		}
+	}
+
+	return nil
+}
+
+// ecsCall signs and sends one ECS request and returns the response body.
+func (c *Client) ecsCall(ctx context.Context, operation string, body []byte) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.ecsEndpointURL(), bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("create ecs request: %w", err)
+		return nil, fmt.Errorf("create ecs request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
 	req.Header.Set("X-Amz-Target", ecsTargetPrefix+operation)
+	req.Header.Set("User-Agent", userAgent(ctx))
 
 	payloadHash := hashSHA256Hex(body)
 	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
 
 	cred, err := c.cfg.Credentials.Retrieve(ctx)
 	if err != nil {
-		return fmt.Errorf("retrieve aws credentials: %w", err)
+		return nil, fmt.Errorf("retrieve aws credentials: %w", err)
 	}
 
 	if err := c.signer.SignHTTP(ctx, cred, req, payloadHash, "ecs", c.region, time.Now().UTC()); err != nil {
-		return fmt.Errorf("sign ecs request %s: %w", operation, err)
+		return nil, fmt.Errorf("sign ecs request %s: %w", operation, err)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("do ecs request %s: %w", operation, err)
+		return nil, fmt.Errorf("do ecs request %s: %w", operation, err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("read ecs response %s: %w", operation, err)
+		return nil, fmt.Errorf("read ecs response %s: %w", operation, err)
 	}
 
 	if resp.StatusCode >= 300 {
-		msg := strings.TrimSpace(string(respBody))
-		if msg == "" {
-			msg = http.StatusText(resp.StatusCode)
-		}
-		return fmt.Errorf("ecs %s failed (%d): %s", operation, resp.StatusCode, msg)
+		return nil, ecsCallError(operation, resp.StatusCode, respBody)
 	}
-
-	if out != nil && len(respBody) > 0 {
-		if err := json.Unmarshal(respBody, out); err != nil {
-			return fmt.Errorf("decode ecs response %s: %w", operation, err)
-		}
-	}
-
-	return nil
+	return respBody, nil
 }
 
 func (c *Client) ecsEndpointURL() string {
@@ -324,6 +650,14 @@ func (c *Client) ecsEndpointURL() string {
 	return fmt.Sprintf("https://ecs.%s.amazonaws.com/", c.region)
 }
 
+func encodeTags(tags map[string]string) string {
+	v := url.Values{}
+	for k, val := range tags {
+		v.Set(k, val)
+	}
+	return v.Encode()
+}
+
 func hashSHA256Hex(b []byte) string {
 	sum := sha256.Sum256(b)
 	return hex.EncodeToString(sum[:])
@@ -335,24 +669,68 @@ type ecsDescribeServicesOutput struct {
 }
 
 type ecsServiceFailure struct {
+	Arn    string `json:"arn"`
 	Reason string `json:"reason"`
+	Detail string `json:"detail"`
 }
 
 type ECSServiceState struct {
-	ServiceName  string          `json:"serviceName"`
-	Status       string          `json:"status"`
-	DesiredCount int32           `json:"desiredCount"`
-	RunningCount int32           `json:"runningCount"`
-	PendingCount int32           `json:"pendingCount"`
-	Deployments  []ecsDeployment `json:"deployments"`
+	ServiceName    string          `json:"serviceName"`
+	Status         string          `json:"status"`
+	TaskDefinition string          `json:"taskDefinition"`
+	DesiredCount   int32           `json:"desiredCount"`
+	RunningCount   int32           `json:"runningCount"`
+	PendingCount   int32           `json:"pendingCount"`
+	Deployments    []ecsDeployment `json:"deployments"`
+
+	LaunchType               string             `json:"launchType"`
+	CapacityProviderStrategy []CapacityProvider `json:"capacityProviderStrategy"`
 }
 
 type ecsDeployment struct {
-	Status       string `json:"status"`
-	RolloutState string `json:"rolloutState"`
-	DesiredCount int32  `json:"desiredCount"`
-	RunningCount int32  `json:"runningCount"`
-	PendingCount int32  `json:"pendingCount"`
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	TaskDefinition string `json:"taskDefinition"`
+	RolloutState   string `json:"rolloutState"`
+	DesiredCount   int32  `json:"desiredCount"`
+	RunningCount   int32  `json:"runningCount"`
+	PendingCount   int32  `json:"pendingCount"`
+	// CreatedAt is epoch seconds, as the ECS JSON API returns timestamps.
+	CreatedAt float64 `json:"createdAt"`
+}
+
+func (d ecsDeployment) created() time.Time {
+	sec := int64(d.CreatedAt)
+	return time.Unix(sec, int64((d.CreatedAt-float64(sec))*1e9)).UTC()
+}
+
+// PrimaryDeployment returns the PRIMARY deployment, if any.
+func (s ECSServiceState) PrimaryDeployment() (ecsDeployment, bool) {
+	for _, d := range s.Deployments {
+		if strings.EqualFold(d.Status, "PRIMARY") {
+			return d, true
+		}
+	}
+	return ecsDeployment{}, false
+}
+
+// PrimaryTaskDefinition returns the task definition ARN of the PRIMARY
+// deployment, falling back to the service-level task definition.
+func (s ECSServiceState) PrimaryTaskDefinition() string {
+	if d, ok := s.PrimaryDeployment(); ok && d.TaskDefinition != "" {
+		return d.TaskDefinition
+	}
+	return s.TaskDefinition
+}
+
+// TaskDefinitionRevision extracts the revision number from a task definition
+// ARN (arn:...:task-definition/family:revision), or "" if absent.
+func TaskDefinitionRevision(arn string) string {
+	i := strings.LastIndex(arn, ":")
+	if i < 0 || i == len(arn)-1 || !strings.Contains(arn, "task-definition/") {
+		return ""
+	}
+	return arn[i+1:]
 }
 
 func (s ECSServiceState) isStable() bool {
@@ -0,0 +1,69 @@
+package awsruntime
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Credentials come from the default provider chain wrapped in the SDK's
+// credentials cache, so every request (each part of a multipart transfer,
+// each ECS call) is signed with credentials retrieved at that moment rather
+// than once per operation. Short-lived role credentials are refreshed
+// credentialExpiryWindow before they expire, leaving room for a request
+// signed just before the deadline to arrive in time. If one is still refused
+// as expired (clock skew, a revoked session), the cache is invalidated and
+// the request retried with fresh credentials.
+
+const credentialExpiryWindow = 5 * time.Minute
+
+// expiredCredentialCodes are the error codes S3 and ECS return for a request
+// signed with credentials that have expired.
+var expiredCredentialCodes = []string{"ExpiredToken", "ExpiredTokenException", "RequestExpired"}
+
+func credentialCacheOptions(o *aws.CredentialsCacheOptions) {
+	o.ExpiryWindow = credentialExpiryWindow
+	// Spread refreshes over the window's second half so replicas don't
+	// all call STS at once.
+	o.ExpiryWindowJitterFrac = 0.5
+}
+
+func isExpiredCredentials(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return slices.Contains(expiredCredentialCodes, apiErr.ErrorCode())
+	}
+	var ecsErr *ECSError
+	if errors.As(err, &ecsErr) {
+		return slices.Contains(expiredCredentialCodes, ecsErr.Reason)
+	}
+	return false
+}
+
+// invalidateCredentials makes the next request retrieve new credentials.
+func (c *Client) invalidateCredentials() {
+	if cache, ok := c.cfg.Credentials.(*aws.CredentialsCache); ok {
+		cache.Invalidate()
+	}
+}
+
+// addCredentialRefresh invalidates the cached credentials when an S3 attempt
+// is refused as expired; the retryer (which treats those codes as
+// retryable) then signs the next attempt with fresh ones. It runs inside the
+// retry loop, once per attempt.
+func (c *Client) addCredentialRefresh(stack *middleware.Stack) error {
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("CredentialRefresh", func(
+		ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler,
+	) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		out, md, err := next.HandleFinalize(ctx, in)
+		if err != nil && isExpiredCredentials(err) {
+			c.invalidateCredentials()
+		}
+		return out, md, err
+	}), middleware.After)
+}
@@ -0,0 +1,172 @@
+package awsruntime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// stubCredentials hands out AKID1, AKID2, ... one per Retrieve.
+type stubCredentials struct {
+	mu        sync.Mutex
+	retrieved int
+}
+
+func (p *stubCredentials) Retrieve(context.Context) (aws.Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retrieved++
+	return aws.Credentials{
+		AccessKeyID:     fmt.Sprintf("AKID%d", p.retrieved),
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+		CanExpire:       true,
+		Expires:         time.Now().Add(time.Hour),
+	}, nil
+}
+
+// stubHTTP answers each request with the next of its responses and records
+// the requests.
+type stubHTTP struct {
+	mu        sync.Mutex
+	responses []func() *http.Response
+	requests  []*http.Request
+}
+
+func (h *stubHTTP) Do(req *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+	}
+	h.requests = append(h.requests, req)
+	if len(h.responses) == 0 {
+		return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}
+	resp := h.responses[0]()
+	h.responses = h.responses[1:]
+	resp.Request = req
+	return resp, nil
+}
+
+func respond(status int, contentType, body string) func() *http.Response {
+	return func() *http.Response {
+		h := http.Header{}
+		if contentType != "" {
+			h.Set("Content-Type", contentType)
+		}
+		return &http.Response{
+			StatusCode:    status,
+			Header:        h,
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+		}
+	}
+}
+
+// signedWith returns the access key id a request was signed with.
+func signedWith(req *http.Request) string {
+	_, cred, _ := strings.Cut(req.Header.Get("Authorization"), "Credential=")
+	id, _, _ := strings.Cut(cred, "/")
+	return id
+}
+
+// newTestClient builds a Client the way New does, but over stubbed
+// credentials and HTTP, with no retry backoff.
+func newTestClient(creds aws.CredentialsProvider, httpClient *stubHTTP) *Client {
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: aws.NewCredentialsCache(creds, credentialCacheOptions),
+		HTTPClient:  httpClient,
+		Retryer: func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			})
+		},
+	}
+	c := &Client{
+		region:      cfg.Region,
+		cfg:         cfg,
+		signer:      v4.NewSigner(),
+		httpClient:  httpClient,
+		ecsEndpoint: "https://ecs.test",
+	}
+	c.s3 = s3.NewFromConfig(cfg, c.s3Options)
+	return c
+}
+
+const expiredTokenXML = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>ExpiredToken</Code><Message>The provided token has expired.</Message></Error>`
+
+func TestS3RetriesExpiredTokenWithFreshCredentials(t *testing.T) {
+	creds := &stubCredentials{}
+	httpClient := &stubHTTP{responses: []func() *http.Response{
+		respond(http.StatusBadRequest, "application/xml", expiredTokenXML),
+		respond(http.StatusNoContent, "", ""),
+	}}
+	c := newTestClient(creds, httpClient)
+
+	if err := c.DeleteObject(context.Background(), "bucket", "backups/survival/a.tar.gz"); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if len(httpClient.requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(httpClient.requests))
+	}
+	if creds.retrieved != 2 {
+		t.Fatalf("credentials retrieved %d times, want 2 (cache not invalidated)", creds.retrieved)
+	}
+	if got := signedWith(httpClient.requests[0]); got != "AKID1" {
+		t.Fatalf("first attempt signed with %q, want AKID1", got)
+	}
+	if got := signedWith(httpClient.requests[1]); got != "AKID2" {
+		t.Fatalf("retry signed with %q, want AKID2", got)
+	}
+}
+
+func TestECSRetriesExpiredTokenWithFreshCredentials(t *testing.T) {
+	creds := &stubCredentials{}
+	httpClient := &stubHTTP{responses: []func() *http.Response{
+		respond(http.StatusBadRequest, "application/x-amz-json-1.1",
+			`{"__type":"com.amazon.coral.service#ExpiredTokenException","message":"The security token included in the request is expired"}`),
+		respond(http.StatusOK, "application/x-amz-json-1.1", `{}`),
+	}}
+	c := newTestClient(creds, httpClient)
+
+	if err := c.SetServiceDesiredCount(context.Background(), "games", "survival", 0, ScaleOptions{}); err != nil {
+		t.Fatalf("SetServiceDesiredCount: %v", err)
+	}
+	if len(httpClient.requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(httpClient.requests))
+	}
+	if creds.retrieved != 2 {
+		t.Fatalf("credentials retrieved %d times, want 2 (cache not invalidated)", creds.retrieved)
+	}
+	if got := signedWith(httpClient.requests[1]); got != "AKID2" {
+		t.Fatalf("retry signed with %q, want AKID2", got)
+	}
+}
+
+func TestS3DoesNotRefreshOnOtherErrors(t *testing.T) {
+	creds := &stubCredentials{}
+	httpClient := &stubHTTP{responses: []func() *http.Response{
+		respond(http.StatusForbidden, "application/xml", `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`),
+	}}
+	c := newTestClient(creds, httpClient)
+
+	if err := c.DeleteObject(context.Background(), "bucket", "backups/survival/a.tar.gz"); err == nil {
+		t.Fatal("DeleteObject succeeded on AccessDenied")
+	}
+	if creds.retrieved != 1 {
+		t.Fatalf("credentials retrieved %d times, want 1", creds.retrieved)
+	}
+}
@@ -0,0 +1,104 @@
+package awsruntime
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// ECS reports problems two ways: per-resource entries in a response's
+// failures list (reason MISSING, ACCESS_DENIED, ...) and whole-call errors
+// whose JSON body names an exception type. Both are turned into ECSError so
+// the known cases unwrap to a domain error the API can map to a status.
+
+// ErrServiceNotFound is returned by DescribeService when the service doesn't
+// exist or has been deleted (INACTIVE).
+var ErrServiceNotFound = domain.ErrECSServiceNotFound
+
+// ECSError is a failure reported by the ECS API.
+type ECSError struct {
+	Operation string
+	// Reason is the failure reason (e.g. "MISSING") or the exception type
+	// (e.g. "AccessDeniedException").
+	Reason string
+	Detail string
+	// Status is the HTTP status of a whole-call error, 0 for an entry in a
+	// response's failures list.
+	Status int
+
+	kind error
+}
+
+func (e *ECSError) Error() string {
+	msg := fmt.Sprintf("ecs %s failed", e.Operation)
+	if e.Status != 0 {
+		msg += fmt.Sprintf(" (%d)", e.Status)
+	}
+	msg += ": " + e.Reason
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Unwrap returns the domain error for a known reason, nil otherwise.
+func (e *ECSError) Unwrap() error { return e.kind }
+
+// ecsErrorKinds maps failure reasons and exception types to domain errors.
+// Keys are lower case; exception types drop any "namespace#" prefix.
+var ecsErrorKinds = map[string]error{
+	"missing":                     domain.ErrECSServiceNotFound,
+	"inactive":                    domain.ErrECSServiceNotFound,
+	"servicenotfoundexception":    domain.ErrECSServiceNotFound,
+	"servicenotactiveexception":   domain.ErrECSServiceNotFound,
+	"clusternotfoundexception":    domain.ErrECSClusterNotFound,
+	"access_denied":               domain.ErrECSAccessDenied,
+	"accessdenied":                domain.ErrECSAccessDenied,
+	"accessdeniedexception":       domain.ErrECSAccessDenied,
+	"unrecognizedclientexception": domain.ErrECSAccessDenied,
+}
+
+func ecsErrorKind(reason string) error {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	if i := strings.LastIndexByte(reason, '#'); i >= 0 {
+		reason = reason[i+1:]
+	}
+	return ecsErrorKinds[reason]
+}
+
+// ecsFailure builds the error for an entry in a response's failures list.
+func ecsFailure(operation string, f ecsServiceFailure) *ECSError {
+	reason := strings.TrimSpace(f.Reason)
+	if reason == "" {
+		reason = "unknown failure"
+	}
+	detail := strings.TrimSpace(f.Detail)
+	if detail == "" {
+		detail = f.Arn
+	}
+	return &ECSError{Operation: operation, Reason: reason, Detail: detail, kind: ecsErrorKind(reason)}
+}
+
+// ecsCallError builds the error for a non-2xx ECS response.
+func ecsCallError(operation string, status int, body []byte) *ECSError {
+	var parsed struct {
+		Type       string `json:"__type"`
+		Message    string `json:"message"`
+		MessageAlt string `json:"Message"`
+	}
+	e := &ECSError{Operation: operation, Status: status}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Type != "" {
+		e.Reason = parsed.Type[strings.LastIndexByte(parsed.Type, '#')+1:]
+		e.Detail = strings.TrimSpace(parsed.Message + parsed.MessageAlt)
+		e.kind = ecsErrorKind(parsed.Type)
+		return e
+	}
+	e.Reason = strings.TrimSpace(string(body))
+	if e.Reason == "" {
+		e.Reason = http.StatusText(status)
+	}
+	return e
+}
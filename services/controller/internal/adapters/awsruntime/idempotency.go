@@ -0,0 +1,118 @@
+package awsruntime
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// Idempotency of the ECS/S3 calls this package makes:
+//
+//   - UpdateService with forceNewDeployment (SetServiceDesiredCount scaling
+//     up, ForceNewDeployment) has no client token in the ECS API. A retry
+//     would roll a second deployment, so calls are keyed by the request id:
+//     a repeat of a token that already succeeded is skipped, and a repeat
+//     after an ambiguous failure first checks whether ECS already started a
+//     deployment since the first attempt.
+//   - UpdateService without forceNewDeployment (scale to 0) sets absolute
+//     state and is safe to repeat. It also forgets the service's last
+//     deployment, so a start → stop → start under one request id deploys
+//     again instead of being skipped as a repeat.
+//   - DescribeServices and S3 reads are read-only; S3 puts and copies
+//     overwrite by key.
+//
+// The controller's operation lock already keeps two operations from racing
+// on the same service; this only covers a client retrying the same request
+// (same X-Request-Id) after a timeout, so attempts are only remembered for
+// deployGuardTTL.
+
+const deployGuardTTL = 5 * time.Minute
+
+type deployAttempt struct {
+	token   string
+	desired int32 // -1 when the call leaves the desired count alone
+	at      time.Time
+	done    bool
+}
+
+// deployGuard remembers the last deployment-triggering call per service.
+type deployGuard struct {
+	mu       sync.Mutex
+	attempts map[string]deployAttempt
+}
+
+// once runs deploy unless the request in ctx already triggered this exact
+// deployment. check reports whether ECS shows a deployment created at or
+// after the given time, for retries of an attempt whose outcome is unknown.
+func (g *deployGuard) once(ctx context.Context, serviceKey string, desired int32, check func(since time.Time) bool, deploy func() error) error {
+	token := domain.RequestID(ctx)
+	if token == "" {
+		return deploy()
+	}
+
+	g.mu.Lock()
+	if g.attempts == nil {
+		g.attempts = map[string]deployAttempt{}
+	}
+	prev, seen := g.attempts[serviceKey]
+	if seen && time.Since(prev.at) > deployGuardTTL {
+		delete(g.attempts, serviceKey)
+		seen = false
+	}
+	g.mu.Unlock()
+
+	if seen && prev.token == token && prev.desired == desired {
+		if prev.done || check(prev.at) {
+			g.finish(serviceKey, prev)
+			return nil
+		}
+	}
+
+	attempt := deployAttempt{token: token, desired: desired, at: time.Now().UTC()}
+	g.mu.Lock()
+	g.attempts[serviceKey] = attempt
+	g.mu.Unlock()
+
+	if err := deploy(); err != nil {
+		return err
+	}
+	g.finish(serviceKey, attempt)
+	return nil
+}
+
+// forget drops the service's remembered deployment, once something else
+// has changed the service since.
+func (g *deployGuard) forget(serviceKey string) {
+	g.mu.Lock()
+	delete(g.attempts, serviceKey)
+	g.mu.Unlock()
+}
+
+func (g *deployGuard) finish(serviceKey string, attempt deployAttempt) {
+	attempt.done = true
+	g.mu.Lock()
+	g.attempts[serviceKey] = attempt
+	g.mu.Unlock()
+}
+
+// deployedSince is the check for deployGuard.once: the service has a
+// deployment created at or after since (with the desired count, if set).
+func (c *Client) deployedSince(ctx context.Context, cluster, service string, desired int32) func(time.Time) bool {
+	return func(since time.Time) bool {
+		st, err := c.DescribeService(ctx, cluster, service)
+		if err != nil {
+			return false
+		}
+		if desired >= 0 && st.DesiredCount != desired {
+			return false
+		}
+		for _, d := range st.Deployments {
+			if !d.created().Before(since.Add(-time.Second)) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,57 @@
+package awsruntime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+func TestDeployGuardRedeploysAfterScaleDown(t *testing.T) {
+	var g deployGuard
+	ctx := domain.WithRequestID(context.Background(), "rid-1")
+	deploys := 0
+	deploy := func() error { deploys++; return nil }
+	never := func(time.Time) bool { return false }
+
+	if err := g.once(ctx, "c/s", 1, never, deploy); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.once(ctx, "c/s", 1, never, deploy); err != nil {
+		t.Fatal(err)
+	}
+	if deploys != 1 {
+		t.Fatalf("repeat of the same request deployed %d times, want 1", deploys)
+	}
+
+	// A scale to 0 in between makes the next start a new deployment.
+	g.forget("c/s")
+	if err := g.once(ctx, "c/s", 1, never, deploy); err != nil {
+		t.Fatal(err)
+	}
+	if deploys != 2 {
+		t.Fatalf("start after a stop deployed %d times in total, want 2", deploys)
+	}
+}
+
+func TestDeployGuardExpiresAttempts(t *testing.T) {
+	var g deployGuard
+	ctx := domain.WithRequestID(context.Background(), "rid-1")
+	deploys := 0
+	deploy := func() error { deploys++; return nil }
+
+	if err := g.once(ctx, "c/s", 1, func(time.Time) bool { return false }, deploy); err != nil {
+		t.Fatal(err)
+	}
+	attempt := g.attempts["c/s"]
+	attempt.at = attempt.at.Add(-deployGuardTTL - time.Second)
+	g.attempts["c/s"] = attempt
+
+	if err := g.once(ctx, "c/s", 1, func(time.Time) bool { return true }, deploy); err != nil {
+		t.Fatal(err)
+	}
+	if deploys != 2 {
+		t.Fatalf("expired attempt was still treated as a repeat (%d deploys)", deploys)
+	}
+}
@@ -0,0 +1,67 @@
+package awsruntime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// EphemeralStorage is the task's ephemeral storage usage as reported by the
+// ECS task metadata endpoint (Fargate platform 1.4+), in MiB.
+type EphemeralStorage struct {
+	ReservedMiB int64 `json:"reserved_mib"`
+	UtilizedMiB int64 `json:"utilized_mib"`
+}
+
+func (s EphemeralStorage) AvailableBytes() int64 {
+	return (s.ReservedMiB - s.UtilizedMiB) << 20
+}
+
+// TaskEphemeralStorage queries ECS_CONTAINER_METADATA_URI_V4/task. ok is false
+// when not running on ECS or when the platform doesn't report storage metrics.
+func TaskEphemeralStorage(ctx context.Context) (storage EphemeralStorage, ok bool, err error) {
+	base := strings.TrimSpace(os.Getenv("ECS_CONTAINER_METADATA_URI_V4"))
+	if base == "" {
+		return EphemeralStorage{}, false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+"/task", nil)
+	if err != nil {
+		return EphemeralStorage{}, false, fmt.Errorf("create task metadata request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return EphemeralStorage{}, false, fmt.Errorf("get task metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return EphemeralStorage{}, false, fmt.Errorf("get task metadata: status %d", resp.StatusCode)
+	}
+
+	var out struct {
+		EphemeralStorageMetrics *struct {
+			Utilized int64 `json:"Utilized"`
+			Reserved int64 `json:"Reserved"`
+		} `json:"EphemeralStorageMetrics"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&out); err != nil {
+		return EphemeralStorage{}, false, fmt.Errorf("decode task metadata: %w", err)
+	}
+	if out.EphemeralStorageMetrics == nil {
+		return EphemeralStorage{}, false, nil
+	}
+
+	return EphemeralStorage{
+		ReservedMiB: out.EphemeralStorageMetrics.Reserved,
+		UtilizedMiB: out.EphemeralStorageMetrics.Utilized,
+	}, true, nil
+}
@@ -0,0 +1,172 @@
+package awsruntime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// multipartThreshold is the file size above which UploadFile switches to
+	// a multipart upload (a single PutObject tops out at 5 GiB and can't
+	// resume).
+	multipartThreshold = 128 << 20
+	multipartPartSize  = 32 << 20
+
+	// copyObjectLimit is the largest object a single CopyObject accepts;
+	// bigger ones are copied in multipartCopyPartSize ranges.
+	copyObjectLimit       = 5 << 30
+	multipartCopyPartSize = 512 << 20
+)
+
+// uploadMultipart uploads f in multipartPartSize parts.
+func (c *Client) uploadMultipart(ctx context.Context, bucket, key string, f *os.File, size int64, opts PutOptions) error {
+	create := &s3.CreateMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		Metadata: objectMetadata(ctx, opts),
+	}
+	if opts.StorageClass != "" {
+		create.StorageClass = s3types.StorageClass(opts.StorageClass)
+	}
+	return c.runMultipart(ctx, create, size, multipartPartSize, func(ctx context.Context, uploadID *string, n int32, offset, length int64) (*string, error) {
+		out, err := c.s3.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(bucket),
+			Key:           aws.String(key),
+			UploadId:      uploadID,
+			PartNumber:    aws.Int32(n),
+			Body:          io.NewSectionReader(f, offset, length),
+			ContentLength: aws.Int64(length),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("s3 upload part %d of s3://%s/%s: %w", n, bucket, key, err)
+		}
+		return out.ETag, nil
+	})
+}
+
+// copyMultipart server-side copies an object too large for a single
+// CopyObject, one byte range per part. Unlike CopyObject, a multipart copy
+// doesn't carry the source's metadata over, so its checksum is passed on.
+func (c *Client) copyMultipart(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, head ObjectHead, tags map[string]string) error {
+	size := head.Size
+	create := &s3.CreateMultipartUploadInput{
+		Bucket:   aws.String(dstBucket),
+		Key:      aws.String(dstKey),
+		Metadata: objectMetadata(ctx, PutOptions{SHA256: head.Metadata[checksumMetadataKey]}),
+	}
+	if len(tags) > 0 {
+		create.Tagging = aws.String(encodeTags(tags))
+	}
+	source := aws.String(url.PathEscape(srcBucket + "/" + srcKey))
+	return c.runMultipart(ctx, create, size, multipartCopyPartSize, func(ctx context.Context, uploadID *string, n int32, offset, length int64) (*string, error) {
+		out, err := c.s3.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:          aws.String(dstBucket),
+			Key:             aws.String(dstKey),
+			UploadId:        uploadID,
+			PartNumber:      aws.Int32(n),
+			CopySource:      source,
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("s3 copy part %d of s3://%s/%s -> s3://%s/%s: %w", n, srcBucket, srcKey, dstBucket, dstKey, err)
+		}
+		if out.CopyPartResult == nil {
+			return nil, fmt.Errorf("s3 copy part %d of s3://%s/%s: empty result", n, srcBucket, srcKey)
+		}
+		return out.CopyPartResult.ETag, nil
+	})
+}
+
+// partFunc transfers one part and returns its ETag.
+type partFunc func(ctx context.Context, uploadID *string, n int32, offset, length int64) (*string, error)
+
+// runMultipart creates a multipart upload, runs part for each partSize slice
+// of size bytes, up to partConcurrency at a time, and completes it. Any
+// failure aborts the upload so no orphaned parts are left billing.
+func (c *Client) runMultipart(ctx context.Context, create *s3.CreateMultipartUploadInput, size, partSize int64, part partFunc) error {
+	bucket, key := aws.ToString(create.Bucket), aws.ToString(create.Key)
+	var started *s3.CreateMultipartUploadOutput
+	err := c.putWithACL(func(acl s3types.ObjectCannedACL) error {
+		create.ACL = acl
+		var err error
+		started, err = c.s3.CreateMultipartUpload(ctx, create)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("s3 create multipart upload s3://%s/%s: %w", bucket, key, err)
+	}
+	uploadID := started.UploadId
+
+	abort := func(cause error) error {
+		_, _ = c.s3.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			UploadId: uploadID,
+		})
+		return cause
+	}
+
+	partCount := int((size + partSize - 1) / partSize)
+	parts := make([]s3types.CompletedPart, partCount)
+	partCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		next     = make(chan int32)
+	)
+	for range max(1, min(c.partConcurrency, partCount)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range next {
+				offset := int64(n-1) * partSize
+				etag, err := part(partCtx, uploadID, n, offset, min(partSize, size-offset))
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				parts[n-1] = s3types.CompletedPart{ETag: etag, PartNumber: aws.Int32(n)}
+			}
+		}()
+	}
+feed:
+	for n := int32(1); int(n) <= partCount; n++ {
+		select {
+		case next <- n:
+		case <-partCtx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		return abort(firstErr)
+	}
+
+	if _, err := c.s3.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: parts},
+	}); err != nil {
+		return abort(fmt.Errorf("s3 complete multipart upload s3://%s/%s: %w", bucket, key, err))
+	}
+	return nil
+}
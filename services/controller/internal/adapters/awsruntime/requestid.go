@@ -0,0 +1,49 @@
+package awsruntime
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// ECS and S3 have no client token on the calls we make, so the controller's
+// request id travels in the User-Agent (recorded by CloudTrail) and, for
+// uploads, in the object's metadata.
+
+const requestIDMetadataKey = "request-id"
+
+// userAgent is the User-Agent for hand-signed ECS calls.
+func userAgent(ctx context.Context) string {
+	ua := "game-infra-controller"
+	if rid := domain.RequestID(ctx); rid != "" {
+		ua += " rid/" + rid
+	}
+	return ua
+}
+
+// addRequestIDUserAgent appends rid/<id> to the SDK's User-Agent. It runs
+// after the SDK's own user agent middleware and before signing.
+func addRequestIDUserAgent(stack *middleware.Stack) error {
+	return stack.Build.Add(middleware.BuildMiddlewareFunc("RequestIDUserAgent", func(
+		ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler,
+	) (middleware.BuildOutput, middleware.Metadata, error) {
+		if rid := domain.RequestID(ctx); rid != "" {
+			if req, ok := in.Request.(*smithyhttp.Request); ok {
+				req.Header.Set("User-Agent", strings.TrimSpace(req.Header.Get("User-Agent")+" rid/"+rid))
+			}
+		}
+		return next.HandleBuild(ctx, in)
+	}), middleware.After)
+}
+
+// requestMetadata tags uploaded objects with the request that wrote them.
+func requestMetadata(ctx context.Context) map[string]string {
+	if rid := domain.RequestID(ctx); rid != "" {
+		return map[string]string{requestIDMetadataKey: rid}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package awsruntime
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+func TestRequestIDReachesS3Request(t *testing.T) {
+	httpClient := &stubHTTP{responses: []func() *http.Response{
+		respond(http.StatusOK, "", ""),
+	}}
+	c := newTestClient(&stubCredentials{}, httpClient)
+	ctx := domain.WithRequestID(context.Background(), "rid-42")
+
+	if err := c.PutString(ctx, "bucket", "backups/survival/marker.json", "{}"); err != nil {
+		t.Fatalf("PutString: %v", err)
+	}
+	req := httpClient.requests[0]
+	if ua := req.Header.Get("User-Agent"); !strings.HasSuffix(ua, " rid/rid-42") {
+		t.Fatalf("User-Agent = %q, want it to end in rid/rid-42", ua)
+	}
+	if got := req.Header.Get("X-Amz-Meta-" + requestIDMetadataKey); got != "rid-42" {
+		t.Fatalf("request id metadata = %q, want rid-42", got)
+	}
+	if auth := req.Header.Get("Authorization"); !strings.Contains(auth, "x-amz-meta-"+requestIDMetadataKey) {
+		t.Fatalf("request id metadata not signed: %s", auth)
+	}
+}
+
+func TestRequestIDReachesECSRequest(t *testing.T) {
+	httpClient := &stubHTTP{responses: []func() *http.Response{
+		respond(http.StatusOK, "application/x-amz-json-1.1", `{}`),
+	}}
+	c := newTestClient(&stubCredentials{}, httpClient)
+	ctx := domain.WithRequestID(context.Background(), "rid-42")
+
+	if err := c.SetServiceDesiredCount(ctx, "games", "survival", 0, ScaleOptions{}); err != nil {
+		t.Fatalf("SetServiceDesiredCount: %v", err)
+	}
+	if ua := httpClient.requests[0].Header.Get("User-Agent"); ua != "game-infra-controller rid/rid-42" {
+		t.Fatalf("User-Agent = %q", ua)
+	}
+}
+
+func TestNoRequestIDLeavesUserAgent(t *testing.T) {
+	httpClient := &stubHTTP{responses: []func() *http.Response{
+		respond(http.StatusNoContent, "", ""),
+	}}
+	c := newTestClient(&stubCredentials{}, httpClient)
+
+	if err := c.DeleteObject(context.Background(), "bucket", "backups/survival/a.tar.gz"); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if ua := httpClient.requests[0].Header.Get("User-Agent"); strings.Contains(ua, "rid/") {
+		t.Fatalf("User-Agent = %q, want no rid/", ua)
+	}
+}
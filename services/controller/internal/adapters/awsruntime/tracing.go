@@ -0,0 +1,29 @@
+package awsruntime
+
+import (
+	"context"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/esuEdu/game-infra/controller/internal/tracing"
+)
+
+// addTracing wraps every SDK call, retries included, in a span named like
+// "S3.PutObject". It sits at the end of the initialize step, after the SDK
+// has registered the service and operation names.
+func addTracing(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("Tracing", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (out middleware.InitializeOutput, md middleware.Metadata, err error) {
+		service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+		ctx, span := tracing.Start(ctx, service+"."+operation,
+			attribute.String("rpc.system", "aws-api"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", operation),
+		)
+		defer func() { tracing.End(span, err) }()
+		return next.HandleInitialize(ctx, in)
+	}), middleware.After)
+}
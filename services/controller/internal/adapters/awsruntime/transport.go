@@ -0,0 +1,72 @@
+package awsruntime
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
+)
+
+// TransportOptions tunes the HTTP client shared by the S3 and ECS calls. The
+// SDK defaults (10 idle connections per host, 4 KiB socket buffers) are
+// sized for many small API calls; these favour a few multi-GB transfers.
+// Every knob is read from the environment by New:
+//
+//	AWS_HTTP_MAX_IDLE_CONNS       idle connections kept open, all hosts (100)
+//	AWS_HTTP_MAX_CONNS_PER_HOST   cap on open connections per host, unset = none
+//	AWS_HTTP_DIAL_TIMEOUT         TCP connect timeout (10s)
+//	AWS_HTTP_TLS_TIMEOUT          TLS handshake timeout (10s)
+//	AWS_HTTP_RESPONSE_TIMEOUT     wait for response headers after a request
+//	                              body is sent (60s); bodies themselves are
+//	                              bounded only by the caller's context
+//	AWS_HTTP_IDLE_TIMEOUT         how long an idle connection is kept (90s)
+//	S3_TRANSFER_CONCURRENCY       parts uploaded in parallel per multipart
+//	                              upload (4)
+type TransportOptions struct {
+	MaxIdleConns    int
+	MaxConnsPerHost int
+	DialTimeout     time.Duration
+	TLSTimeout      time.Duration
+	ResponseTimeout time.Duration
+	IdleTimeout     time.Duration
+	PartConcurrency int
+}
+
+// socketBuffer replaces net/http's 4 KiB read/write buffers; larger buffers
+// cut syscalls per part on fast links.
+const socketBuffer = 256 << 10
+
+func TransportOptionsFromEnv() TransportOptions {
+	return TransportOptions{
+		MaxIdleConns:    env.Int("AWS_HTTP_MAX_IDLE_CONNS", 100),
+		MaxConnsPerHost: env.Int("AWS_HTTP_MAX_CONNS_PER_HOST", 0),
+		DialTimeout:     env.Duration("AWS_HTTP_DIAL_TIMEOUT", 10*time.Second),
+		TLSTimeout:      env.Duration("AWS_HTTP_TLS_TIMEOUT", 10*time.Second),
+		ResponseTimeout: env.Duration("AWS_HTTP_RESPONSE_TIMEOUT", 60*time.Second),
+		IdleTimeout:     env.Duration("AWS_HTTP_IDLE_TIMEOUT", 90*time.Second),
+		PartConcurrency: env.Int("S3_TRANSFER_CONCURRENCY", 4),
+	}
+}
+
+// httpClient builds the SDK client. Almost every request goes to the one S3
+// endpoint, so the per-host idle pool is as large as the global one; with
+// the SDK's 10 a burst of parallel parts would keep reconnecting.
+func (o TransportOptions) httpClient() *awshttp.BuildableClient {
+	return awshttp.NewBuildableClient().
+		WithDialerOptions(func(d *net.Dialer) {
+			d.Timeout = o.DialTimeout
+		}).
+		WithTransportOptions(func(tr *http.Transport) {
+			tr.MaxIdleConns = o.MaxIdleConns
+			tr.MaxIdleConnsPerHost = o.MaxIdleConns
+			tr.MaxConnsPerHost = o.MaxConnsPerHost
+			tr.TLSHandshakeTimeout = o.TLSTimeout
+			tr.ResponseHeaderTimeout = o.ResponseTimeout
+			tr.IdleConnTimeout = o.IdleTimeout
+			tr.ReadBufferSize = socketBuffer
+			tr.WriteBufferSize = socketBuffer
+		})
+}
@@ -0,0 +1,363 @@
+// Package ecsgame is the shared plumbing for adapters whose server runs as a
+// single ECS service and whose backups are zips in the backup bucket under
+// <BACKUP_PREFIX>[/<ENV>]/<game>/ with a plain-text latest.txt marker.
+package ecsgame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/archive"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/awsruntime"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/marker"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+type Runtime struct {
+	game       string
+	serviceEnv string
+	log        *slog.Logger
+
+	AWSRegion    string
+	Cluster      string
+	Service      string
+	Bucket       string
+	BackupPrefix string // BACKUP_PREFIX[/ENV], see env.BackupPrefix
+	TmpDir       string
+
+	AllowEmptyBackup bool // BACKUP_ALLOW_EMPTY: upload a backup that captured no files
+
+	CapacityProviders   []awsruntime.CapacityProvider
+	capacityProviderErr error
+	backupPrefixErr     error
+
+	Clock domain.Clock // stamps backup keys
+
+	mu  sync.Mutex
+	aws *awsruntime.Client
+}
+
+// New reads the common settings; serviceEnv names the variable holding the
+// game's ECS service (e.g. ECS_SERVICE_TERRARIA).
+func New(log *slog.Logger, game, serviceEnv string) *Runtime {
+	capacityProviders, capacityProviderErr := awsruntime.ParseCapacityProviderStrategy(os.Getenv("ECS_CAPACITY_PROVIDER_STRATEGY"))
+	backupPrefix, backupPrefixErr := env.BackupPrefix()
+	return &Runtime{
+		CapacityProviders:   capacityProviders,
+		capacityProviderErr: capacityProviderErr,
+		backupPrefixErr:     backupPrefixErr,
+
+		game:         game,
+		serviceEnv:   serviceEnv,
+		log:          log,
+		AWSRegion:    env.OrDefault("AWS_REGION", "us-east-1"),
+		Cluster:      strings.TrimSpace(os.Getenv("ECS_CLUSTER_NAME")),
+		Service:      strings.TrimSpace(os.Getenv(serviceEnv)),
+		Bucket:       strings.TrimSpace(os.Getenv("BACKUP_BUCKET")),
+		BackupPrefix: backupPrefix,
+		TmpDir:       strings.TrimSpace(os.Getenv("TMP_DIR")),
+		Clock:        domain.SystemClock{},
+
+		AllowEmptyBackup: env.Bool("BACKUP_ALLOW_EMPTY", false),
+	}
+}
+
+// Validate returns hard errors for broken settings, then ErrAdapterDegraded
+// when ECS is not configured.
+func (r *Runtime) Validate() error {
+	var errs []error
+	if r.Bucket == "" {
+		errs = append(errs, errors.New("BACKUP_BUCKET is not set; stop, backup and restore will fail"))
+	}
+	if (r.Cluster == "") != (r.Service == "") {
+		errs = append(errs, fmt.Errorf("ECS_CLUSTER_NAME and %s must be set together", r.serviceEnv))
+	}
+	if r.capacityProviderErr != nil {
+		errs = append(errs, fmt.Errorf("ECS_CAPACITY_PROVIDER_STRATEGY: %w", r.capacityProviderErr))
+	}
+	if r.backupPrefixErr != nil {
+		errs = append(errs, fmt.Errorf("backup prefix: %w", r.backupPrefixErr))
+	}
+	if _, err := awsruntime.ObjectACLFromEnv(); err != nil {
+		errs = append(errs, fmt.Errorf("BACKUP_OBJECT_ACL: %w", err))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if !r.ECSConfigured() {
+		return fmt.Errorf("%w: ECS not configured, start/stop only track local state", domain.ErrAdapterDegraded)
+	}
+	return nil
+}
+
+// ecsService is the cluster and service to act on: the operation's override
+// when ctx carries one, else the configured ones.
+func (r *Runtime) ecsService(ctx context.Context) (cluster, service string) {
+	return domain.ECSTargetOr(ctx, r.Cluster, r.Service)
+}
+
+func (r *Runtime) ECSConfigured() bool {
+	return r.Cluster != "" && r.Service != "" && r.AWSRegion != ""
+}
+
+func (r *Runtime) S3Configured() bool {
+	return r.Bucket != "" && r.AWSRegion != "" && r.backupPrefixErr == nil
+}
+
+// SetDesiredCount scales the ECS service and waits for it to settle. It is a
+// no-op when ECS is not configured.
+func (r *Runtime) SetDesiredCount(ctx context.Context, count int32) error {
+	if !r.ECSConfigured() {
+		return nil
+	}
+	cluster, service := r.ecsService(ctx)
+	if err := r.Preflight(ctx); err != nil {
+		return err
+	}
+	awsClient, err := r.AWS(ctx)
+	if err != nil {
+		return err
+	}
+	opts := awsruntime.ScaleOptions{}
+	if count > 0 {
+		if r.capacityProviderErr != nil {
+			return fmt.Errorf("%w: ECS_CAPACITY_PROVIDER_STRATEGY: %v", domain.ErrMisconfigured, r.capacityProviderErr)
+		}
+		opts = awsruntime.ScaleOptions{ForceNewDeployment: true, CapacityProviders: r.CapacityProviders}
+	}
+	if err := awsClient.SetServiceDesiredCount(ctx, cluster, service, count, opts); err != nil {
+		return err
+	}
+	return awsClient.WaitServiceStable(ctx, cluster, service, 10*time.Minute)
+}
+
+// Preflight checks the ECS service exists before anything is changed,
+// unless the operation already did (see domain.WithPreflighted).
+func (r *Runtime) Preflight(ctx context.Context) error {
+	if !r.ECSConfigured() || domain.Preflighted(ctx) {
+		return nil
+	}
+	cluster, service := r.ecsService(ctx)
+	awsClient, err := r.AWS(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := awsClient.DescribeService(ctx, cluster, service); err != nil {
+		if errors.Is(err, awsruntime.ErrServiceNotFound) {
+			return fmt.Errorf("%w: ECS service %q not found in cluster %q (check %s)", domain.ErrMisconfigured, service, cluster, r.serviceEnv)
+		}
+		return fmt.Errorf("ecs pre-flight: %w", err)
+	}
+	return nil
+}
+
+// HealthChecks covers the configured S3 bucket and ECS service, for the
+// adapters' own HealthChecks.
+func (r *Runtime) HealthChecks() map[string]func(ctx context.Context) error {
+	checks := map[string]func(ctx context.Context) error{}
+	if r.S3Configured() {
+		checks["s3"] = func(ctx context.Context) error {
+			awsClient, err := r.AWS(ctx)
+			if err != nil {
+				return err
+			}
+			return awsClient.HeadBucket(ctx, r.Bucket)
+		}
+	}
+	if r.ECSConfigured() {
+		checks["ecs"] = func(ctx context.Context) error {
+			awsClient, err := r.AWS(ctx)
+			if err != nil {
+				return err
+			}
+			_, err = awsClient.DescribeService(ctx, r.Cluster, r.Service)
+			return err
+		}
+	}
+	return checks
+}
+
+// EnsureIdle fails with ErrDataDirInUse while ECS reports running or pending
+// tasks, so a restore never rewrites files under a live server.
+func (r *Runtime) EnsureIdle(ctx context.Context) error {
+	if !r.ECSConfigured() {
+		return nil
+	}
+	cluster, service := r.ecsService(ctx)
+	awsClient, err := r.AWS(ctx)
+	if err != nil {
+		return err
+	}
+	st, err := awsClient.DescribeService(ctx, cluster, service)
+	if err != nil {
+		return fmt.Errorf("check ecs tasks before restore: %w", err)
+	}
+	if st.RunningCount > 0 || st.PendingCount > 0 {
+		return fmt.Errorf("%w (running=%d pending=%d)", domain.ErrDataDirInUse, st.RunningCount, st.PendingCount)
+	}
+	return nil
+}
+
+// TempFile creates an empty temp file in TMP_DIR and returns its path.
+func (r *Runtime) TempFile(pattern string) (string, error) {
+	f, err := os.CreateTemp(r.TmpDir, pattern)
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	path := f.Name()
+	_ = f.Close()
+	return path, nil
+}
+
+// UploadBackup uploads zipPath as a new timestamped backup, moves the latest
+// marker to it, and returns its s3:// URI.
+func (r *Runtime) UploadBackup(ctx context.Context, zipPath string) (string, error) {
+	if !r.S3Configured() {
+		return "", errors.New("s3 backup not configured")
+	}
+	awsClient, err := r.AWS(ctx)
+	if err != nil {
+		return "", err
+	}
+	now := r.Clock.Now()
+	key := r.prefix() + domain.BackupName(now, ".zip")
+	m, err := marker.New(key, zipPath, now)
+	if err != nil {
+		return "", err
+	}
+	if err := awsClient.UploadFile(ctx, r.Bucket, key, zipPath, awsruntime.PutOptions{SHA256: m.SHA256()}); err != nil {
+		return "", fmt.Errorf("upload backup to s3: %w", err)
+	}
+	markerValue, err := m.Encode()
+	if err != nil {
+		return "", err
+	}
+	if err := awsClient.PutString(ctx, r.Bucket, r.prefix()+"latest.txt", markerValue); err != nil {
+		return "", fmt.Errorf("upload latest marker: %w", err)
+	}
+	return fmt.Sprintf("s3://%s/%s", r.Bucket, key), nil
+}
+
+// ZipBackup zips srcDir, keeping the entries include accepts (all when nil),
+// and uploads the zip as a new backup. A zip with no files is refused unless
+// AllowEmptyBackup is set.
+func (r *Runtime) ZipBackup(ctx context.Context, srcDir string, include func(rel string, d fs.DirEntry) bool) (domain.BackupReport, error) {
+	tmpZipPath, err := r.TempFile(r.game + "-backup-*.zip")
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+	defer os.Remove(tmpZipPath)
+
+	stats, err := archive.ZipDirectory(srcDir, tmpZipPath, include)
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+	if stats.Files == 0 && !r.AllowEmptyBackup {
+		return domain.BackupReport{}, fmt.Errorf("%w: nothing to back up in %s (set BACKUP_ALLOW_EMPTY to allow)", domain.ErrEmptyBackup, srcDir)
+	}
+	uri, err := r.UploadBackup(ctx, tmpZipPath)
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+	return domain.BackupReport{Backup: uri, Files: stats.Files, Bytes: stats.Bytes, ArchiveBytes: stats.ArchiveBytes}, nil
+}
+
+// DownloadBackup fetches backupRef (an s3:// URI or a key in the backup
+// bucket) to path and returns its s3:// URI.
+func (r *Runtime) DownloadBackup(ctx context.Context, backupRef, path string) (string, error) {
+	if !r.S3Configured() {
+		return "", errors.New("s3 backup not configured")
+	}
+	bucket, key, err := parseBackupRef(r.Bucket, backupRef)
+	if err != nil {
+		return "", err
+	}
+	// Keys in the backup bucket must be this environment's.
+	if bucket == r.Bucket && !env.KeyUnder(r.BackupPrefix, key) {
+		return "", fmt.Errorf("%w: %s is outside %s/", domain.ErrNoBackupForGame, key, r.BackupPrefix)
+	}
+	awsClient, err := r.AWS(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := awsClient.DownloadFile(ctx, bucket, key, path); err != nil {
+		return "", fmt.Errorf("download backup from s3: %w", err)
+	}
+	return fmt.Sprintf("s3://%s/%s", bucket, key), nil
+}
+
+// LatestBackup reads and validates the latest marker. Plain-text markers
+// from older controllers are still accepted.
+func (r *Runtime) LatestBackup(ctx context.Context) (string, error) {
+	if !r.S3Configured() {
+		return "", errors.New("s3 backup not configured")
+	}
+	awsClient, err := r.AWS(ctx)
+	if err != nil {
+		return "", err
+	}
+	markerKey := r.prefix() + "latest.txt"
+	raw, err := awsClient.GetString(ctx, r.Bucket, markerKey)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(raw) == "" {
+		return "", domain.ErrNoBackupForGame
+	}
+	m, err := marker.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid latest backup marker s3://%s/%s: %w", r.Bucket, markerKey, err)
+	}
+	bucket, key, err := parseBackupRef(r.Bucket, m.Key)
+	if err != nil {
+		return "", fmt.Errorf("parse latest backup marker: %w", err)
+	}
+	return fmt.Sprintf("s3://%s/%s", bucket, key), nil
+}
+
+func (r *Runtime) AWS(ctx context.Context) (*awsruntime.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.aws != nil {
+		return r.aws, nil
+	}
+	client, err := awsruntime.New(ctx, r.AWSRegion)
+	if err != nil {
+		return nil, err
+	}
+	r.aws = client
+	return client, nil
+}
+
+func (r *Runtime) prefix() string {
+	if r.BackupPrefix == "" {
+		return r.game + "/"
+	}
+	return r.BackupPrefix + "/" + r.game + "/"
+}
+
+func parseBackupRef(defaultBucket, backupRef string) (bucket, key string, err error) {
+	ref := strings.TrimSpace(backupRef)
+	if ref == "" {
+		return "", "", errors.New("empty backup ref")
+	}
+	if strings.HasPrefix(ref, domain.LocalBackupScheme) {
+		return "", "", fmt.Errorf("%w: %s is a local path", domain.ErrNotSupported, backupRef)
+	}
+	if trimmed, ok := strings.CutPrefix(ref, "s3://"); ok {
+		bucket, key, ok := strings.Cut(trimmed, "/")
+		if !ok || bucket == "" || key == "" {
+			return "", "", fmt.Errorf("invalid s3 backup ref: %s", backupRef)
+		}
+		return bucket, key, nil
+	}
+	return defaultBucket, ref, nil
+}
@@ -0,0 +1,19 @@
+package ecsgame
+
+import (
+	"testing"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
+)
+
+func TestPrefixStaysUnderBackupPrefix(t *testing.T) {
+	for _, prefix := range []string{"backups", "backups/staging", "custom/deep/prefix"} {
+		r := &Runtime{game: "terraria", BackupPrefix: prefix}
+		if got, want := r.prefix(), prefix+"/terraria/"; got != want {
+			t.Errorf("prefix %q: game prefix = %q, want %q", prefix, got, want)
+		}
+		if !env.KeyUnder(prefix, r.prefix()+"latest.txt") {
+			t.Errorf("prefix %q: %q is outside it", prefix, r.prefix()+"latest.txt")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package env reads adapter settings from the environment.
+package env
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OrDefault returns the trimmed value of key, or fallback when unset.
+func OrDefault(key, fallback string) string {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+	return val
+}
+
+var envNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// BackupPrefix is the S3 key prefix every backup, marker and trash key lives
+// under: BACKUP_PREFIX (default "backups"), then ENV when set, so
+// environments sharing a bucket (backups/staging, backups/prod) never see
+// each other's keys. An ENV that isn't a single lowercase path segment is an
+// error rather than something to clean up, since a guessed prefix could
+// collide with another environment's.
+func BackupPrefix() (string, error) {
+	prefix := BackupRoot()
+	name := strings.TrimSpace(os.Getenv("ENV"))
+	if name == "" {
+		return prefix, nil
+	}
+	if !ValidEnvName(name) {
+		return "", fmt.Errorf("ENV %q must match %s", name, envNamePattern)
+	}
+	if prefix == "" {
+		return name, nil
+	}
+	return prefix + "/" + name, nil
+}
+
+// BackupRoot is BACKUP_PREFIX without ENV: the prefix every environment's
+// keys share.
+func BackupRoot() string {
+	return strings.Trim(OrDefault("BACKUP_PREFIX", "backups"), "/")
+}
+
+// ValidEnvName reports whether name can be an ENV, a single lowercase path
+// segment.
+func ValidEnvName(name string) bool { return envNamePattern.MatchString(name) }
+
+// KeyUnder reports whether key lies under prefix (as returned by
+// BackupPrefix); an empty prefix contains every key. S3 keys are not paths,
+// so a plain prefix match is exact: "a/../b" is a key under "a".
+func KeyUnder(prefix, key string) bool {
+	return prefix == "" || strings.HasPrefix(key, prefix+"/")
+}
+
+// Duration parses key as a positive time.Duration, or returns fallback.
+func Duration(key string, fallback time.Duration) time.Duration {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// Int parses key as a positive int, or returns fallback.
+func Int(key string, fallback int) int {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+// Bool parses key with strconv.ParseBool, or returns fallback.
+func Bool(key string, fallback bool) bool {
+	b, err := strconv.ParseBool(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return fallback
+	}
+	return b
+}
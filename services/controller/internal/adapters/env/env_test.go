@@ -0,0 +1,57 @@
+package env
+
+import "testing"
+
+func TestBackupPrefix(t *testing.T) {
+	tests := []struct {
+		prefix, env string
+		want        string
+		wantErr     bool
+	}{
+		{prefix: "", env: "", want: "backups"},
+		{prefix: "custom", env: "", want: "custom"},
+		{prefix: "/custom/", env: "", want: "custom"},
+		{prefix: "backups", env: "staging", want: "backups/staging"},
+		{prefix: "/", env: "prod", want: "prod"},
+		{prefix: "backups", env: "prod_2", want: "backups/prod_2"},
+
+		{prefix: "backups", env: "..", wantErr: true},
+		{prefix: "backups", env: "../prod", wantErr: true},
+		{prefix: "backups", env: "a/b", wantErr: true},
+		{prefix: "backups", env: "Staging", wantErr: true},
+		{prefix: "backups", env: "-staging", wantErr: true},
+		{prefix: "backups", env: "stag ing", wantErr: true},
+		{prefix: "backups", env: "staging\n", want: "backups/staging"}, // trimmed
+	}
+	for _, tt := range tests {
+		t.Setenv("BACKUP_PREFIX", tt.prefix)
+		t.Setenv("ENV", tt.env)
+		got, err := BackupPrefix()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("BACKUP_PREFIX=%q ENV=%q: error = %v, want error %v", tt.prefix, tt.env, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("BACKUP_PREFIX=%q ENV=%q: prefix = %q, want %q", tt.prefix, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestKeyUnder(t *testing.T) {
+	tests := []struct {
+		prefix, key string
+		want        bool
+	}{
+		{"", "anything", true},
+		{"backups/staging", "backups/staging/minecraft/1.zip", true},
+		{"backups/staging", "backups/staging", false},
+		{"backups/staging", "backups/staging-old/minecraft/1.zip", false},
+		{"backups/staging", "backups/prod/minecraft/1.zip", false},
+		{"backups/staging", "other/backups/staging/minecraft/1.zip", false},
+	}
+	for _, tt := range tests {
+		if got := KeyUnder(tt.prefix, tt.key); got != tt.want {
+			t.Errorf("KeyUnder(%q, %q) = %v, want %v", tt.prefix, tt.key, got, tt.want)
+		}
+	}
+}
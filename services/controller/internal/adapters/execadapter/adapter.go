@@ -0,0 +1,258 @@
+// Package execadapter implements game adapters from declarative shell command
+// templates, so a game can be onboarded without writing Go.
+package execadapter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/archive"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/ecsgame"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// maxOutput caps how much command output is kept.
+const maxOutput = 64 << 10
+
+type Adapter struct {
+	log        *slog.Logger
+	spec       Spec
+	rt         *ecsgame.Runtime
+	mu         sync.Mutex
+	running    bool
+	lastBackup string
+}
+
+// NewAdapter builds an adapter from a spec returned by LoadFile.
+func NewAdapter(log *slog.Logger, spec Spec) *Adapter {
+	return &Adapter{
+		log:  log.With("game", spec.Name),
+		spec: spec,
+		rt:   ecsgame.New(log, spec.Name, ""),
+	}
+}
+
+func (a *Adapter) Type() domain.GameType { return domain.GameType(a.spec.Name) }
+
+func (a *Adapter) Validate() error {
+	var errs []error
+	if !a.rt.S3Configured() {
+		errs = append(errs, errors.New("BACKUP_BUCKET is not set; stop, backup and restore will fail"))
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		errs = append(errs, errors.New("sh not found"))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if a.spec.templates["command"] == nil {
+		return fmt.Errorf("%w: no command template", domain.ErrAdapterDegraded)
+	}
+	return nil
+}
+
+func (a *Adapter) Start(ctx context.Context) error {
+	if _, err := a.run(ctx, "start", ""); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.running = true
+	a.mu.Unlock()
+	a.log.Info("exec adapter start")
+	return nil
+}
+
+func (a *Adapter) Stop(ctx context.Context) error {
+	if _, err := a.run(ctx, "stop", ""); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.running = false
+	a.mu.Unlock()
+	a.log.Info("exec adapter stop")
+	return nil
+}
+
+func (a *Adapter) Backup(ctx context.Context) (string, error) {
+	report, err := a.BackupReport(ctx)
+	return report.Backup, err
+}
+
+// BackupReport runs the optional backup hook, then zips the data dir to S3.
+func (a *Adapter) BackupReport(ctx context.Context) (domain.BackupReport, error) {
+	if a.spec.templates["backup"] != nil {
+		if _, err := a.run(ctx, "backup", ""); err != nil {
+			return domain.BackupReport{}, err
+		}
+	}
+
+	report, err := a.rt.ZipBackup(ctx, a.spec.DataDir, nil)
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+
+	a.mu.Lock()
+	a.lastBackup = report.Backup
+	a.mu.Unlock()
+	a.log.Info("exec adapter backup complete", "backup", report.Backup,
+		"files", report.Files, "bytes", report.Bytes, "archive_bytes", report.ArchiveBytes)
+	return report, nil
+}
+
+// WorldModTime is the newest modification time in the data dir, or zero when
+// it is empty.
+func (a *Adapter) WorldModTime(ctx context.Context) (time.Time, error) {
+	return archive.NewestModTime(a.spec.DataDir, nil)
+}
+
+func (a *Adapter) Restore(ctx context.Context, backupKey string) error {
+	tmpZipPath, err := a.rt.TempFile(a.spec.Name + "-restore-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpZipPath)
+
+	backup, err := a.rt.DownloadBackup(ctx, backupKey, tmpZipPath)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(a.spec.DataDir); err != nil {
+		return fmt.Errorf("clear data dir: %w", err)
+	}
+	if err := os.MkdirAll(a.spec.DataDir, 0o755); err != nil {
+		return fmt.Errorf("create data dir: %w", err)
+	}
+	if err := archive.UnzipToDirectory(tmpZipPath, a.spec.DataDir); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.lastBackup = backup
+	a.mu.Unlock()
+	a.log.Info("exec adapter restore complete", "backup", backup)
+	return nil
+}
+
+func (a *Adapter) LatestBackup(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	lastBackup := a.lastBackup
+	a.mu.Unlock()
+	if lastBackup != "" {
+		return lastBackup, nil
+	}
+	return a.rt.LatestBackup(ctx)
+}
+
+func (a *Adapter) SeedFromSource(ctx context.Context, sourceURL string) error {
+	return domain.ErrNotSupported
+}
+
+func (a *Adapter) SyncToSource(ctx context.Context, sourceURL string) error {
+	return domain.ErrNotSupported
+}
+
+// Capabilities: commands need a command template in the spec.
+func (a *Adapter) Capabilities() domain.Capabilities {
+	return domain.Capabilities{
+		Backup:  a.rt.S3Configured(),
+		Command: a.spec.templates["command"] != nil,
+	}
+}
+
+func (a *Adapter) SendCommand(ctx context.Context, command string) error {
+	_, err := a.SendCommandOutput(ctx, command)
+	return err
+}
+
+func (a *Adapter) SendCommandOutput(ctx context.Context, command string) (string, error) {
+	if a.spec.templates["command"] == nil {
+		return "", domain.ErrNotSupported
+	}
+	return a.run(ctx, "command", command)
+}
+
+func (a *Adapter) Status(ctx context.Context) (map[string]any, error) {
+	a.mu.Lock()
+	running := a.running
+	lastBackup := a.lastBackup
+	a.mu.Unlock()
+
+	return map[string]any{
+		"adapter":     "exec",
+		"ready":       true,
+		"running":     running,
+		"last_backup": lastBackup,
+		"data_dir":    a.spec.DataDir,
+		"timeout":     a.spec.timeout.String(),
+	}, nil
+}
+
+// run renders the named template and executes it with sh in the data dir,
+// killed after the spec's timeout. Only PATH and HOME are passed through.
+func (a *Adapter) run(ctx context.Context, name, command string) (string, error) {
+	tmpl := a.spec.templates[name]
+	if tmpl == nil {
+		return "", fmt.Errorf("exec adapter %s: no %s template", a.spec.Name, name)
+	}
+	script, err := render(tmpl, templateData{
+		Game:    a.spec.Name,
+		DataDir: a.spec.DataDir,
+		Command: command,
+	})
+	if err != nil {
+		return "", fmt.Errorf("render %s template: %w", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.spec.timeout)
+	defer cancel()
+
+	if err := os.MkdirAll(a.spec.DataDir, 0o755); err != nil {
+		return "", fmt.Errorf("prepare data dir: %w", err)
+	}
+	c := exec.CommandContext(ctx, "sh", "-c", script)
+	c.Dir = a.spec.DataDir
+	c.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + os.Getenv("HOME")}
+	c.WaitDelay = 5 * time.Second
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &limitedWriter{w: &stdout, n: maxOutput}
+	c.Stderr = &limitedWriter{w: &stderr, n: maxOutput}
+
+	if err := c.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s timed out after %s", name, a.spec.timeout)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return stdout.String(), fmt.Errorf("%s failed: %s", name, msg)
+	}
+	return stdout.String(), nil
+}
+
+// limitedWriter keeps the first n bytes and discards the rest without failing
+// the command.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.n > 0 {
+		keep := min(len(p), l.n)
+		if _, err := l.w.Write(p[:keep]); err != nil {
+			return 0, err
+		}
+		l.n -= keep
+	}
+	return len(p), nil
+}
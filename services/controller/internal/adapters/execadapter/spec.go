@@ -0,0 +1,136 @@
+package execadapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Spec declares one game driven by shell commands. It is read from the JSON
+// array in EXEC_ADAPTERS_FILE, e.g.
+//
+//	[{
+//	  "name": "factorio",
+//	  "data_dir": "/srv/factorio",
+//	  "start": "docker start factorio",
+//	  "stop": "docker stop factorio",
+//	  "backup": "docker exec factorio rcon /server-save",
+//	  "command": "docker exec factorio rcon {{.Command}}",
+//	  "timeout": "2m"
+//	}]
+//
+// Templates see .Game, .DataDir and (for command) .Command. Every value is
+// already shell-quoted, so templates must not add their own quotes around
+// them. "backup" is an optional hook run before the data dir is zipped.
+type Spec struct {
+	Name    string `json:"name"`
+	DataDir string `json:"data_dir"`
+	Start   string `json:"start"`
+	Stop    string `json:"stop"`
+	Backup  string `json:"backup"`
+	Command string `json:"command"`
+	Timeout string `json:"timeout"`
+
+	timeout   time.Duration
+	templates map[string]*template.Template
+}
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9-]{0,31}$`)
+
+const defaultTimeout = 2 * time.Minute
+
+// LoadFile reads and validates the specs in path.
+func LoadFile(path string) ([]Spec, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read exec adapters: %w", err)
+	}
+	var specs []Spec
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&specs); err != nil {
+		return nil, fmt.Errorf("parse exec adapters %s: %w", path, err)
+	}
+
+	var errs []error
+	for i := range specs {
+		if err := specs[i].compile(); err != nil {
+			errs = append(errs, fmt.Errorf("exec adapter %d (%s): %w", i, specs[i].Name, err))
+		}
+	}
+	return specs, errors.Join(errs...)
+}
+
+func (s *Spec) compile() error {
+	if !validName.MatchString(s.Name) {
+		return fmt.Errorf("name must match %s", validName)
+	}
+	if strings.TrimSpace(s.DataDir) == "" {
+		return errors.New("data_dir is required")
+	}
+	if strings.TrimSpace(s.Start) == "" || strings.TrimSpace(s.Stop) == "" {
+		return errors.New("start and stop are required")
+	}
+
+	s.timeout = defaultTimeout
+	if s.Timeout != "" {
+		d, err := time.ParseDuration(s.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", s.Timeout)
+		}
+		s.timeout = d
+	}
+
+	s.templates = map[string]*template.Template{}
+	for name, text := range map[string]string{
+		"start":   s.Start,
+		"stop":    s.Stop,
+		"backup":  s.Backup,
+		"command": s.Command,
+	} {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return fmt.Errorf("%s template: %w", name, err)
+		}
+		// Render once with placeholder values so unknown fields fail at load
+		// rather than mid-operation.
+		if _, err := render(tmpl, templateData{Game: "x", DataDir: "x", Command: "x"}); err != nil {
+			return fmt.Errorf("%s template: %w", name, err)
+		}
+		s.templates[name] = tmpl
+	}
+	return nil
+}
+
+type templateData struct {
+	Game    string
+	DataDir string
+	Command string
+}
+
+func render(tmpl *template.Template, data templateData) (string, error) {
+	quoted := templateData{
+		Game:    shellQuote(data.Game),
+		DataDir: shellQuote(data.DataDir),
+		Command: shellQuote(data.Command),
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, quoted); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// shellQuote wraps v in single quotes for sh.
+func shellQuote(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
@@ -2,9 +2,9 @@ package hytale
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"sync"
-	"time"
 
 	"github.com/esuEdu/game-infra/controller/internal/domain"
 )
@@ -15,14 +15,21 @@ type Adapter struct {
 	running    bool
 	lastBackup string
 	lastSource string
+	clock      domain.Clock
 }
 
 func NewAdapter(log *slog.Logger) *Adapter {
-	return &Adapter{log: log}
+	return &Adapter{log: log, clock: domain.SystemClock{}}
 }
 
+func (a *Adapter) SetClock(clock domain.Clock) { a.clock = clock }
+
 func (a *Adapter) Type() domain.GameType { return domain.GameHytale }
 
+func (a *Adapter) Validate() error {
+	return fmt.Errorf("%w: hytale adapter is a stub", domain.ErrAdapterDegraded)
+}
+
 func (a *Adapter) Start(ctx context.Context) error {
 	a.mu.Lock()
 	a.running = true
@@ -41,7 +48,7 @@ func (a *Adapter) Stop(ctx context.Context) error {
 
 func (a *Adapter) Backup(ctx context.Context) (string, error) {
 	a.mu.Lock()
-	a.lastBackup = "s3://backups/hytale/" + time.Now().UTC().Format("20060102-150405") + ".zip"
+	a.lastBackup = "s3://backups/hytale/" + domain.BackupName(a.clock.Now(), ".zip")
 	backup := a.lastBackup
 	a.mu.Unlock()
 	a.log.Info("hytale backup (stub)", "backup", backup)
@@ -65,13 +72,21 @@ func (a *Adapter) SeedFromSource(ctx context.Context, sourceURL string) error {
 }
 
 func (a *Adapter) SyncToSource(ctx context.Context, sourceURL string) error {
+	_, err := a.SyncToSourceCommitted(ctx, sourceURL)
+	return err
+}
+
+func (a *Adapter) SyncToSourceCommitted(ctx context.Context, sourceURL string) (bool, error) {
 	a.mu.Lock()
 	a.lastSource = sourceURL
 	a.mu.Unlock()
 	a.log.Info("hytale sync to source (stub)", "source", sourceURL)
-	return nil
+	return false, nil
 }
 
+// Capabilities reports nothing: every operation is a stub.
+func (a *Adapter) Capabilities() domain.Capabilities { return domain.Capabilities{} }
+
 func (a *Adapter) SendCommand(ctx context.Context, command string) error {
 	a.log.Info("hytale command (stub)", "cmd", command)
 	return nil
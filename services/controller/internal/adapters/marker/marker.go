@@ -0,0 +1,96 @@
+// Package marker reads and writes the latest backup marker, the latest.txt
+// every adapter keeps beside a stream's backups.
+package marker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Latest is the JSON document stored at <prefix>/<game>/latest.txt. Older
+// controllers wrote the bare backup key as plain text; Parse still accepts
+// that format.
+type Latest struct {
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+	Checksum  string    `json:"checksum"`
+	Size      int64     `json:"size"`
+}
+
+// New builds the marker for the backup at key from its local file at path.
+func New(key, path string, createdAt time.Time) (Latest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Latest{}, fmt.Errorf("open backup for checksum %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return Latest{}, fmt.Errorf("checksum backup %s: %w", path, err)
+	}
+
+	return Latest{
+		Key:       key,
+		CreatedAt: createdAt.UTC(),
+		Checksum:  "sha256:" + hex.EncodeToString(h.Sum(nil)),
+		Size:      size,
+	}, nil
+}
+
+// SHA256 is the marker's hex digest without its "sha256:" prefix, as
+// recorded on the uploaded object.
+func (m Latest) SHA256() string { return strings.TrimPrefix(m.Checksum, "sha256:") }
+
+func (m Latest) Encode() (string, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("encode latest marker: %w", err)
+	}
+	return string(b), nil
+}
+
+// Parse reads a marker, refusing a JSON one with any field missing or
+// malformed. A legacy plain-text marker has only its Key set.
+func Parse(raw string) (Latest, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Latest{}, errors.New("latest backup marker is empty")
+	}
+
+	// Legacy plain-text marker: the value is the backup key itself.
+	if !strings.HasPrefix(raw, "{") {
+		return Latest{Key: raw}, nil
+	}
+
+	var m Latest
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		return Latest{}, fmt.Errorf("latest backup marker is not valid json: %w", err)
+	}
+	m.Key = strings.TrimSpace(m.Key)
+	if m.Key == "" {
+		return Latest{}, errors.New("latest backup marker has no key")
+	}
+	if m.CreatedAt.IsZero() {
+		return Latest{}, errors.New("latest backup marker has no created_at")
+	}
+	if m.Size <= 0 {
+		return Latest{}, fmt.Errorf("latest backup marker has invalid size: %d", m.Size)
+	}
+	sum, ok := strings.CutPrefix(m.Checksum, "sha256:")
+	if !ok || len(sum) != sha256.Size*2 {
+		return Latest{}, fmt.Errorf("latest backup marker has invalid checksum: %q", m.Checksum)
+	}
+	if _, err := hex.DecodeString(sum); err != nil {
+		return Latest{}, fmt.Errorf("latest backup marker has invalid checksum: %q", m.Checksum)
+	}
+	return m, nil
+}
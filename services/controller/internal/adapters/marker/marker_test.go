@@ -0,0 +1,66 @@
+package marker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "world.zip")
+	if err := os.WriteFile(path, []byte("world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	m, err := New("backups/terraria/20250102-030405.zip", path, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Size != 5 || len(m.SHA256()) != 64 || !strings.HasPrefix(m.Checksum, "sha256:") {
+		t.Fatalf("marker = %+v", m)
+	}
+	raw, err := m.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", raw, err)
+	}
+	if got != m {
+		t.Fatalf("round trip = %+v, want %+v", got, m)
+	}
+}
+
+func TestParse(t *testing.T) {
+	const sum = "sha256:2b7e1a4a1f9b0c1b8f4a3b6c5d7e8f90112233445566778899aabbccddeeff00"
+	tests := []struct {
+		name    string
+		raw     string
+		wantKey string
+		wantErr bool
+	}{
+		{name: "legacy key", raw: "backups/valheim/20250101-000000.zip\n", wantKey: "backups/valheim/20250101-000000.zip"},
+		{name: "json", raw: `{"key":"k.zip","created_at":"2025-01-01T00:00:00Z","checksum":"` + sum + `","size":1}`, wantKey: "k.zip"},
+		{name: "empty", raw: "  ", wantErr: true},
+		{name: "bad json", raw: `{"key":`, wantErr: true},
+		{name: "no key", raw: `{"created_at":"2025-01-01T00:00:00Z","checksum":"` + sum + `","size":1}`, wantErr: true},
+		{name: "no created_at", raw: `{"key":"k.zip","checksum":"` + sum + `","size":1}`, wantErr: true},
+		{name: "no size", raw: `{"key":"k.zip","created_at":"2025-01-01T00:00:00Z","checksum":"` + sum + `"}`, wantErr: true},
+		{name: "short checksum", raw: `{"key":"k.zip","created_at":"2025-01-01T00:00:00Z","checksum":"sha256:abc","size":1}`, wantErr: true},
+		{name: "checksum not hex", raw: `{"key":"k.zip","created_at":"2025-01-01T00:00:00Z","checksum":"sha256:` + strings.Repeat("z", 64) + `","size":1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if m.Key != tt.wantKey {
+				t.Fatalf("key = %q, want %q", m.Key, tt.wantKey)
+			}
+		})
+	}
+}
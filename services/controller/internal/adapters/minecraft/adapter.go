@@ -1,7 +1,6 @@
 package minecraft
 
 import (
-	"archive/zip"
 	"bytes"
 	"context"
 	"errors"
@@ -13,12 +12,17 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/esuEdu/game-infra/controller/internal/adapters/archive"
 	"github.com/esuEdu/game-infra/controller/internal/adapters/awsruntime"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/marker"
 	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/tracing"
 )
 
 type Adapter struct {
@@ -28,188 +32,567 @@ type Adapter struct {
 	lastBackup string
 	lastSource string
 
-	awsRegion string
-	cluster   string
-	service   string
-	bucket    string
+	lastBackupAt   time.Time     // when lastBackup was cached
+	latestCacheTTL time.Duration // 0 always reads the marker
+
+	lastRestoreRegion string
+
+	awsRegion    string
+	cluster      string
+	service      string
+	bucket       string
+	desiredCount int32
+	targetCount  int32
+
+	secondaryBucket string
+	secondaryRegion string
+	copyBuckets     []string // BACKUP_COPY_BUCKETS; CopyBackup may also write to bucket
+	copyEnvs        []string // BACKUP_COPY_ENVS; CopyBackup may write to their game prefix
+
+	backupRoot      string // BACKUP_PREFIX, the root every ENV's prefix is under
+	backupPrefix    string // BACKUP_PREFIX[/ENV], see env.BackupPrefix
+	backupPrefixErr error  // an invalid ENV; S3 is treated as unconfigured
 
-	backupPrefix string
 	dataDir      string
+	tmpDir       string // "" uses the system temp dir
+	localDir     string // LOCAL_BACKUP_DIR, root for file:// restores; "" disables them
+	trashTTL     time.Duration
+	storageClass string
+	backupFormat archive.Format // archive format of new backups; restores detect it by key
 
-	aws *awsruntime.Client
+	aws          *awsruntime.Client
+	awsSecondary *awsruntime.Client
 
 	gitUserName  string
 	gitUserEmail string
-	gitToken     string
+	gitToken     string // guarded by mu; rotated by SetGitToken
+
+	gitAllowedHosts    []string // GIT_ALLOWED_HOSTS; empty allows any remote
+	gitAllowedHostsErr error
+
+	gitRetryAttempts   int           // tries per clone/push, including the first
+	gitRetryMaxElapsed time.Duration // no retry starts after this much time
+	seedCopyWorkers    int           // goroutines copying files into the data dir on seed
+
+	gameHost       string
+	gamePort       int
+	playerInterval time.Duration
+	playerWindow   time.Duration
+	players        *playerWindow
+	stopPoller     context.CancelFunc
+
+	rconPort     int
+	rconPassword string
+	rconStats    rconStats
+	flushSave    bool // quiesce the world over RCON before hot backups
+	markerRepair bool // rebuild a missing or stale latest marker from the listing
+	allowEmpty   bool // BACKUP_ALLOW_EMPTY: upload a backup that captured no files
+
+	capacityProviders   []awsruntime.CapacityProvider
+	capacityProviderErr error
+
+	clock domain.Clock
 }
 
 func NewAdapter(log *slog.Logger) *Adapter {
+	storageClass := strings.ToUpper(env.OrDefault("BACKUP_STORAGE_CLASS", "STANDARD"))
+	if !awsruntime.ValidStorageClass(storageClass) {
+		log.Warn("invalid BACKUP_STORAGE_CLASS, using STANDARD", "storage_class", storageClass)
+		storageClass = "STANDARD"
+	}
+
+	backupFormat, err := archive.ParseFormat(os.Getenv("BACKUP_FORMAT"))
+	if err != nil {
+		log.Warn("invalid BACKUP_FORMAT, using zip", "err", err)
+		backupFormat = archive.FormatZip
+	}
+
+	desiredCount := env.Int("DESIRED_COUNT", 1)
+	if desiredCount < 1 {
+		log.Warn("invalid DESIRED_COUNT, using 1", "desired_count", desiredCount)
+		desiredCount = 1
+	}
+
+	capacityProviders, capacityProviderErr := awsruntime.ParseCapacityProviderStrategy(os.Getenv("ECS_CAPACITY_PROVIDER_STRATEGY"))
+	backupPrefix, backupPrefixErr := env.BackupPrefix()
+	gitAllowedHosts, gitAllowedHostsErr := parseGitAllowedHosts(os.Getenv("GIT_ALLOWED_HOSTS"))
+
+	playerInterval := env.Duration("PLAYER_SAMPLE_INTERVAL", 30*time.Second)
+	playerWindowSize := env.Duration("PLAYER_SAMPLE_WINDOW", 15*time.Minute)
+
 	return &Adapter{
-		log:          log,
-		awsRegion:    envOrDefault("AWS_REGION", "us-east-1"),
-		cluster:      strings.TrimSpace(os.Getenv("ECS_CLUSTER_NAME")),
-		service:      strings.TrimSpace(os.Getenv("ECS_SERVICE_MINECRAFT")),
-		bucket:       strings.TrimSpace(os.Getenv("BACKUP_BUCKET")),
-		backupPrefix: strings.Trim(strings.TrimSpace(envOrDefault("BACKUP_PREFIX", "backups")), "/"),
-		dataDir:      envOrDefault("MC_DATA_DIR", "/srv/minecraft-data"),
-		gitUserName:  envOrDefault("GIT_USER_NAME", "GameStack Bot"),
-		gitUserEmail: envOrDefault("GIT_USER_EMAIL", "gamestack-bot@example.com"),
-		gitToken:     strings.TrimSpace(os.Getenv("GIT_AUTH_TOKEN")),
+		log:                log,
+		awsRegion:          env.OrDefault("AWS_REGION", "us-east-1"),
+		cluster:            strings.TrimSpace(os.Getenv("ECS_CLUSTER_NAME")),
+		service:            strings.TrimSpace(os.Getenv("ECS_SERVICE_MINECRAFT")),
+		bucket:             strings.TrimSpace(os.Getenv("BACKUP_BUCKET")),
+		desiredCount:       int32(desiredCount),
+		secondaryBucket:    strings.TrimSpace(os.Getenv("BACKUP_BUCKET_SECONDARY")),
+		secondaryRegion:    strings.TrimSpace(os.Getenv("BACKUP_SECONDARY_REGION")),
+		copyBuckets:        parseNameList(os.Getenv("BACKUP_COPY_BUCKETS")),
+		copyEnvs:           parseNameList(os.Getenv("BACKUP_COPY_ENVS")),
+		backupRoot:         env.BackupRoot(),
+		backupPrefix:       backupPrefix,
+		backupPrefixErr:    backupPrefixErr,
+		dataDir:            env.OrDefault("MC_DATA_DIR", "/srv/minecraft-data"),
+		tmpDir:             strings.TrimSpace(os.Getenv("TMP_DIR")),
+		localDir:           strings.TrimSpace(os.Getenv("LOCAL_BACKUP_DIR")),
+		trashTTL:           env.Duration("BACKUP_TRASH_TTL", 7*24*time.Hour),
+		latestCacheTTL:     env.Duration("LATEST_BACKUP_CACHE_TTL", 5*time.Minute),
+		storageClass:       storageClass,
+		backupFormat:       backupFormat,
+		gitUserName:        env.OrDefault("GIT_USER_NAME", "GameStack Bot"),
+		gitUserEmail:       env.OrDefault("GIT_USER_EMAIL", "gamestack-bot@example.com"),
+		gitToken:           strings.TrimSpace(os.Getenv("GIT_AUTH_TOKEN")),
+		gitAllowedHosts:    gitAllowedHosts,
+		gitAllowedHostsErr: gitAllowedHostsErr,
+		gitRetryAttempts:   max(env.Int("GIT_RETRY_ATTEMPTS", 3), 1),
+		gitRetryMaxElapsed: env.Duration("GIT_RETRY_MAX_ELAPSED", 2*time.Minute),
+		seedCopyWorkers:    max(env.Int("SEED_COPY_WORKERS", 1), 1),
+		gameHost:           strings.TrimSpace(os.Getenv("GAME_HOST")),
+		gamePort:           env.Int("GAME_PORT", 25565),
+		playerInterval:     playerInterval,
+		playerWindow:       playerWindowSize,
+		players:            newPlayerWindow(int(playerWindowSize / playerInterval)),
+		rconPort:           env.Int("RCON_PORT", 25575),
+		rconPassword:       os.Getenv("RCON_PASSWORD"),
+		flushSave:          env.Bool("BACKUP_FLUSH_SAVE", false),
+		markerRepair:       env.Bool("LATEST_MARKER_REPAIR", false),
+		allowEmpty:         env.Bool("BACKUP_ALLOW_EMPTY", false),
+
+		capacityProviders:   capacityProviders,
+		capacityProviderErr: capacityProviderErr,
+
+		clock: domain.SystemClock{},
 	}
 }
 
 func (a *Adapter) Type() domain.GameType { return domain.GameMinecraft }
 
+// SetClock replaces the clock behind backup keys, markers, trash expiry and
+// sync commit messages.
+func (a *Adapter) SetClock(clock domain.Clock) { a.clock = clock }
+
+// Validate reports configuration that would otherwise only fail once an
+// operation runs.
+func (a *Adapter) Validate() error {
+	var errs []error
+	if a.bucket == "" {
+		errs = append(errs, errors.New("BACKUP_BUCKET is not set; stop, backup and restore will fail"))
+	}
+	if (a.cluster == "") != (a.service == "") {
+		errs = append(errs, errors.New("ECS_CLUSTER_NAME and ECS_SERVICE_MINECRAFT must be set together"))
+	}
+	if a.capacityProviderErr != nil {
+		errs = append(errs, fmt.Errorf("ECS_CAPACITY_PROVIDER_STRATEGY: %w", a.capacityProviderErr))
+	}
+	if a.backupPrefixErr != nil {
+		errs = append(errs, fmt.Errorf("backup prefix: %w", a.backupPrefixErr))
+	}
+	if _, err := awsruntime.ObjectACLFromEnv(); err != nil {
+		errs = append(errs, fmt.Errorf("BACKUP_OBJECT_ACL: %w", err))
+	}
+	if a.gitAllowedHostsErr != nil {
+		errs = append(errs, fmt.Errorf("GIT_ALLOWED_HOSTS: %w", a.gitAllowedHostsErr))
+	}
+	if info, err := os.Stat(a.dataDir); err == nil && !info.IsDir() {
+		errs = append(errs, fmt.Errorf("MC_DATA_DIR %s is not a directory", a.dataDir))
+	}
+	if a.localDir != "" {
+		if info, err := os.Stat(a.localDir); err != nil || !info.IsDir() {
+			errs = append(errs, fmt.Errorf("LOCAL_BACKUP_DIR %s is not a directory; local restores will fail", a.localDir))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if !a.ecsConfigured() {
+		errs = append(errs, fmt.Errorf("%w: ECS not configured, start/stop only track local state", domain.ErrAdapterDegraded))
+	}
+	if a.flushSave && !a.rconConfigured() {
+		errs = append(errs, fmt.Errorf("%w: BACKUP_FLUSH_SAVE needs GAME_HOST and RCON_PASSWORD, hot backups will not be flushed", domain.ErrAdapterDegraded))
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		errs = append(errs, fmt.Errorf("%w: git not found, seed and sync will fail", domain.ErrAdapterDegraded))
+	}
+	return errors.Join(errs...)
+}
+
+// LogFile is the server log the game container writes into the shared data dir.
+func (a *Adapter) LogFile() string { return filepath.Join(a.dataDir, "logs", "latest.log") }
+
 func (a *Adapter) Start(ctx context.Context) error {
+	return a.StartScaled(ctx, a.desiredCount)
+}
+
+// StartScaled brings the ECS service up to count tasks and waits until all of
+// them are running.
+func (a *Adapter) StartScaled(ctx context.Context, count int32) error {
+	cluster, service := a.ecsService(ctx)
+	if count < 1 {
+		return fmt.Errorf("desired count must be >= 1, got %d", count)
+	}
+	if a.capacityProviderErr != nil {
+		return fmt.Errorf("%w: ECS_CAPACITY_PROVIDER_STRATEGY: %v", domain.ErrMisconfigured, a.capacityProviderErr)
+	}
 	if a.ecsConfigured() {
+		if err := a.Preflight(ctx); err != nil {
+			return err
+		}
 		awsClient, err := a.awsClient(ctx)
 		if err != nil {
 			return err
 		}
-		if err := awsClient.SetServiceDesiredCount(ctx, a.cluster, a.service, 1, true); err != nil {
+		if err := awsClient.SetServiceDesiredCount(ctx, cluster, service, count, awsruntime.ScaleOptions{
+			ForceNewDeployment: true,
+			CapacityProviders:  a.capacityProviders,
+		}); err != nil {
 			return err
 		}
-		if err := awsClient.WaitServiceStable(ctx, a.cluster, a.service, 10*time.Minute); err != nil {
+		if err := awsClient.WaitServiceStable(ctx, cluster, service, 10*time.Minute); err != nil {
 			return err
 		}
 	}
 
 	a.mu.Lock()
 	a.running = true
+	a.targetCount = count
 	a.mu.Unlock()
-	a.log.Info("minecraft start", "cluster", a.cluster, "service", a.service)
+	a.startPlayerPoller()
+	a.log.Info("minecraft start", "cluster", cluster, "service", service, "desired_count", count)
+	return nil
+}
+
+// Preflight checks the ECS service exists. UpdateService against a missing
+// service only fails after the controller has committed to starting, so
+// callers run this before changing anything. It is skipped when the
+// operation already pre-flighted (see domain.WithPreflighted).
+func (a *Adapter) Preflight(ctx context.Context) error {
+	if !a.ecsConfigured() || domain.Preflighted(ctx) {
+		return nil
+	}
+	cluster, service := a.ecsService(ctx)
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := awsClient.DescribeService(ctx, cluster, service); err != nil {
+		if errors.Is(err, awsruntime.ErrServiceNotFound) {
+			return fmt.Errorf("%w: ECS service %q not found in cluster %q (check ECS_SERVICE_MINECRAFT)", domain.ErrMisconfigured, service, cluster)
+		}
+		return fmt.Errorf("ecs pre-flight: %w", err)
+	}
 	return nil
 }
 
 func (a *Adapter) Stop(ctx context.Context) error {
+	cluster, service := a.ecsService(ctx)
 	if a.ecsConfigured() {
 		awsClient, err := a.awsClient(ctx)
 		if err != nil {
 			return err
 		}
-		if err := awsClient.SetServiceDesiredCount(ctx, a.cluster, a.service, 0, false); err != nil {
+		if err := awsClient.SetServiceDesiredCount(ctx, cluster, service, 0, awsruntime.ScaleOptions{}); err != nil {
 			return err
 		}
-		if err := awsClient.WaitServiceStable(ctx, a.cluster, a.service, 10*time.Minute); err != nil {
+		if err := awsClient.WaitServiceStable(ctx, cluster, service, 10*time.Minute); err != nil {
 			return err
 		}
 	}
 
 	a.mu.Lock()
 	a.running = false
+	a.targetCount = 0
 	a.mu.Unlock()
-	a.log.Info("minecraft stop", "cluster", a.cluster, "service", a.service)
+	a.stopPlayerPoller()
+	a.log.Info("minecraft stop", "cluster", cluster, "service", service)
 	return nil
 }
 
 func (a *Adapter) Backup(ctx context.Context) (string, error) {
+	return a.BackupStream(ctx, "")
+}
+
+// BackupStream backs up the data dir into the given backup stream. The empty
+// stream is the default lineage stored directly under <prefix>/minecraft/.
+func (a *Adapter) BackupStream(ctx context.Context, stream string) (string, error) {
+	report, err := a.BackupStreamReport(ctx, stream)
+	return report.Backup, err
+}
+
+// BackupReport backs up the data dir into the default stream.
+func (a *Adapter) BackupReport(ctx context.Context) (domain.BackupReport, error) {
+	return a.BackupStreamReport(ctx, "")
+}
+
+// BackupStreamReport is BackupStream, reporting what the archive captured.
+// An archive with no files is refused unless BACKUP_ALLOW_EMPTY is set.
+func (a *Adapter) BackupStreamReport(ctx context.Context, stream string) (domain.BackupReport, error) {
+	if err := domain.ValidateStream(stream); err != nil {
+		return domain.BackupReport{}, err
+	}
 	if !a.s3Configured() {
-		return "", errors.New("s3 backup not configured")
+		return domain.BackupReport{}, errors.New("s3 backup not configured")
 	}
 
 	if err := os.MkdirAll(a.dataDir, 0o755); err != nil {
-		return "", fmt.Errorf("prepare data dir: %w", err)
+		return domain.BackupReport{}, fmt.Errorf("prepare data dir: %w", err)
+	}
+	if err := a.checkBackupDiskSpace(ctx); err != nil {
+		return domain.BackupReport{}, err
+	}
+
+	tmpArchive, err := os.CreateTemp(a.tmpDir, "minecraft-backup-*"+a.backupFormat.Ext())
+	if err != nil {
+		return domain.BackupReport{}, fmt.Errorf("create temp backup: %w", err)
 	}
+	tmpPath := tmpArchive.Name()
+	_ = tmpArchive.Close()
+	defer os.Remove(tmpPath)
 
-	tmpZip, err := os.CreateTemp("", "minecraft-backup-*.zip")
+	resume, err := a.quiesce(ctx)
 	if err != nil {
-		return "", fmt.Errorf("create temp backup: %w", err)
+		return domain.BackupReport{}, err
+	}
+	stats, packErr := archive.Pack(a.backupFormat, a.dataDir, tmpPath, nil)
+	resume()
+	if packErr != nil {
+		return domain.BackupReport{}, packErr
+	}
+	if stats.Files == 0 && !a.allowEmpty {
+		return domain.BackupReport{}, fmt.Errorf("%w: nothing to back up in %s (set BACKUP_ALLOW_EMPTY to allow)", domain.ErrEmptyBackup, a.dataDir)
 	}
-	tmpZipPath := tmpZip.Name()
-	_ = tmpZip.Close()
-	defer os.Remove(tmpZipPath)
 
-	if err := zipDirectory(a.dataDir, tmpZipPath); err != nil {
+	uri, err := a.publishBackup(ctx, stream, tmpPath)
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+	a.log.Info("minecraft backup complete", "backup", uri, "stream", stream,
+		"files", stats.Files, "bytes", stats.Bytes, "archive_bytes", stats.ArchiveBytes)
+	return domain.BackupReport{Backup: uri, Files: stats.Files, Bytes: stats.Bytes, ArchiveBytes: stats.ArchiveBytes}, nil
+}
+
+// ImportBackup registers an externally prepared world zip as the latest
+// backup of the default stream, after checking it is a well-formed archive.
+func (a *Adapter) ImportBackup(ctx context.Context, zipPath string) (string, error) {
+	if !a.s3Configured() {
+		return "", errors.New("s3 backup not configured")
+	}
+	if err := archive.Validate(zipPath); err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrInvalidArchive, err)
+	}
+	uri, err := a.publishBackup(ctx, "", zipPath)
+	if err != nil {
 		return "", err
 	}
+	a.log.Info("minecraft backup imported", "backup", uri)
+	return uri, nil
+}
 
-	key := a.backupKey()
+// publishBackup uploads zipPath as a new backup of stream, moves the latest
+// marker to it and replicates it. The key keeps zipPath's archive extension
+// so restores can tell the format.
+func (a *Adapter) publishBackup(ctx context.Context, stream, zipPath string) (string, error) {
+	key := a.backupKey(stream, archive.FormatOf(zipPath))
 	uri := fmt.Sprintf("s3://%s/%s", a.bucket, key)
 	awsClient, err := a.awsClient(ctx)
 	if err != nil {
 		return "", err
 	}
-	if err := awsClient.UploadFile(ctx, a.bucket, key, tmpZipPath); err != nil {
+	m, err := marker.New(key, zipPath, a.clock.Now())
+	if err != nil {
+		return "", err
+	}
+	opts := a.putOptions(m)
+	if err := awsClient.UploadFile(ctx, a.bucket, key, zipPath, opts); err != nil {
 		return "", fmt.Errorf("upload backup to s3: %w", err)
 	}
 
-	if err := awsClient.PutString(ctx, a.bucket, a.latestBackupKey(), key); err != nil {
+	markerValue, err := m.Encode()
+	if err != nil {
+		return "", err
+	}
+	if err := awsClient.PutString(ctx, a.bucket, a.latestBackupKey(stream), markerValue); err != nil {
 		return "", fmt.Errorf("upload latest marker: %w", err)
 	}
+	a.replicateBackup(ctx, key, zipPath, opts, a.latestBackupKey(stream), markerValue)
 
-	a.mu.Lock()
-	a.lastBackup = uri
-	backup := a.lastBackup
-	a.mu.Unlock()
+	if stream == "" {
+		a.mu.Lock()
+		a.setLastBackup(uri)
+		a.mu.Unlock()
+	}
+	return uri, nil
+}
 
-	a.log.Info("minecraft backup complete", "backup", backup)
-	return backup, nil
+// WorldModTime is the newest modification time in the data dir, or zero when
+// it is empty.
+func (a *Adapter) WorldModTime(ctx context.Context) (time.Time, error) {
+	return archive.NewestModTime(a.dataDir, nil)
 }
 
 func (a *Adapter) Restore(ctx context.Context, backupKey string) error {
+	return a.RestoreWithOptions(ctx, backupKey, domain.RestoreOptions{})
+}
+
+func (a *Adapter) RestoreWithOptions(ctx context.Context, backupKey string, opts domain.RestoreOptions) error {
+	fetched, err := a.fetchBackup(ctx, backupKey, opts)
+	if err != nil {
+		return err
+	}
+	defer fetched.cleanup()
+
+	if err := a.guardDataDir(ctx, opts.Force); err != nil {
+		return err
+	}
+	if err := a.swapDataDir(fetched); err != nil {
+		return err
+	}
+	a.log.Info("minecraft restore complete", "backup", fetched.uri, "region", fetched.region)
+	return nil
+}
+
+// RestorePaths extracts only the files at or under paths (relative to the
+// data dir, e.g. world/region/r.0.0.mca) from a backup, overwriting those and
+// leaving the rest of the data dir untouched.
+func (a *Adapter) RestorePaths(ctx context.Context, backupKey string, paths []string, opts domain.RestoreOptions) ([]string, error) {
+	clean := make([]string, 0, len(paths))
+	for _, p := range paths {
+		c, err := archive.SafePath(strings.TrimSpace(p))
+		if err != nil || c == "." {
+			return nil, fmt.Errorf("%w: %q", domain.ErrInvalidPath, p)
+		}
+		clean = append(clean, c)
+	}
+
+	fetched, err := a.fetchBackup(ctx, backupKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer fetched.cleanup()
+
+	if err := a.guardDataDir(ctx, opts.Force); err != nil {
+		return nil, err
+	}
+	files, err := archive.ExtractPaths(fetched.path, a.dataDir, clean)
+	if err != nil {
+		return files, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPathNotInBackup, strings.Join(paths, ", "))
+	}
+	a.log.Info("minecraft partial restore complete", "backup", fetched.uri, "files", len(files))
+	return files, nil
+}
+
+// fetchedBackup is a backup downloaded to a temp archive, ready to unpack.
+type fetchedBackup struct {
+	path   string
+	uri    string
+	region string
+	local  bool // path is the operator's file, not a temp copy
+}
+
+func (f fetchedBackup) cleanup() {
+	if !f.local {
+		_ = os.Remove(f.path)
+	}
+}
+
+// fetchBackup resolves backupKey and downloads it (from the secondary region
+// if needed) into a temp file the caller must clean up. A file:// ref is
+// opened in place instead, when opts allow it (see fetchLocalBackup).
+func (a *Adapter) fetchBackup(ctx context.Context, backupKey string, opts domain.RestoreOptions) (fetchedBackup, error) {
+	if path, ok := domain.LocalBackupPath(backupKey); ok {
+		return a.fetchLocalBackup(path, opts)
+	}
 	if !a.s3Configured() {
-		return errors.New("s3 backup not configured")
+		return fetchedBackup{}, errors.New("s3 backup not configured")
 	}
 	if strings.TrimSpace(backupKey) == "" {
-		return errors.New("empty backup key")
+		return fetchedBackup{}, errors.New("empty backup key")
 	}
 
 	bucket, key, err := parseBackupRef(a.bucket, backupKey)
 	if err != nil {
-		return err
+		return fetchedBackup{}, err
+	}
+	if !a.ownKey(bucket, key) {
+		return fetchedBackup{}, fmt.Errorf("%w: %s is outside %s/", domain.ErrNoBackupForGame, key, a.backupPrefix)
+	}
+	if err := a.ensureReadable(ctx, bucket, key); err != nil {
+		return fetchedBackup{}, err
 	}
 
-	tmpZip, err := os.CreateTemp("", "minecraft-restore-*.zip")
+	// The temp file keeps the key's extension, which is how unpacking picks
+	// the archive format.
+	tmpArchive, err := os.CreateTemp(a.tmpDir, "minecraft-restore-*"+archive.FormatOf(key).Ext())
 	if err != nil {
-		return fmt.Errorf("create temp restore file: %w", err)
+		return fetchedBackup{}, fmt.Errorf("create temp restore file: %w", err)
 	}
-	tmpZipPath := tmpZip.Name()
-	_ = tmpZip.Close()
-	defer os.Remove(tmpZipPath)
+	fetched := fetchedBackup{path: tmpArchive.Name(), uri: fmt.Sprintf("s3://%s/%s", bucket, key)}
+	_ = tmpArchive.Close()
 
-	awsClient, err := a.awsClient(ctx)
+	fetched.region, err = a.downloadBackup(ctx, bucket, key, fetched.path)
 	if err != nil {
-		return err
-	}
-	if err := awsClient.DownloadFile(ctx, bucket, key, tmpZipPath); err != nil {
-		return fmt.Errorf("download backup from s3: %w", err)
+		fetched.cleanup()
+		return fetchedBackup{}, fmt.Errorf("download backup from s3: %w", err)
 	}
+	return fetched, nil
+}
 
+// swapDataDir replaces the data dir with the fetched backup and records it.
+func (a *Adapter) swapDataDir(fetched fetchedBackup) error {
 	if err := resetDirectory(a.dataDir); err != nil {
 		return err
 	}
-	if err := unzipToDirectory(tmpZipPath, a.dataDir); err != nil {
+	if err := archive.Unpack(fetched.path, a.dataDir); err != nil {
 		return err
 	}
 
+	if fetched.local {
+		return nil
+	}
 	a.mu.Lock()
-	a.lastBackup = fmt.Sprintf("s3://%s/%s", bucket, key)
+	a.setLastBackup(fetched.uri)
+	a.lastRestoreRegion = fetched.region
 	a.mu.Unlock()
-	a.log.Info("minecraft restore complete", "backup", a.lastBackup)
 	return nil
 }
 
 func (a *Adapter) SeedFromSource(ctx context.Context, sourceURL string) error {
+	_, err := a.SeedFromSourceReport(ctx, sourceURL, domain.SeedOptions{})
+	return err
+}
+
+// SeedFromSourceReport resets the data dir from the source repo and reports
+// the resolved ref/path and how much was copied.
+func (a *Adapter) SeedFromSourceReport(ctx context.Context, sourceURL string, opts domain.SeedOptions) (domain.SeedReport, error) {
 	sourceURL = strings.TrimSpace(sourceURL)
 	if sourceURL == "" {
-		return errors.New("source url is required")
+		return domain.SeedReport{}, errors.New("source url is required")
 	}
 
 	repoURL, repoRef, repoPath := parseSourceURL(sourceURL)
 	authURL, err := a.withGitToken(repoURL)
 	if err != nil {
-		return err
+		return domain.SeedReport{}, err
 	}
 
-	tmpDir, err := os.MkdirTemp("", "minecraft-seed-*")
+	tmpDir, err := os.MkdirTemp(a.tmpDir, "minecraft-seed-*")
 	if err != nil {
-		return fmt.Errorf("create temp seed dir: %w", err)
+		return domain.SeedReport{}, fmt.Errorf("create temp seed dir: %w", err)
 	}
 	defer os.RemoveAll(tmpDir)
 
 	repoDir := filepath.Join(tmpDir, "repo")
-	if _, err := a.run(ctx, "git", "clone", "--depth", "1", "--branch", repoRef, authURL, repoDir); err != nil {
-		return fmt.Errorf("git clone source: %w", err)
+	if _, err := a.runGitNetwork(ctx, repoDir, "clone", "--depth", "1", "--branch", repoRef, authURL, repoDir); err != nil {
+		return domain.SeedReport{}, fmt.Errorf("git clone source: %w", err)
+	}
+	commit, err := a.headCommit(ctx, repoDir)
+	if err != nil {
+		return domain.SeedReport{}, err
 	}
 
 	srcDir := repoDir
@@ -217,50 +600,75 @@ func (a *Adapter) SeedFromSource(ctx context.Context, sourceURL string) error {
 		srcDir = filepath.Join(repoDir, repoPath)
 	}
 	if _, err := os.Stat(srcDir); err != nil {
-		return fmt.Errorf("source path not found in repo: %s", repoPath)
+		return domain.SeedReport{}, fmt.Errorf("source path not found in repo: %s", repoPath)
 	}
 
+	if err := a.guardDataDir(ctx, opts.Force); err != nil {
+		return domain.SeedReport{}, err
+	}
 	if err := resetDirectory(a.dataDir); err != nil {
-		return err
+		return domain.SeedReport{}, err
 	}
-	if err := copyDirectoryContents(srcDir, a.dataDir); err != nil {
-		return err
+	stats, err := copyDirectoryContents(srcDir, a.dataDir, a.seedCopyWorkers)
+	if err != nil {
+		return domain.SeedReport{}, err
 	}
 
 	a.mu.Lock()
 	a.lastSource = sourceURL
 	a.mu.Unlock()
-	a.log.Info("minecraft seed from source complete", "source", sourceURL)
-	return nil
+	a.log.Info("minecraft seed from source complete", "source", sourceURL, "commit", commit, "files", stats.Files, "bytes", stats.Bytes)
+	return domain.SeedReport{
+		Source: sourceURL,
+		Ref:    repoRef,
+		Path:   repoPath,
+		Commit: commit,
+		Files:  stats.Files,
+		Bytes:  stats.Bytes,
+	}, nil
 }
 
 func (a *Adapter) SyncToSource(ctx context.Context, sourceURL string) error {
+	_, err := a.SyncToSourceCommitted(ctx, sourceURL)
+	return err
+}
+
+// SyncToSourceCommitted pushes the data dir to the source repo and reports
+// whether a commit was created (false when the repo was already up to date).
+func (a *Adapter) SyncToSourceCommitted(ctx context.Context, sourceURL string) (bool, error) {
+	report, err := a.SyncToSourceReport(ctx, sourceURL)
+	return report.Committed, err
+}
+
+// SyncToSourceReport pushes the data dir to the source repo and reports
+// whether a commit was created and the commit the ref is at afterwards.
+func (a *Adapter) SyncToSourceReport(ctx context.Context, sourceURL string) (domain.SyncReport, error) {
 	sourceURL = strings.TrimSpace(sourceURL)
 	if sourceURL == "" {
-		return errors.New("source url is required")
+		return domain.SyncReport{}, errors.New("source url is required")
 	}
 
 	repoURL, repoRef, repoPath := parseSourceURL(sourceURL)
 	authURL, err := a.withGitToken(repoURL)
 	if err != nil {
-		return err
+		return domain.SyncReport{}, err
 	}
 
-	tmpDir, err := os.MkdirTemp("", "minecraft-sync-*")
+	tmpDir, err := os.MkdirTemp(a.tmpDir, "minecraft-sync-*")
 	if err != nil {
-		return fmt.Errorf("create temp sync dir: %w", err)
+		return domain.SyncReport{}, fmt.Errorf("create temp sync dir: %w", err)
 	}
 	defer os.RemoveAll(tmpDir)
 
 	repoDir := filepath.Join(tmpDir, "repo")
-	if _, err := a.run(ctx, "git", "clone", authURL, repoDir); err != nil {
-		return fmt.Errorf("git clone for sync: %w", err)
+	if _, err := a.runGitNetwork(ctx, repoDir, "clone", authURL, repoDir); err != nil {
+		return domain.SyncReport{}, fmt.Errorf("git clone for sync: %w", err)
 	}
 
 	if repoRef != "" {
 		if _, err := a.run(ctx, "git", "-C", repoDir, "checkout", repoRef); err != nil {
 			if _, err := a.run(ctx, "git", "-C", repoDir, "checkout", "-b", repoRef); err != nil {
-				return fmt.Errorf("checkout branch for sync: %w", err)
+				return domain.SyncReport{}, fmt.Errorf("checkout branch for sync: %w", err)
 			}
 		}
 	}
@@ -269,116 +677,588 @@ func (a *Adapter) SyncToSource(ctx context.Context, sourceURL string) error {
 	if repoPath != "" {
 		targetDir = filepath.Join(repoDir, repoPath)
 		if err := os.MkdirAll(targetDir, 0o755); err != nil {
-			return fmt.Errorf("create repo path for sync: %w", err)
+			return domain.SyncReport{}, fmt.Errorf("create repo path for sync: %w", err)
 		}
 	}
 
 	if err := clearDirectory(targetDir); err != nil {
-		return err
+		return domain.SyncReport{}, err
 	}
-	if err := copyDirectoryContents(a.dataDir, targetDir); err != nil {
-		return err
+	stats, err := copyDirectoryContents(a.dataDir, targetDir, 1)
+	if err != nil {
+		return domain.SyncReport{}, err
 	}
 
 	if _, err := a.run(ctx, "git", "-C", repoDir, "config", "user.name", a.gitUserName); err != nil {
-		return fmt.Errorf("git config user.name: %w", err)
+		return domain.SyncReport{}, fmt.Errorf("git config user.name: %w", err)
 	}
 	if _, err := a.run(ctx, "git", "-C", repoDir, "config", "user.email", a.gitUserEmail); err != nil {
-		return fmt.Errorf("git config user.email: %w", err)
+		return domain.SyncReport{}, fmt.Errorf("git config user.email: %w", err)
 	}
 
 	if _, err := a.run(ctx, "git", "-C", repoDir, "add", "-A"); err != nil {
-		return fmt.Errorf("git add: %w", err)
+		return domain.SyncReport{}, fmt.Errorf("git add: %w", err)
 	}
 
 	statusOut, err := a.run(ctx, "git", "-C", repoDir, "status", "--porcelain")
 	if err != nil {
-		return fmt.Errorf("git status: %w", err)
+		return domain.SyncReport{}, fmt.Errorf("git status: %w", err)
 	}
 	if strings.TrimSpace(statusOut) == "" {
-		a.log.Info("minecraft sync skipped (no changes)", "source", sourceURL)
-		return nil
+		// The data dir already matches the ref, so its head is still the
+		// commit the data dir was last synced to.
+		head, err := a.headCommit(ctx, repoDir)
+		if err != nil {
+			return domain.SyncReport{}, err
+		}
+		a.log.Info("minecraft sync skipped (no changes)", "source", sourceURL, "commit", head, "files", stats.Files, "bytes", stats.Bytes)
+		return domain.SyncReport{Committed: false, Commit: head}, nil
 	}
 
-	msg := fmt.Sprintf("chore: sync minecraft data %s", time.Now().UTC().Format(time.RFC3339))
+	msg := fmt.Sprintf("chore: sync minecraft data %s", a.clock.Now().UTC().Format(time.RFC3339))
+	if rid := domain.RequestID(ctx); rid != "" {
+		msg += "\n\nX-Request-Id: " + rid
+	}
 	if _, err := a.run(ctx, "git", "-C", repoDir, "commit", "-m", msg); err != nil {
-		return fmt.Errorf("git commit: %w", err)
+		return domain.SyncReport{}, fmt.Errorf("git commit: %w", err)
+	}
+
+	commit, err := a.headCommit(ctx, repoDir)
+	if err != nil {
+		return domain.SyncReport{}, err
 	}
 
 	pushRef := "HEAD"
 	if repoRef != "" {
 		pushRef = fmt.Sprintf("HEAD:refs/heads/%s", repoRef)
 	}
-	if _, err := a.run(ctx, "git", "-C", repoDir, "push", "origin", pushRef); err != nil {
-		return fmt.Errorf("git push: %w", err)
+	if _, err := a.runGitNetwork(ctx, "", "-C", repoDir, "push", "origin", pushRef); err != nil {
+		return domain.SyncReport{}, fmt.Errorf("git push: %w", err)
 	}
 
 	a.mu.Lock()
 	a.lastSource = sourceURL
 	a.mu.Unlock()
-	a.log.Info("minecraft sync to source complete", "source", sourceURL)
-	return nil
+	a.log.Info("minecraft sync to source complete", "source", sourceURL, "commit", commit, "files", stats.Files, "bytes", stats.Bytes)
+	return domain.SyncReport{Committed: true, Commit: commit}, nil
+}
+
+// headCommit resolves HEAD of the clone in repoDir to its full SHA.
+func (a *Adapter) headCommit(ctx context.Context, repoDir string) (string, error) {
+	out, err := a.run(ctx, "git", "-C", repoDir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse: %w", err)
+	}
+	return strings.TrimSpace(out), nil
 }
 
 func (a *Adapter) SendCommand(ctx context.Context, command string) error {
-	a.log.Info("minecraft command (stub)", "cmd", command)
-	return nil
+	_, err := a.SendCommandOutput(ctx, command)
+	return err
+}
+
+// Capabilities: backups need S3, commands RCON and syncs a git token to push
+// with; seeding clones without one.
+func (a *Adapter) Capabilities() domain.Capabilities {
+	return domain.Capabilities{
+		Backup:      a.s3Configured(),
+		Command:     a.rconConfigured(),
+		Seed:        true,
+		Sync:        a.currentGitToken() != "",
+		ListBackups: a.s3Configured(),
+	}
 }
 
 func (a *Adapter) Status(ctx context.Context) (map[string]any, error) {
+	cluster, service := a.ecsService(ctx)
 	a.mu.Lock()
 	running := a.running
 	lastBackup := a.lastBackup
 	lastSource := a.lastSource
+	lastRestoreRegion := a.lastRestoreRegion
+	targetCount := a.targetCount
 	a.mu.Unlock()
 
-	return map[string]any{
-		"adapter":     "minecraft",
-		"ready":       true,
-		"running":     running,
-		"last_backup": lastBackup,
-		"last_source": lastSource,
-		"cluster":     a.cluster,
-		"service":     a.service,
-		"bucket":      a.bucket,
-	}, nil
+	out := map[string]any{
+		"adapter":             "minecraft",
+		"ready":               true,
+		"running":             running,
+		"last_backup":         lastBackup,
+		"last_source":         lastSource,
+		"last_restore_region": lastRestoreRegion,
+		"cluster":             cluster,
+		"service":             service,
+		"desired_count":       targetCount,
+		"bucket":              a.bucket,
+		"secondary_bucket":    a.secondaryBucket,
+		"storage_class":       a.storageClass,
+	}
+
+	// Each external lookup is bounded and fails on its own: the field is
+	// marked unavailable and the rest of the status is still returned.
+	degraded := false
+	lookup := func(field string, fn func(ctx context.Context) (any, error)) {
+		lookupCtx, cancel := context.WithTimeout(ctx, statusLookupTimeout)
+		defer cancel()
+		v, err := fn(lookupCtx)
+		if err != nil {
+			degraded = true
+			out[field] = map[string]any{"unavailable": true, "error": err.Error()}
+			return
+		}
+		if v != nil {
+			out[field] = v
+		}
+	}
+	if a.ecsConfigured() {
+		lookup("ecs", a.ecsStatus)
+	}
+	if a.s3Configured() {
+		lookup("latest_backup", a.latestMarkerStatus)
+	}
+	lookup("ephemeral_storage", func(ctx context.Context) (any, error) {
+		storage, ok, err := awsruntime.TaskEphemeralStorage(ctx)
+		if err != nil || !ok {
+			return nil, err
+		}
+		return storage, nil
+	})
+	if players := a.players.summary(); players != nil {
+		players["window_seconds"] = int(a.playerWindow.Seconds())
+		out["players"] = players
+	}
+	out["degraded"] = degraded
+	return out, nil
+}
+
+// statusLookupTimeout bounds each ECS/S3 lookup in Status so one unreachable
+// dependency can't stall the endpoint.
+const statusLookupTimeout = 3 * time.Second
+
+// latestMarkerStatus reads the default stream's latest marker from S3. No
+// marker yet (no backups) is not a failure.
+func (a *Adapter) latestMarkerStatus(ctx context.Context) (any, error) {
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	value, err := a.readMarker(ctx, a.latestBackupKey(""))
+	if awsClient.IsObjectNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return marker.Parse(value)
 }
 
 func (a *Adapter) LatestBackup(ctx context.Context) (string, error) {
-	a.mu.Lock()
-	if strings.TrimSpace(a.lastBackup) != "" {
-		backup := a.lastBackup
+	return a.LatestBackupStream(ctx, "")
+}
+
+// LatestBackupStream resolves the newest backup of a stream from its latest
+// marker. Only the default stream is cached in memory, for
+// LATEST_BACKUP_CACHE_TTL, so uploads by other tools show up eventually.
+func (a *Adapter) LatestBackupStream(ctx context.Context, stream string) (string, error) {
+	return a.latestBackup(ctx, stream, false)
+}
+
+// RefreshLatestBackup re-reads a stream's latest marker from S3, bypassing
+// and then updating the cache.
+func (a *Adapter) RefreshLatestBackup(ctx context.Context, stream string) (string, error) {
+	return a.latestBackup(ctx, stream, true)
+}
+
+func (a *Adapter) latestBackup(ctx context.Context, stream string, refresh bool) (string, error) {
+	if err := domain.ValidateStream(stream); err != nil {
+		return "", err
+	}
+	if stream == "" && !refresh {
+		a.mu.Lock()
+		if backup, ok := a.cachedLatest(); ok {
+			a.mu.Unlock()
+			return backup, nil
+		}
 		a.mu.Unlock()
-		return backup, nil
 	}
-	a.mu.Unlock()
 
 	if !a.s3Configured() {
 		return "", errors.New("s3 backup not configured")
 	}
 
-	awsClient, err := a.awsClient(ctx)
+	backup, broken, err := a.readLatestMarker(ctx, stream)
+	if broken && a.markerRepair {
+		backup, err = a.repairLatestMarker(ctx, stream, err)
+	}
 	if err != nil {
 		return "", err
 	}
-	latestValue, err := awsClient.GetString(ctx, a.bucket, a.latestBackupKey())
+
+	if stream == "" {
+		a.mu.Lock()
+		a.setLastBackup(backup)
+		a.mu.Unlock()
+	}
+	return backup, nil
+}
+
+// cachedLatest returns the cached default-stream backup while it is within
+// its TTL. The caller holds a.mu.
+func (a *Adapter) cachedLatest() (string, bool) {
+	if strings.TrimSpace(a.lastBackup) == "" || a.latestCacheTTL <= 0 {
+		return "", false
+	}
+	if a.clock.Now().Sub(a.lastBackupAt) >= a.latestCacheTTL {
+		return "", false
+	}
+	return a.lastBackup, true
+}
+
+// setLastBackup caches the default stream's latest backup. The caller holds
+// a.mu.
+func (a *Adapter) setLastBackup(uri string) {
+	a.lastBackup = uri
+	a.lastBackupAt = a.clock.Now()
+}
+
+// ListBackups lists the backups of a stream, oldest first. Nested streams and
+// the latest marker are excluded from the default stream listing.
+func (a *Adapter) ListBackups(ctx context.Context, stream string) ([]domain.BackupInfo, error) {
+	if err := domain.ValidateStream(stream); err != nil {
+		return nil, err
+	}
+	if !a.s3Configured() {
+		return nil, errors.New("s3 backup not configured")
+	}
+
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := awsClient.ListObjects(ctx, a.bucket, a.streamPrefix(stream), "/")
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]domain.BackupInfo, 0, len(objects))
+	for _, obj := range objects {
+		if !domain.IsBackupKey(obj.Key) {
+			continue
+		}
+		backups = append(backups, domain.BackupInfo{
+			Key:          fmt.Sprintf("s3://%s/%s", a.bucket, obj.Key),
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Key < backups[j].Key })
+	return backups, nil
+}
+
+// OpenBackup streams one of this game's backups from the primary bucket.
+// Refs outside the bucket or the minecraft prefix are refused so the
+// endpoint can't be used to read arbitrary objects.
+func (a *Adapter) OpenBackup(ctx context.Context, backupRef string) (domain.BackupContent, error) {
+	if !a.s3Configured() {
+		return domain.BackupContent{}, errors.New("s3 backup not configured")
+	}
+	bucket, key, err := parseBackupRef(a.bucket, backupRef)
+	if err != nil {
+		return domain.BackupContent{}, err
+	}
+	if !a.ownBackup(bucket, key) {
+		return domain.BackupContent{}, domain.ErrNoBackupForGame
+	}
+	if err := a.ensureReadable(ctx, bucket, key); err != nil {
+		return domain.BackupContent{}, err
+	}
+
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return domain.BackupContent{}, err
+	}
+	body, size, err := awsClient.OpenObject(ctx, bucket, key)
 	if err != nil {
-		return "", fmt.Errorf("read latest backup marker: %w", err)
+		if awsClient.IsObjectNotFound(err) {
+			return domain.BackupContent{}, domain.ErrNoBackupForGame
+		}
+		return domain.BackupContent{}, err
+	}
+	return domain.BackupContent{ReadCloser: body, Key: fmt.Sprintf("s3://%s/%s", bucket, key), Size: size}, nil
+}
+
+// DeleteBackup soft-deletes a backup by moving it under the trash prefix with
+// an expires-at tag. The object is purged later by the bucket lifecycle rule.
+func (a *Adapter) DeleteBackup(ctx context.Context, backupRef string) (string, error) {
+	if !a.s3Configured() {
+		return "", errors.New("s3 backup not configured")
+	}
+
+	bucket, key, err := parseBackupRef(a.bucket, backupRef)
+	if err != nil {
+		return "", err
+	}
+	if !a.ownBackup(bucket, key) {
+		return "", fmt.Errorf("%w: %s is not a backup under %s", domain.ErrNoBackupForGame, key, a.streamPrefix(""))
 	}
 
-	bucket, key, err := parseBackupRef(a.bucket, strings.TrimSpace(latestValue))
+	awsClient, err := a.awsClient(ctx)
 	if err != nil {
-		return "", fmt.Errorf("parse latest backup marker: %w", err)
+		return "", err
+	}
+
+	trashKey := a.trashKey(key)
+	tags := map[string]string{
+		"expires-at": a.clock.Now().UTC().Add(a.trashTTL).Format(time.RFC3339),
+	}
+	if err := awsClient.CopyObject(ctx, bucket, key, bucket, trashKey, tags); err != nil {
+		return "", fmt.Errorf("move backup to trash: %w", err)
 	}
-	if key == "" {
-		return "", errors.New("latest backup marker is empty")
+	if err := awsClient.DeleteObject(ctx, bucket, key); err != nil {
+		return "", fmt.Errorf("delete original backup: %w", err)
 	}
 
-	backup := fmt.Sprintf("s3://%s/%s", bucket, key)
+	uri := fmt.Sprintf("s3://%s/%s", bucket, key)
 	a.mu.Lock()
-	a.lastBackup = backup
+	if a.lastBackup == uri {
+		a.lastBackup = ""
+	}
 	a.mu.Unlock()
-	return backup, nil
+
+	trashURI := fmt.Sprintf("s3://%s/%s", bucket, trashKey)
+	a.log.Info("minecraft backup moved to trash", "backup", uri, "trash", trashURI)
+	if err := a.dropFromLatestMarker(ctx, key); err != nil {
+		return "", fmt.Errorf("backup moved to %s but the latest marker still names it: %w", trashURI, err)
+	}
+	return trashURI, nil
+}
+
+// RestoreDeletedBackup moves a soft-deleted backup back to its original key,
+// as long as its trash window has not expired.
+func (a *Adapter) RestoreDeletedBackup(ctx context.Context, backupRef string) (string, error) {
+	if !a.s3Configured() {
+		return "", errors.New("s3 backup not configured")
+	}
+
+	bucket, key, err := parseBackupRef(a.bucket, backupRef)
+	if err != nil {
+		return "", err
+	}
+	if rel, ok := strings.CutPrefix(key, a.trashPrefix()); ok {
+		key = a.originalKey(rel)
+	}
+	if !a.ownBackup(bucket, key) {
+		return "", fmt.Errorf("%w: %s is not a backup under %s", domain.ErrNoBackupForGame, key, a.streamPrefix(""))
+	}
+	trashKey := a.trashKey(key)
+
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	tags, err := awsClient.GetObjectTags(ctx, bucket, trashKey)
+	if err != nil {
+		if awsClient.IsObjectNotFound(err) {
+			return "", domain.ErrBackupNotInTrash
+		}
+		return "", err
+	}
+	if raw := tags["expires-at"]; raw != "" {
+		if expiresAt, err := time.Parse(time.RFC3339, raw); err == nil && a.clock.Now().UTC().After(expiresAt) {
+			return "", domain.ErrBackupNotInTrash
+		}
+	}
+
+	if err := awsClient.CopyObject(ctx, bucket, trashKey, bucket, key, nil); err != nil {
+		return "", fmt.Errorf("restore backup from trash: %w", err)
+	}
+	if err := awsClient.DeleteObject(ctx, bucket, trashKey); err != nil {
+		return "", fmt.Errorf("delete trashed backup: %w", err)
+	}
+
+	uri := fmt.Sprintf("s3://%s/%s", bucket, key)
+	a.log.Info("minecraft backup restored from trash", "backup", uri)
+	return uri, nil
+}
+
+// DesiredCount reports the ECS service desired count, or 0 when ECS is not
+// configured for this adapter.
+func (a *Adapter) DesiredCount(ctx context.Context) (int32, error) {
+	if !a.ecsConfigured() {
+		return 0, nil
+	}
+	cluster, service := a.ecsService(ctx)
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+	st, err := awsClient.DescribeService(ctx, cluster, service)
+	if err != nil {
+		return 0, err
+	}
+	return st.DesiredCount, nil
+}
+
+// checkBackupDiskSpace fails early when the task's ephemeral storage can't
+// hold a temp zip of the data dir. The uncompressed size is used as an upper
+// bound. It is skipped outside ECS, when storage metrics are unavailable, or
+// when TMP_DIR points temp files at a mounted volume.
+func (a *Adapter) checkBackupDiskSpace(ctx context.Context) error {
+	if a.tmpDir != "" {
+		return nil
+	}
+	storage, ok, err := awsruntime.TaskEphemeralStorage(ctx)
+	if err != nil {
+		a.log.Warn("minecraft ephemeral storage check skipped", "err", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	need, err := directorySize(a.dataDir)
+	if err != nil {
+		return err
+	}
+	if avail := storage.AvailableBytes(); need > avail {
+		return fmt.Errorf("%w: backup needs up to %d bytes, %d available; increase the task's ephemeral storage or enable streaming backup",
+			domain.ErrInsufficientDisk, need, avail)
+	}
+	return nil
+}
+
+// guardDataDir refuses to clear the data dir while ECS reports running or
+// pending tasks. The data dir is typically an EFS mount shared with the game
+// container, so clearing it underneath a live server corrupts the world.
+func (a *Adapter) guardDataDir(ctx context.Context, force bool) error {
+	if force || !a.ecsConfigured() {
+		return nil
+	}
+	cluster, service := a.ecsService(ctx)
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return err
+	}
+	st, err := awsClient.DescribeService(ctx, cluster, service)
+	if err != nil {
+		return fmt.Errorf("check ecs tasks before data dir reset: %w", err)
+	}
+	if st.RunningCount > 0 || st.PendingCount > 0 {
+		return fmt.Errorf("%w (running=%d pending=%d)", domain.ErrDataDirInUse, st.RunningCount, st.PendingCount)
+	}
+	return nil
+}
+
+// putOptions is how backups are uploaded; the marker's checksum is recorded
+// on the object so restores can verify it.
+func (a *Adapter) putOptions(m marker.Latest) awsruntime.PutOptions {
+	return awsruntime.PutOptions{StorageClass: a.storageClass, SHA256: m.SHA256()}
+}
+
+// ensureReadable checks whether a backup sits in an archived storage class.
+// Archived objects get a Glacier restore started (if not already running) and
+// the caller is told to retry once the restored copy is available.
+func (a *Adapter) ensureReadable(ctx context.Context, bucket, key string) error {
+	if a.secondaryConfigured() && bucket == a.secondaryBucket {
+		return nil
+	}
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return err
+	}
+	head, err := awsClient.HeadObject(ctx, bucket, key)
+	if err != nil {
+		// Let the download path (and its secondary fallback) report errors.
+		return nil
+	}
+	if !awsruntime.IsArchivedStorageClass(head.StorageClass) {
+		return nil
+	}
+
+	switch {
+	case strings.Contains(head.Restore, `ongoing-request="false"`):
+		return nil
+	case strings.Contains(head.Restore, `ongoing-request="true"`):
+		return fmt.Errorf("%w: restore of s3://%s/%s (%s) is in progress, retry later", domain.ErrBackupArchived, bucket, key, head.StorageClass)
+	}
+
+	if err := awsClient.RestoreArchivedObject(ctx, bucket, key, 2); err != nil {
+		return err
+	}
+	a.log.Warn("minecraft backup archived, glacier restore initiated", "bucket", bucket, "key", key, "storage_class", head.StorageClass)
+	return fmt.Errorf("%w: restore of s3://%s/%s (%s) initiated, retry once it completes", domain.ErrBackupArchived, bucket, key, head.StorageClass)
+}
+
+// Redeploy forces a new ECS deployment at the current desired count and waits
+// for it to finish rolling out.
+func (a *Adapter) Redeploy(ctx context.Context) (map[string]any, error) {
+	if !a.ecsConfigured() {
+		return nil, errors.New("ecs not configured")
+	}
+	cluster, service := a.ecsService(ctx)
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := awsClient.ForceNewDeployment(ctx, cluster, service); err != nil {
+		return nil, err
+	}
+	if err := awsClient.WaitServiceStable(ctx, cluster, service, 10*time.Minute); err != nil {
+		return nil, err
+	}
+
+	st, err := awsClient.DescribeService(ctx, cluster, service)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]any{
+		"desired_count":   st.DesiredCount,
+		"running_count":   st.RunningCount,
+		"task_definition": st.PrimaryTaskDefinition(),
+	}
+	if d, ok := st.PrimaryDeployment(); ok {
+		out["deployment_id"] = d.ID
+		out["rollout_state"] = d.RolloutState
+		out["deployment_running_count"] = d.RunningCount
+		out["deployment_pending_count"] = d.PendingCount
+	}
+	a.log.Info("minecraft redeploy complete", "cluster", cluster, "service", service, "task_definition", out["task_definition"])
+	return out, nil
+}
+
+// ecsStatus describes the live ECS service for Status. Lookup failures are
+// reported inline rather than failing the whole status call.
+func (a *Adapter) ecsStatus(ctx context.Context) (any, error) {
+	cluster, service := a.ecsService(ctx)
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st, err := awsClient.DescribeService(ctx, cluster, service)
+	if err != nil {
+		return nil, err
+	}
+
+	taskDef := st.PrimaryTaskDefinition()
+	return map[string]any{
+		"status":                   st.Status,
+		"desired_count":            st.DesiredCount,
+		"running_count":            st.RunningCount,
+		"pending_count":            st.PendingCount,
+		"task_definition":          taskDef,
+		"task_definition_revision": awsruntime.TaskDefinitionRevision(taskDef),
+		"deployments":              len(st.Deployments),
+		"launch_type":              st.LaunchType,
+		"capacity_providers":       st.CapacityProviderStrategy,
+		"configured_providers":     a.capacityProviders,
+	}, nil
+}
+
+// ecsService is the cluster and service to act on: the operation's
+// override when ctx carries one, else ECS_CLUSTER_NAME/ECS_SERVICE_MINECRAFT.
+func (a *Adapter) ecsService(ctx context.Context) (cluster, service string) {
+	return domain.ECSTargetOr(ctx, a.cluster, a.service)
 }
 
 func (a *Adapter) ecsConfigured() bool {
@@ -386,10 +1266,41 @@ func (a *Adapter) ecsConfigured() bool {
 }
 
 func (a *Adapter) s3Configured() bool {
-	return a.bucket != "" && a.awsRegion != ""
+	return a.bucket != "" && a.awsRegion != "" && a.backupPrefixErr == nil
+}
+
+// ownKey reports whether bucket/key belongs to this environment: anything
+// in another bucket is the caller's explicit choice, but keys in the backup
+// bucket must sit under the composed prefix, so one environment can't
+// restore or delete another's backups.
+func (a *Adapter) ownKey(bucket, key string) bool {
+	return bucket != a.bucket || env.KeyUnder(a.backupPrefix, key)
 }
 
-func (a *Adapter) run(ctx context.Context, cmd string, args ...string) (string, error) {
+// ownBackup reports whether bucket/key is one of this game's backups in the
+// primary bucket. Endpoints that read or move a single object (download,
+// delete, undelete) require it, so they can't reach other games' backups,
+// the latest markers or anything else the controller's role can.
+func (a *Adapter) ownBackup(bucket, key string) bool {
+	return bucket == a.bucket && strings.HasPrefix(key, a.streamPrefix("")) && domain.IsBackupKey(key)
+}
+
+func (a *Adapter) run(ctx context.Context, cmd string, args ...string) (_ string, err error) {
+	// Only the subcommand is recorded; URLs in args (and so git's errors)
+	// can carry the git token.
+	name := cmd
+	if cmd == "git" {
+		name += " " + gitSubcommand(args)
+	}
+	ctx, span := tracing.Start(ctx, name)
+	defer func() {
+		var spanErr error
+		if err != nil {
+			spanErr = errors.New(scrubToken(err, a.currentGitToken()))
+		}
+		tracing.End(span, spanErr)
+	}()
+
 	c := exec.CommandContext(ctx, cmd, args...)
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -405,20 +1316,44 @@ func (a *Adapter) run(ctx context.Context, cmd string, args ...string) (string,
 	return stdout.String(), nil
 }
 
-func (a *Adapter) backupKey() string {
-	base := fmt.Sprintf("minecraft/%s.zip", time.Now().UTC().Format("20060102-150405"))
+func (a *Adapter) streamPrefix(stream string) string {
+	prefix := "minecraft/"
+	if stream != "" {
+		prefix += stream + "/"
+	}
+	if a.backupPrefix != "" {
+		prefix = a.backupPrefix + "/" + prefix
+	}
+	return prefix
+}
+
+func (a *Adapter) backupKey(stream string, format archive.Format) string {
+	return a.streamPrefix(stream) + domain.BackupName(a.clock.Now(), format.Ext())
+}
+
+func (a *Adapter) latestBackupKey(stream string) string {
+	return a.streamPrefix(stream) + "latest.txt"
+}
+
+func (a *Adapter) trashPrefix() string {
 	if a.backupPrefix == "" {
-		return base
+		return "trash/"
 	}
-	return a.backupPrefix + "/" + base
+	return a.backupPrefix + "/trash/"
 }
 
-func (a *Adapter) latestBackupKey() string {
-	key := "minecraft/latest.txt"
+func (a *Adapter) trashKey(key string) string {
 	if a.backupPrefix != "" {
-		key = a.backupPrefix + "/" + key
+		key = strings.TrimPrefix(key, a.backupPrefix+"/")
 	}
-	return key
+	return a.trashPrefix() + key
+}
+
+func (a *Adapter) originalKey(rel string) string {
+	if a.backupPrefix == "" {
+		return rel
+	}
+	return a.backupPrefix + "/" + rel
 }
 
 func (a *Adapter) awsClient(ctx context.Context) (*awsruntime.Client, error) {
@@ -444,14 +1379,6 @@ func (a *Adapter) awsClient(ctx context.Context) (*awsruntime.Client, error) {
 	return client, nil
 }
 
-func envOrDefault(key, fallback string) string {
-	val := strings.TrimSpace(os.Getenv(key))
-	if val == "" {
-		return fallback
-	}
-	return val
-}
-
 func parseSourceURL(raw string) (repoURL, ref, path string) {
 	repoURL = strings.TrimSpace(raw)
 	ref = "main"
@@ -476,7 +1403,13 @@ func parseSourceURL(raw string) (repoURL, ref, path string) {
 }
 
 func (a *Adapter) withGitToken(repoURL string) (string, error) {
-	if a.gitToken == "" {
+	return a.remoteURL(repoURL, a.currentGitToken())
+}
+
+// gitAuthURL embeds token in an https repo URL; other schemes and an empty
+// token leave it unchanged.
+func gitAuthURL(repoURL, token string) (string, error) {
+	if token == "" {
 		return repoURL, nil
 	}
 	parsed, err := url.Parse(repoURL)
@@ -486,15 +1419,24 @@ func (a *Adapter) withGitToken(repoURL string) (string, error) {
 	if parsed.Scheme != "https" {
 		return repoURL, nil
 	}
-	parsed.User = url.UserPassword("x-access-token", a.gitToken)
+	parsed.User = url.UserPassword("x-access-token", token)
 	return parsed.String(), nil
 }
 
+// ParseBackupRef resolves a backup ref to its bucket and key, taking bare
+// keys to be in the backup bucket.
+func (a *Adapter) ParseBackupRef(backupRef string) (bucket, key string, err error) {
+	return parseBackupRef(a.bucket, strings.TrimPrefix(strings.TrimSpace(backupRef), "/"))
+}
+
 func parseBackupRef(defaultBucket, backupRef string) (bucket, key string, err error) {
 	ref := strings.TrimSpace(backupRef)
 	if ref == "" {
 		return "", "", errors.New("empty backup ref")
 	}
+	if strings.HasPrefix(ref, domain.LocalBackupScheme) {
+		return "", "", fmt.Errorf("%w: %s is a local path, only a full restore reads it", domain.ErrLocalBackup, backupRef)
+	}
 	if strings.HasPrefix(ref, "s3://") {
 		trimmed := strings.TrimPrefix(ref, "s3://")
 		parts := strings.SplitN(trimmed, "/", 2)
@@ -509,6 +1451,27 @@ func parseBackupRef(defaultBucket, backupRef string) (bucket, key string, err er
 	return defaultBucket, ref, nil
 }
 
+func directorySize(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("measure directory %s: %w", dir, err)
+	}
+	return total, nil
+}
+
 func resetDirectory(dir string) error {
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("create directory %s: %w", dir, err)
@@ -536,30 +1499,39 @@ func clearDirectory(dir string) error {
 	return nil
 }
 
-func copyDirectoryContents(srcDir, dstDir string) error {
+type copyStats struct {
+	Files int
+	Bytes int64
+}
+
+// copyDirectoryContents copies srcDir into dstDir, skipping .git. With
+// workers > 1 regular files are copied by that many goroutines; see copyPool.
+func copyDirectoryContents(srcDir, dstDir string, workers int) (copyStats, error) {
 	if err := os.MkdirAll(dstDir, 0o755); err != nil {
-		return fmt.Errorf("create destination dir %s: %w", dstDir, err)
+		return copyStats{}, fmt.Errorf("create destination dir %s: %w", dstDir, err)
 	}
 
 	entries, err := os.ReadDir(srcDir)
 	if err != nil {
-		return fmt.Errorf("read source dir %s: %w", srcDir, err)
+		return copyStats{}, fmt.Errorf("read source dir %s: %w", srcDir, err)
 	}
 
+	pool := newCopyPool(workers)
 	for _, entry := range entries {
 		if entry.Name() == ".git" {
 			continue
 		}
 		srcPath := filepath.Join(srcDir, entry.Name())
 		dstPath := filepath.Join(dstDir, entry.Name())
-		if err := copyPath(srcPath, dstPath); err != nil {
-			return err
+		if err := copyPath(srcPath, dstPath, pool); err != nil {
+			pool.fail(err)
+			break
 		}
 	}
-	return nil
+	return pool.wait()
 }
 
-func copyPath(srcPath, dstPath string) error {
+func copyPath(srcPath, dstPath string, pool *copyPool) error {
 	info, err := os.Lstat(srcPath)
 	if err != nil {
 		return fmt.Errorf("stat %s: %w", srcPath, err)
@@ -567,7 +1539,7 @@ func copyPath(srcPath, dstPath string) error {
 
 	switch mode := info.Mode(); {
 	case mode.IsRegular():
-		return copyFile(srcPath, dstPath, mode.Perm())
+		return pool.copy(copyJob{src: srcPath, dst: dstPath, perm: mode.Perm(), size: info.Size()})
 	case mode.IsDir():
 		if err := os.MkdirAll(dstPath, mode.Perm()); err != nil {
 			return fmt.Errorf("mkdir %s: %w", dstPath, err)
@@ -577,7 +1549,7 @@ func copyPath(srcPath, dstPath string) error {
 			return fmt.Errorf("readdir %s: %w", srcPath, err)
 		}
 		for _, child := range children {
-			if err := copyPath(filepath.Join(srcPath, child.Name()), filepath.Join(dstPath, child.Name())); err != nil {
+			if err := copyPath(filepath.Join(srcPath, child.Name()), filepath.Join(dstPath, child.Name()), pool); err != nil {
 				return err
 			}
 		}
@@ -606,117 +1578,3 @@ func copyFile(srcPath, dstPath string, perm fs.FileMode) error {
 	}
 	return nil
 }
-
-func zipDirectory(srcDir, dstZip string) error {
-	out, err := os.Create(dstZip)
-	if err != nil {
-		return fmt.Errorf("create zip %s: %w", dstZip, err)
-	}
-	defer out.Close()
-
-	zw := zip.NewWriter(out)
-	defer zw.Close()
-
-	if err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		if path == srcDir {
-			return nil
-		}
-
-		relPath, err := filepath.Rel(srcDir, path)
-		if err != nil {
-			return err
-		}
-		relPath = filepath.ToSlash(relPath)
-
-		if d.IsDir() {
-			_, err := zw.Create(relPath + "/")
-			return err
-		}
-
-		info, err := d.Info()
-		if err != nil {
-			return err
-		}
-
-		header, err := zip.FileInfoHeader(info)
-		if err != nil {
-			return err
-		}
-		header.Name = relPath
-		header.Method = zip.Deflate
-
-		w, err := zw.CreateHeader(header)
-		if err != nil {
-			return err
-		}
-
-		f, err := os.Open(path)
-		if err != nil {
-			return err
-		}
-		_, err = io.Copy(w, f)
-		closeErr := f.Close()
-		if err != nil {
-			return err
-		}
-		if closeErr != nil {
-			return closeErr
-		}
-		return nil
-	}); err != nil {
-		return fmt.Errorf("walk source dir for zip: %w", err)
-	}
-
-	return nil
-}
-
-func unzipToDirectory(srcZip, dstDir string) error {
-	r, err := zip.OpenReader(srcZip)
-	if err != nil {
-		return fmt.Errorf("open zip %s: %w", srcZip, err)
-	}
-	defer r.Close()
-
-	for _, f := range r.File {
-		cleanName := filepath.Clean(f.Name)
-		if filepath.IsAbs(cleanName) || strings.HasPrefix(cleanName, "..") {
-			return fmt.Errorf("zip contains invalid path: %s", f.Name)
-		}
-		outPath := filepath.Join(dstDir, cleanName)
-
-		if f.FileInfo().IsDir() {
-			if err := os.MkdirAll(outPath, f.Mode()); err != nil {
-				return fmt.Errorf("mkdir %s: %w", outPath, err)
-			}
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
-			return fmt.Errorf("mkdir parent for %s: %w", outPath, err)
-		}
-
-		in, err := f.Open()
-		if err != nil {
-			return fmt.Errorf("open zip entry %s: %w", f.Name, err)
-		}
-
-		out, err := os.OpenFile(outPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, f.Mode())
-		if err != nil {
-			in.Close()
-			return fmt.Errorf("open output file %s: %w", outPath, err)
-		}
-
-		if _, err := io.Copy(out, in); err != nil {
-			in.Close()
-			out.Close()
-			return fmt.Errorf("extract %s: %w", f.Name, err)
-		}
-		in.Close()
-		out.Close()
-	}
-
-	return nil
-}
@@ -0,0 +1,172 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/awsruntime"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/marker"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// BACKUP_COPY_BUCKETS (comma-separated bucket names) lists the buckets
+// CopyBackup may write to besides BACKUP_BUCKET, e.g. prod's bucket on a
+// staging controller. Unset allows only the backup bucket.
+//
+// The destination prefix is this game's prefix in this environment, or in
+// another one: any <BACKUP_PREFIX>/<env>/minecraft in a BACKUP_COPY_BUCKETS
+// bucket, and in BACKUP_BUCKET only the envs listed in BACKUP_COPY_ENVS
+// (comma-separated ENV names), so a staging controller sharing a bucket with
+// prod can promote into prod without reaching every environment.
+
+// parseNameList trims and dedupes a comma-separated list.
+func parseNameList(raw string) []string {
+	var buckets []string
+	for _, b := range strings.Split(raw, ",") {
+		b = strings.TrimSpace(b)
+		if b != "" && !slices.Contains(buckets, b) {
+			buckets = append(buckets, b)
+		}
+	}
+	return buckets
+}
+
+// CopyBackup server-side copies one of this game's backups to another
+// bucket (or prefix), e.g. to promote a staging world to prod. The source
+// must be in the backup bucket under the game's prefix, and the destination
+// one of BACKUP_COPY_BUCKETS (or the backup bucket), with destPrefix under
+// the game's prefix here or in another environment (see copyDestAllowed);
+// destPrefix defaults to the source's own prefix. With
+// setLatest the copy also becomes the latest backup under destPrefix; the
+// source's marker checksum is carried over when the source is its stream's
+// latest, otherwise a key-only marker is written.
+func (a *Adapter) CopyBackup(ctx context.Context, sourceRef, destBucket, destPrefix string, setLatest bool) (string, error) {
+	if !a.s3Configured() {
+		return "", errors.New("s3 backup not configured")
+	}
+	srcBucket, srcKey, err := parseBackupRef(a.bucket, sourceRef)
+	if err != nil {
+		return "", err
+	}
+	if srcBucket != a.bucket || !strings.HasPrefix(srcKey, a.streamPrefix("")) {
+		return "", fmt.Errorf("%w: %s is outside s3://%s/%s", domain.ErrNoBackupForGame, sourceRef, a.bucket, a.streamPrefix(""))
+	}
+	if !domain.IsBackupKey(srcKey) {
+		return "", fmt.Errorf("%w: %s is not a backup archive", domain.ErrNoBackupForGame, sourceRef)
+	}
+	destBucket = strings.TrimSpace(destBucket)
+	if destBucket == "" {
+		destBucket = a.bucket
+	}
+	if destBucket != a.bucket && !slices.Contains(a.copyBuckets, destBucket) {
+		return "", fmt.Errorf("%w: %s is not in BACKUP_COPY_BUCKETS", domain.ErrBucketAccessDenied, destBucket)
+	}
+	destPrefix = strings.Trim(strings.TrimSpace(destPrefix), "/")
+	if destPrefix == "" {
+		destPrefix = path.Dir(srcKey)
+	}
+	if !a.copyDestAllowed(destBucket, destPrefix) {
+		return "", fmt.Errorf("%w: dest_prefix %s is not under the minecraft prefix of this or an allowed environment", domain.ErrInvalidPath, destPrefix)
+	}
+	destKey := destPrefix + "/" + path.Base(srcKey)
+	if destBucket == srcBucket && destKey == srcKey {
+		return "", fmt.Errorf("%w: destination is the source backup", domain.ErrInvalidPath)
+	}
+
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	// Check both ends before a copy that can run for minutes on a large
+	// world; a denied copy otherwise fails only after CreateMultipartUpload.
+	if _, err := awsClient.HeadObject(ctx, srcBucket, srcKey); err != nil {
+		if awsClient.IsObjectNotFound(err) {
+			return "", fmt.Errorf("%w: %v", domain.ErrNoBackupForGame, err)
+		}
+		return "", a.copyError(awsClient, srcBucket, err)
+	}
+	if err := awsClient.HeadBucket(ctx, destBucket); err != nil {
+		return "", a.copyError(awsClient, destBucket, err)
+	}
+	if err := a.ensureReadable(ctx, srcBucket, srcKey); err != nil {
+		return "", err
+	}
+
+	if err := awsClient.CopyObject(ctx, srcBucket, srcKey, destBucket, destKey, nil); err != nil {
+		return "", a.copyError(awsClient, destBucket, err)
+	}
+	uri := fmt.Sprintf("s3://%s/%s", destBucket, destKey)
+	a.log.Info("minecraft backup copied", "source", fmt.Sprintf("s3://%s/%s", srcBucket, srcKey), "backup", uri)
+
+	if setLatest {
+		markerKey := destPrefix + "/latest.txt"
+		markerValue, err := a.copiedMarker(ctx, awsClient, srcBucket, srcKey, destKey)
+		if err != nil {
+			return "", err
+		}
+		if err := awsClient.PutString(ctx, destBucket, markerKey, markerValue); err != nil {
+			return "", a.copyError(awsClient, destBucket, fmt.Errorf("upload latest marker: %w", err))
+		}
+		if destBucket == a.bucket && markerKey == a.latestBackupKey("") {
+			a.mu.Lock()
+			a.setLastBackup(uri)
+			a.mu.Unlock()
+		}
+	}
+	return uri, nil
+}
+
+// copyDestAllowed reports whether a copy may be written under destPrefix in
+// destBucket: this game's own prefix, or <BACKUP_PREFIX>/<env>/minecraft for
+// an env in BACKUP_COPY_ENVS, or for any env when destBucket is one of
+// BACKUP_COPY_BUCKETS. The game segment is always minecraft.
+func (a *Adapter) copyDestAllowed(destBucket, destPrefix string) bool {
+	under := func(gamePrefix string) bool {
+		return destPrefix == gamePrefix || env.KeyUnder(gamePrefix, destPrefix)
+	}
+	if under(strings.TrimSuffix(a.streamPrefix(""), "/")) {
+		return true
+	}
+	rel := destPrefix
+	if a.backupRoot != "" {
+		var ok bool
+		if rel, ok = strings.CutPrefix(destPrefix, a.backupRoot+"/"); !ok {
+			return false
+		}
+	}
+	name, _, _ := strings.Cut(rel, "/")
+	if !env.ValidEnvName(name) || !under(path.Join(a.backupRoot, name, "minecraft")) {
+		return false
+	}
+	return slices.Contains(a.copyEnvs, name) || (destBucket != a.bucket && slices.Contains(a.copyBuckets, destBucket))
+}
+
+// copiedMarker builds the latest marker for a copied backup, reusing the
+// source marker's checksum and size when it points at the same object.
+func (a *Adapter) copiedMarker(ctx context.Context, awsClient *awsruntime.Client, srcBucket, srcKey, destKey string) (string, error) {
+	raw, err := awsClient.GetString(ctx, srcBucket, path.Dir(srcKey)+"/latest.txt")
+	if err == nil {
+		if m, err := marker.Parse(raw); err == nil && m.Checksum != "" {
+			if bucket, key, err := parseBackupRef(srcBucket, m.Key); err == nil && bucket == srcBucket && key == srcKey {
+				m.Key = destKey
+				return m.Encode()
+			}
+		}
+	}
+	// Legacy plain-text form: the only marker valid without a checksum.
+	return destKey, nil
+}
+
+// copyError marks permission failures on either bucket so they surface as
+// 403s rather than internal errors.
+func (a *Adapter) copyError(awsClient *awsruntime.Client, bucket string, err error) error {
+	if awsClient.IsAccessDenied(err) {
+		return fmt.Errorf("%w: %s: %v", domain.ErrBucketAccessDenied, bucket, err)
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+package minecraft
+
+import "testing"
+
+func TestCopyDestAllowed(t *testing.T) {
+	staging := &Adapter{
+		bucket:       "games",
+		backupRoot:   "backups",
+		backupPrefix: "backups/staging",
+		copyBuckets:  []string{"prod-games"},
+		copyEnvs:     []string{"prod"},
+	}
+	tests := []struct {
+		name               string
+		bucket, destPrefix string
+		want               bool
+	}{
+		{"own prefix", "games", "backups/staging/minecraft", true},
+		{"own stream", "games", "backups/staging/minecraft/nightly", true},
+		{"promote to prod in the shared bucket", "games", "backups/prod/minecraft", true},
+		{"promote to a prod stream in the shared bucket", "games", "backups/prod/minecraft/nightly", true},
+		{"promote to prod in its own bucket", "prod-games", "backups/prod/minecraft", true},
+		{"any env in a copy bucket", "prod-games", "backups/qa/minecraft", true},
+		{"env not listed in the shared bucket", "games", "backups/qa/minecraft", false},
+		{"other game in prod", "games", "backups/prod/terraria", false},
+		{"other game in a copy bucket", "prod-games", "backups/prod/terraria", false},
+		{"prod's trash", "games", "backups/prod/trash/minecraft", false},
+		{"prod env root", "games", "backups/prod", false},
+		{"lookalike game segment", "games", "backups/prod/minecraft-old", false},
+		{"outside the root", "prod-games", "other/prod/minecraft", false},
+		{"invalid env segment", "prod-games", "backups/Prod/minecraft", false},
+		{"own prefix in a copy bucket", "prod-games", "backups/staging/minecraft", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := staging.copyDestAllowed(tt.bucket, tt.destPrefix); got != tt.want {
+				t.Errorf("copyDestAllowed(%q, %q) = %v, want %v", tt.bucket, tt.destPrefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyDestAllowedWithoutCopyConfig(t *testing.T) {
+	a := &Adapter{bucket: "games", backupRoot: "backups", backupPrefix: "backups/staging"}
+	if !a.copyDestAllowed("games", "backups/staging/minecraft") {
+		t.Error("own prefix refused")
+	}
+	if a.copyDestAllowed("games", "backups/prod/minecraft") {
+		t.Error("another environment allowed without BACKUP_COPY_ENVS")
+	}
+}
@@ -0,0 +1,100 @@
+package minecraft
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+)
+
+// errCopyStopped ends the walk once a queued copy has failed; the failure
+// itself is already recorded.
+var errCopyStopped = errors.New("copy stopped")
+
+type copyJob struct {
+	src, dst string
+	perm     fs.FileMode
+	size     int64
+}
+
+// copyPool copies the regular files copyPath finds. With one worker it copies
+// each file inline, in walk order, and stops at the first failure. With more,
+// files are queued to that many goroutines while the walk goes on; the walk
+// creates each directory before queueing anything under it, so a copy never
+// races its parent's mkdir. After a failure no more files are queued, the
+// copies in flight finish, and every failure is returned, joined.
+type copyPool struct {
+	jobs chan copyJob // nil when copying inline
+	wg   sync.WaitGroup
+
+	mu    sync.Mutex
+	stats copyStats
+	errs  []error
+}
+
+func newCopyPool(workers int) *copyPool {
+	p := &copyPool{}
+	if workers <= 1 {
+		return p
+	}
+	p.jobs = make(chan copyJob, workers)
+	p.wg.Add(workers)
+	for range workers {
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				if err := p.run(job); err != nil {
+					p.fail(err)
+				}
+			}
+		}()
+	}
+	return p
+}
+
+func (p *copyPool) copy(job copyJob) error {
+	if p.jobs == nil {
+		return p.run(job)
+	}
+	p.mu.Lock()
+	failed := len(p.errs) > 0
+	p.mu.Unlock()
+	if failed {
+		return errCopyStopped
+	}
+	p.jobs <- job
+	return nil
+}
+
+func (p *copyPool) run(job copyJob) error {
+	if err := copyFile(job.src, job.dst, job.perm); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.stats.Files++
+	p.stats.Bytes += job.size
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *copyPool) fail(err error) {
+	if errors.Is(err, errCopyStopped) {
+		return
+	}
+	p.mu.Lock()
+	p.errs = append(p.errs, err)
+	p.mu.Unlock()
+}
+
+// wait drains the queue and returns the totals and failures.
+func (p *copyPool) wait() (copyStats, error) {
+	if p.jobs != nil {
+		close(p.jobs)
+		p.wg.Wait()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.errs) == 1 {
+		return p.stats, p.errs[0]
+	}
+	return p.stats, errors.Join(p.errs...)
+}
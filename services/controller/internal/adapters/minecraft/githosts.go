@@ -0,0 +1,85 @@
+package minecraft
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// GIT_ALLOWED_HOSTS (comma-separated host names, e.g.
+// "github.com,gitlab.example.com") limits the remotes seed, sync, source
+// validation and the git health check will talk to. The check runs before
+// the token is put into the URL, so a source pointing anywhere else can't
+// carry the token off. Unset allows any host.
+
+// parseGitAllowedHosts lowercases and dedupes the list, dropping any port.
+func parseGitAllowedHosts(raw string) ([]string, error) {
+	var hosts []string
+	for _, h := range strings.Split(raw, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		if strings.ContainsAny(h, "/@") {
+			return nil, fmt.Errorf("%q is not a host name", h)
+		}
+		if host, _, err := net.SplitHostPort(h); err == nil {
+			h = host
+		}
+		h = strings.TrimSuffix(h, ".")
+		if !slices.Contains(hosts, h) {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts, nil
+}
+
+// gitHost returns the host of an http(s)/ssh URL or scp-style user@host:path.
+func gitHost(repoURL string) (string, error) {
+	repoURL = strings.TrimSpace(repoURL)
+	if strings.Contains(repoURL, "://") {
+		u, err := url.Parse(repoURL)
+		if err != nil || u.Hostname() == "" {
+			return "", fmt.Errorf("%w: no host in repository url", domain.ErrInvalidSourceURL)
+		}
+		return strings.TrimSuffix(strings.ToLower(u.Hostname()), "."), nil
+	}
+	userHost, _, ok := strings.Cut(repoURL, ":")
+	_, host, hasUser := strings.Cut(userHost, "@")
+	if !ok || !hasUser || host == "" {
+		return "", fmt.Errorf("%w: no host in repository url", domain.ErrInvalidSourceURL)
+	}
+	return strings.TrimSuffix(strings.ToLower(host), "."), nil
+}
+
+// checkGitHost refuses repoURL unless its host is allowed. An unparseable
+// GIT_ALLOWED_HOSTS refuses everything rather than nothing.
+func (a *Adapter) checkGitHost(repoURL string) error {
+	if a.gitAllowedHostsErr != nil {
+		return fmt.Errorf("%w: %v", domain.ErrGitHostNotAllowed, a.gitAllowedHostsErr)
+	}
+	if len(a.gitAllowedHosts) == 0 {
+		return nil
+	}
+	host, err := gitHost(repoURL)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(a.gitAllowedHosts, host) {
+		return fmt.Errorf("%w: %s", domain.ErrGitHostNotAllowed, host)
+	}
+	return nil
+}
+
+// remoteURL is the URL git is given for repoURL: refused unless its host is
+// allowed, then with token embedded.
+func (a *Adapter) remoteURL(repoURL, token string) (string, error) {
+	if err := a.checkGitHost(repoURL); err != nil {
+		return "", err
+	}
+	return gitAuthURL(repoURL, token)
+}
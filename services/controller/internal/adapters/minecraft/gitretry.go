@@ -0,0 +1,99 @@
+package minecraft
+
+import (
+	"context"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	gitRetryBackoff    = time.Second // doubles per retry
+	gitRetryMaxBackoff = 15 * time.Second
+)
+
+// retryableGitErrors are stderr fragments of failures worth another attempt:
+// DNS, dropped connections, timeouts and server-side 5xx. Anything else (bad
+// credentials, a missing repo or ref, a rejected non-fast-forward push) fails
+// at once, since repeating it can't help.
+var retryableGitErrors = []string{
+	"could not resolve host",
+	"temporary failure in name resolution",
+	"connection timed out",
+	"operation timed out",
+	"connection reset",
+	"connection refused",
+	"couldn't connect to server",
+	"failed to connect",
+	"network is unreachable",
+	"the remote end hung up unexpectedly",
+	"early eof",
+	"unexpected disconnect",
+	"rpc failed",
+	"gnutls_handshake",
+	"ssl_read",
+	"ssl_connect",
+	"the requested url returned error: 5",
+}
+
+func retryableGitError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, frag := range retryableGitErrors {
+		if strings.Contains(msg, frag) {
+			return true
+		}
+	}
+	return false
+}
+
+// runGitNetwork runs a git command that talks to the remote (clone, fetch,
+// push), retrying transient network failures with jittered exponential
+// backoff up to GIT_RETRY_ATTEMPTS tries within GIT_RETRY_MAX_ELAPSED.
+// partial, when set, is removed before each retry so a half-finished clone
+// doesn't block the next one.
+func (a *Adapter) runGitNetwork(ctx context.Context, partial string, args ...string) (string, error) {
+	begin := time.Now()
+	backoff := gitRetryBackoff
+	for attempt := 1; ; attempt++ {
+		out, err := a.run(ctx, "git", args...)
+		if err == nil || attempt >= a.gitRetryAttempts || !retryableGitError(err) {
+			return out, err
+		}
+		// Equal jitter: half the backoff fixed, half random.
+		wait := backoff/2 + rand.N(backoff/2+1)
+		if time.Since(begin)+wait > a.gitRetryMaxElapsed {
+			return out, err
+		}
+		a.log.Warn("git network error, retrying", "op", gitSubcommand(args), "attempt", attempt, "in", wait, "err", scrubToken(err, a.currentGitToken()))
+		select {
+		case <-ctx.Done():
+			return out, err
+		case <-time.After(wait):
+		}
+		if partial != "" {
+			_ = os.RemoveAll(partial)
+		}
+		backoff = min(2*backoff, gitRetryMaxBackoff)
+	}
+}
+
+// gitSubcommand names the command in args for logs, skipping "-C dir".
+func gitSubcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-C" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+	return ""
+}
+
+// scrubToken keeps token out of anything logged or reported.
+func scrubToken(err error, token string) string {
+	if token == "" {
+		return err.Error()
+	}
+	return strings.ReplaceAll(err.Error(), token, "***")
+}
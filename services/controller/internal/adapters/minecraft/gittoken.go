@@ -0,0 +1,41 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+func (a *Adapter) currentGitToken() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.gitToken
+}
+
+// SetGitToken swaps the token used to clone and push, so a rotated
+// credential takes effect without a restart. The new token must first list
+// sourceURL over https; on failure the old one stays in place. Neither token
+// is ever logged or returned in an error.
+func (a *Adapter) SetGitToken(ctx context.Context, token, sourceURL string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("empty git token")
+	}
+	repoURL, _, _ := parseSourceURL(sourceURL)
+	if parsed, err := url.Parse(repoURL); err != nil || parsed.Scheme != "https" {
+		return fmt.Errorf("%w: source is not an https url, so the token can't be checked", domain.ErrGitTokenRejected)
+	}
+	if err := a.lsRemote(ctx, sourceURL, token); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrGitTokenRejected, err)
+	}
+
+	a.mu.Lock()
+	a.gitToken = token
+	a.mu.Unlock()
+	a.log.Info("minecraft git token rotated")
+	return nil
+}
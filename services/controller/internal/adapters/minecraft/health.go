@@ -0,0 +1,70 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// HealthChecks reports the dependencies this adapter is configured for. RCON
+// is only checked while the server is running, since a stopped world is not
+// a failure.
+func (a *Adapter) HealthChecks(sourceURL string) map[string]func(ctx context.Context) error {
+	checks := map[string]func(ctx context.Context) error{}
+	if a.s3Configured() {
+		checks["s3"] = func(ctx context.Context) error {
+			awsClient, err := a.awsClient(ctx)
+			if err != nil {
+				return err
+			}
+			return awsClient.HeadBucket(ctx, a.bucket)
+		}
+	}
+	if a.ecsConfigured() {
+		checks["ecs"] = func(ctx context.Context) error {
+			_, err := a.ecsStatus(ctx)
+			return err
+		}
+	}
+	a.mu.Lock()
+	running := a.running
+	a.mu.Unlock()
+	if running && a.rconConfigured() {
+		checks["rcon"] = func(ctx context.Context) error {
+			conn, err := a.dialRCON(ctx)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		}
+	}
+	if sourceURL = strings.TrimSpace(sourceURL); sourceURL != "" {
+		checks["git"] = func(ctx context.Context) error {
+			return a.checkGitRemote(ctx, sourceURL)
+		}
+	}
+	return checks
+}
+
+// checkGitRemote runs git ls-remote against the source repo, which proves it
+// resolves and that the token (if any) can read it, without cloning.
+func (a *Adapter) checkGitRemote(ctx context.Context, sourceURL string) error {
+	return a.lsRemote(ctx, sourceURL, a.currentGitToken())
+}
+
+// lsRemote runs git ls-remote against sourceURL authenticated with token.
+func (a *Adapter) lsRemote(ctx context.Context, sourceURL, token string) error {
+	repoURL, _, _ := parseSourceURL(sourceURL)
+	authURL, err := a.remoteURL(repoURL, token)
+	if err != nil {
+		return err
+	}
+	if _, err := a.run(ctx, "git", "ls-remote", "--heads", authURL); err != nil {
+		// git may echo the URL back; never let the token reach the report.
+		if token != "" {
+			return errors.New(scrubToken(err, token))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package minecraft
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// HotRestore swaps the world files under a running server instead of
+// restarting the ECS task: players are kicked, saving is paused and flushed,
+// the data dir is replaced, and the server is told to reload before saving
+// resumes.
+//
+// This is best effort. Minecraft keeps loaded chunks and player data in
+// memory and `reload` only re-reads datapacks, so anything still loaded can be
+// written back over the restored files. The service only calls it with
+// UNSAFE_HOT_RESTORE set, and falls back to stop/restore/start when it
+// returns domain.ErrNotSupported.
+func (a *Adapter) HotRestore(ctx context.Context, backupKey string) error {
+	a.mu.Lock()
+	running := a.running
+	a.mu.Unlock()
+	if !running || !a.rconConfigured() {
+		return fmt.Errorf("%w: hot restore needs a running server with RCON", domain.ErrNotSupported)
+	}
+
+	// Download before touching the server so a bad key costs no downtime.
+	fetched, err := a.fetchBackup(ctx, backupKey, domain.RestoreOptions{})
+	if err != nil {
+		return err
+	}
+	defer fetched.cleanup()
+
+	// The pre-swap commands get their own connection and deadline; the swap
+	// of a large world can outlast it.
+	if err := a.quiesceForSwap(ctx); err != nil {
+		a.resumeAfterSwap(ctx, false)
+		return fmt.Errorf("hot restore: %w", err)
+	}
+
+	swapErr := a.swapDataDir(fetched)
+	a.resumeAfterSwap(ctx, swapErr == nil)
+	if swapErr != nil {
+		return fmt.Errorf("hot restore: %w", swapErr)
+	}
+	a.log.Info("minecraft hot restore complete", "backup", fetched.uri, "region", fetched.region)
+	return nil
+}
+
+// quiesceForSwap kicks players, pauses saving and flushes the world.
+func (a *Adapter) quiesceForSwap(ctx context.Context) error {
+	rconCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	conn, err := a.dialRCON(rconCtx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, cmd := range []string{"kick @a Restoring world", "save-off", "save-all flush"} {
+		if _, err := conn.Exec(cmd); err != nil {
+			return fmt.Errorf("%s: %w", cmd, err)
+		}
+	}
+	return nil
+}
+
+// resumeAfterSwap dials a fresh connection to reload (when the swap went
+// through) and turn saving back on. The restore context may already be
+// cancelled or past its deadline; saving must come back regardless.
+func (a *Adapter) resumeAfterSwap(ctx context.Context, reload bool) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	conn, err := a.dialRCON(ctx)
+	if err != nil {
+		a.log.Error("minecraft save-on failed; autosave is still off", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	if reload {
+		if _, err := conn.Exec("reload"); err != nil {
+			a.log.Warn("minecraft reload after hot restore failed", "err", err)
+		}
+	}
+	if _, err := conn.Exec("save-on"); err != nil {
+		a.log.Error("minecraft save-on failed; autosave is still off", "err", err)
+	}
+}
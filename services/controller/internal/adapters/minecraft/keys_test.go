@@ -0,0 +1,116 @@
+package minecraft
+
+import (
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/archive"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+func TestKeysStayUnderPrefix(t *testing.T) {
+	// Streams are validated before they reach the adapter; the rejected
+	// ones are here to show why.
+	streams := []string{"", "nightly", "pre-restore", domain.PreRestoreStream, "..", "../prod", "a/b", "latest", "trash"}
+	for _, prefix := range []string{"backups", "backups/staging", "backups/prod", "custom/deep/prefix", "staging"} {
+		a := &Adapter{backupPrefix: prefix, clock: domain.NewFakeClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))}
+		var keys []string
+		for _, stream := range streams {
+			if domain.ValidateStream(stream) != nil {
+				continue
+			}
+			keys = append(keys,
+				a.streamPrefix(stream)+"x",
+				a.backupKey(stream, archive.FormatZip),
+				a.backupKey(stream, archive.FormatTarGz),
+				a.latestBackupKey(stream),
+			)
+		}
+		for _, key := range append([]string{}, keys...) {
+			keys = append(keys, a.trashKey(key))
+		}
+		keys = append(keys, a.trashPrefix()+"x", a.originalKey("minecraft/x.zip"))
+
+		for _, key := range keys {
+			if !env.KeyUnder(prefix, key) {
+				t.Errorf("prefix %q: key %q is outside it", prefix, key)
+			}
+			if path.Clean(key) != key || strings.Contains(key, "//") {
+				t.Errorf("prefix %q: key %q is not clean", prefix, key)
+			}
+		}
+	}
+}
+
+func TestKeysOfOtherEnvironments(t *testing.T) {
+	staging := &Adapter{backupPrefix: "backups/staging"}
+	prod := &Adapter{backupPrefix: "backups/prod"}
+	for _, key := range []string{
+		prod.latestBackupKey(""),
+		prod.streamPrefix("nightly") + "20250101-000000.zip",
+		prod.trashKey(prod.latestBackupKey("")),
+		"backups/staging-old/minecraft/20250101-000000.zip",
+		"backups/minecraft/20250101-000000.zip",
+	} {
+		if env.KeyUnder(staging.backupPrefix, key) {
+			t.Errorf("staging treats %q as its own", key)
+		}
+	}
+}
+
+func TestTrashKeyRoundTrip(t *testing.T) {
+	a := &Adapter{backupPrefix: "backups/staging"}
+	key := a.streamPrefix("nightly") + "20250101-000000.zip"
+	rel, ok := strings.CutPrefix(a.trashKey(key), a.trashPrefix())
+	if !ok {
+		t.Fatalf("trash key %q is outside %q", a.trashKey(key), a.trashPrefix())
+	}
+	if got := a.originalKey(rel); got != key {
+		t.Fatalf("originalKey(trash of %q) = %q", key, got)
+	}
+}
+
+func TestRapidBackupKeysAreDistinct(t *testing.T) {
+	// A frozen clock: every backup is taken in the same second.
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := &Adapter{backupPrefix: "backups", clock: domain.NewFakeClock(at)}
+
+	seen := map[string]bool{}
+	for range 100 {
+		key := a.backupKey("", archive.FormatZip)
+		if seen[key] {
+			t.Fatalf("backup key %q was generated twice in the same second", key)
+		}
+		seen[key] = true
+		if got, ok := domain.BackupTime(key); !ok || !got.Equal(at) {
+			t.Fatalf("BackupTime(%q) = %v, %v; want %v", key, got, ok, at)
+		}
+	}
+}
+
+func TestOwnBackup(t *testing.T) {
+	a := &Adapter{bucket: "games", backupPrefix: "backups/prod"}
+	tests := []struct {
+		bucket, key string
+		want        bool
+	}{
+		{"games", "backups/prod/minecraft/20250101-000000.zip", true},
+		{"games", "backups/prod/minecraft/nightly/20250101-000000.tar.gz", true},
+		{"games", "backups/prod/minecraft/latest.txt", false},
+		{"games", "backups/prod/minecraft/nightly/latest.txt", false},
+		{"games", "backups/prod/terraria/20250101-000000.zip", false},
+		{"games", "backups/prod/trash/minecraft/20250101-000000.zip", false},
+		{"games", "backups/staging/minecraft/20250101-000000.zip", false},
+		{"games", "backups/prod/minecraft-old/20250101-000000.zip", false},
+		{"games", "state/controller.json", false},
+		{"other", "backups/prod/minecraft/20250101-000000.zip", false},
+	}
+	for _, tt := range tests {
+		if got := a.ownBackup(tt.bucket, tt.key); got != tt.want {
+			t.Errorf("ownBackup(%q, %q) = %v, want %v", tt.bucket, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package minecraft
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// fetchLocalBackup resolves the path of a file:// backup ref for a
+// break-glass restore that bypasses S3. It needs opts.AllowLocal and
+// LOCAL_BACKUP_DIR; a relative path is taken from that dir, and the resolved
+// file (after symlinks) must be a regular file inside it.
+func (a *Adapter) fetchLocalBackup(path string, opts domain.RestoreOptions) (fetchedBackup, error) {
+	if !opts.AllowLocal {
+		return fetchedBackup{}, fmt.Errorf("%w: pass allow_local to restore file://%s", domain.ErrLocalBackup, path)
+	}
+	if a.localDir == "" {
+		return fetchedBackup{}, fmt.Errorf("%w: LOCAL_BACKUP_DIR is not set", domain.ErrLocalBackup)
+	}
+
+	root, err := filepath.EvalSymlinks(a.localDir)
+	if err != nil {
+		return fetchedBackup{}, fmt.Errorf("resolve LOCAL_BACKUP_DIR: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return fetchedBackup{}, fmt.Errorf("%w: %s: %w", domain.ErrInvalidPath, path, err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fetchedBackup{}, fmt.Errorf("%w: %s is outside LOCAL_BACKUP_DIR", domain.ErrInvalidPath, path)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return fetchedBackup{}, fmt.Errorf("%w: %s: %w", domain.ErrInvalidPath, path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fetchedBackup{}, fmt.Errorf("%w: %s is not a regular file", domain.ErrInvalidPath, path)
+	}
+
+	a.log.Warn("minecraft restoring from a local file, bypassing s3", "path", resolved)
+	return fetchedBackup{path: resolved, uri: domain.LocalBackupScheme + resolved, local: true}, nil
+}
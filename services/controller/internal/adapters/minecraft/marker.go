@@ -0,0 +1,192 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/marker"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// readLatestMarker resolves a stream's latest marker to a backup URI. broken
+// is true when the marker is missing or unreadable as a marker, and, with
+// LATEST_MARKER_REPAIR, when the backup it names no longer exists: the cases
+// repairLatestMarker can fix. S3 being unreachable or refusing access is not.
+func (a *Adapter) readLatestMarker(ctx context.Context, stream string) (backup string, broken bool, err error) {
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	markerKey := a.latestBackupKey(stream)
+	latestValue, err := a.readMarker(ctx, markerKey)
+	if err != nil {
+		return "", awsClient.IsObjectNotFound(err), fmt.Errorf("read latest backup marker: %w", err)
+	}
+
+	m, err := marker.Parse(latestValue)
+	if err != nil {
+		return "", true, fmt.Errorf("invalid latest backup marker s3://%s/%s: %w", a.bucket, markerKey, err)
+	}
+
+	bucket, key, err := parseBackupRef(a.bucket, m.Key)
+	if err != nil {
+		return "", true, fmt.Errorf("parse latest backup marker: %w", err)
+	}
+	if key == "" {
+		return "", true, errors.New("latest backup marker is empty")
+	}
+
+	backup = fmt.Sprintf("s3://%s/%s", bucket, key)
+	if a.markerRepair && bucket == a.bucket {
+		if _, err := awsClient.HeadObject(ctx, bucket, key); err != nil {
+			if awsClient.IsObjectNotFound(err) {
+				return "", true, fmt.Errorf("latest backup marker points at missing %s", backup)
+			}
+			return "", false, fmt.Errorf("check latest backup %s: %w", backup, err)
+		}
+	}
+	return backup, false, nil
+}
+
+// repairLatestMarker points a broken latest marker at the stream's newest
+// backup by key timestamp and returns it. The rewritten marker is the bare
+// key (the legacy format) since the checksum would take a full download; the
+// next backup writes a complete one. cause is the marker's failure, returned
+// when there is nothing to repair it with.
+func (a *Adapter) repairLatestMarker(ctx context.Context, stream string, cause error) (string, error) {
+	backups, err := a.ListBackups(ctx, stream)
+	if err != nil {
+		return "", fmt.Errorf("%w (repair: list backups: %v)", cause, err)
+	}
+	newest := newestBackup(backups)
+	if newest == "" {
+		return "", fmt.Errorf("%w: %w", domain.ErrNoBackupForGame, cause)
+	}
+
+	_, key, err := parseBackupRef(a.bucket, newest)
+	if err != nil {
+		return "", err
+	}
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	markerKey := a.latestBackupKey(stream)
+	if err := awsClient.PutString(ctx, a.bucket, markerKey, key); err != nil {
+		return "", fmt.Errorf("%w (repair: write marker: %v)", cause, err)
+	}
+	a.log.Warn("minecraft latest marker repaired from listing",
+		"marker", fmt.Sprintf("s3://%s/%s", a.bucket, markerKey), "backup", newest, "cause", cause)
+	return newest, nil
+}
+
+// newestBackup is the backup with the latest key timestamp, or "" when none
+// has one.
+func newestBackup(backups []domain.BackupInfo) string {
+	var newest string
+	var newestAt time.Time
+	for _, b := range backups {
+		at, ok := domain.BackupTime(b.Key)
+		if !ok {
+			continue
+		}
+		if newest == "" || at.After(newestAt) || (at.Equal(newestAt) && b.Key > newest) {
+			newest, newestAt = b.Key, at
+		}
+	}
+	return newest
+}
+
+// dropFromLatestMarker moves the latest marker off key, a backup that was
+// just deleted, so a start doesn't restore a missing object: it is pointed
+// at the stream's next newest backup (as a bare key, like
+// repairLatestMarker) or removed when none is left. A marker naming another
+// backup is left alone.
+func (a *Adapter) dropFromLatestMarker(ctx context.Context, key string) error {
+	stream := path.Dir(strings.TrimPrefix(key, a.streamPrefix("")))
+	if stream == "." {
+		stream = ""
+	}
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return err
+	}
+	markerKey := a.latestBackupKey(stream)
+	raw, err := a.readMarker(ctx, markerKey)
+	if err != nil {
+		if awsClient.IsObjectNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("read latest backup marker: %w", err)
+	}
+	m, err := marker.Parse(raw)
+	if err != nil {
+		return nil // already broken; nothing here names key
+	}
+	if mBucket, mKey, err := parseBackupRef(a.bucket, m.Key); err != nil || mBucket != a.bucket || mKey != key {
+		return nil
+	}
+
+	backups, err := a.ListBackups(ctx, stream)
+	if err != nil {
+		return fmt.Errorf("list backups: %w", err)
+	}
+	remaining := slices.DeleteFunc(backups, func(b domain.BackupInfo) bool {
+		return b.Key == fmt.Sprintf("s3://%s/%s", a.bucket, key)
+	})
+	next := newestBackup(remaining)
+	if next == "" {
+		if err := awsClient.DeleteObject(ctx, a.bucket, markerKey); err != nil {
+			return fmt.Errorf("delete latest backup marker: %w", err)
+		}
+		a.log.Info("minecraft latest marker removed", "marker", fmt.Sprintf("s3://%s/%s", a.bucket, markerKey))
+		return nil
+	}
+	_, nextKey, err := parseBackupRef(a.bucket, next)
+	if err != nil {
+		return err
+	}
+	if err := awsClient.PutString(ctx, a.bucket, markerKey, nextKey); err != nil {
+		return fmt.Errorf("write latest backup marker: %w", err)
+	}
+	a.log.Info("minecraft latest marker moved", "marker", fmt.Sprintf("s3://%s/%s", a.bucket, markerKey), "backup", next)
+	return nil
+}
+
+// BackupCreatedAt is when backupRef was taken: its marker's created_at when
+// the latest marker beside it names it, otherwise when the object was last
+// written. Zero (with no error) for local backups, whose only clue is their
+// name.
+func (a *Adapter) BackupCreatedAt(ctx context.Context, backupRef string) (time.Time, string, error) {
+	if _, local := domain.LocalBackupPath(backupRef); local {
+		return time.Time{}, "", nil
+	}
+	bucket, key, err := parseBackupRef(a.bucket, backupRef)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	awsClient, err := a.awsClient(ctx)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	if raw, err := awsClient.GetString(ctx, bucket, path.Dir(key)+"/latest.txt"); err == nil {
+		if m, err := marker.Parse(raw); err == nil && !m.CreatedAt.IsZero() {
+			if mBucket, mKey, err := parseBackupRef(bucket, m.Key); err == nil && mBucket == bucket && mKey == key {
+				return m.CreatedAt.UTC(), "marker", nil
+			}
+		}
+	}
+	head, err := awsClient.HeadObject(ctx, bucket, key)
+	if err != nil {
+		if awsClient.IsObjectNotFound(err) {
+			return time.Time{}, "", fmt.Errorf("%w: %v", domain.ErrNoBackupForGame, err)
+		}
+		return time.Time{}, "", err
+	}
+	return head.LastModified.UTC(), "object", nil
+}
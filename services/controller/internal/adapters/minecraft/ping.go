@@ -0,0 +1,129 @@
+package minecraft
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// pingResult is the subset of the Server List Ping status response we use.
+type pingResult struct {
+	Version struct {
+		Name string `json:"name"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+	} `json:"players"`
+}
+
+// GameAddr is the server's TCP address for readiness probes, or "" when
+// GAME_HOST is unset.
+func (a *Adapter) GameAddr() string {
+	if a.gameHost == "" {
+		return ""
+	}
+	return net.JoinHostPort(a.gameHost, strconv.Itoa(a.gamePort))
+}
+
+// serverListPing performs a Minecraft Server List Ping (handshake + status
+// request) and returns the decoded status.
+func serverListPing(ctx context.Context, host string, port int) (pingResult, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return pingResult{}, fmt.Errorf("dial %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	var handshake bytes.Buffer
+	writeVarInt(&handshake, 0x00) // packet id
+	writeVarInt(&handshake, -1)   // protocol version (any)
+	writeVarInt(&handshake, int32(len(host)))
+	handshake.WriteString(host)
+	_ = binary.Write(&handshake, binary.BigEndian, uint16(port))
+	writeVarInt(&handshake, 1) // next state: status
+
+	var status bytes.Buffer
+	writeVarInt(&status, 0x00)
+
+	var out bytes.Buffer
+	writeVarInt(&out, int32(handshake.Len()))
+	out.Write(handshake.Bytes())
+	writeVarInt(&out, int32(status.Len()))
+	out.Write(status.Bytes())
+	if _, err := conn.Write(out.Bytes()); err != nil {
+		return pingResult{}, fmt.Errorf("write ping: %w", err)
+	}
+
+	r := bufio.NewReader(conn)
+	if _, err := readVarInt(r); err != nil { // packet length
+		return pingResult{}, fmt.Errorf("read ping length: %w", err)
+	}
+	id, err := readVarInt(r)
+	if err != nil {
+		return pingResult{}, fmt.Errorf("read ping packet id: %w", err)
+	}
+	if id != 0x00 {
+		return pingResult{}, fmt.Errorf("unexpected ping packet id %d", id)
+	}
+	n, err := readVarInt(r)
+	if err != nil {
+		return pingResult{}, fmt.Errorf("read ping payload length: %w", err)
+	}
+	if n <= 0 || n > 1<<20 {
+		return pingResult{}, fmt.Errorf("invalid ping payload length %d", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return pingResult{}, fmt.Errorf("read ping payload: %w", err)
+	}
+
+	var res pingResult
+	if err := json.Unmarshal(payload, &res); err != nil {
+		return pingResult{}, fmt.Errorf("decode ping payload: %w", err)
+	}
+	return res, nil
+}
+
+func writeVarInt(buf *bytes.Buffer, v int32) {
+	u := uint32(v)
+	for {
+		if u&^0x7F == 0 {
+			buf.WriteByte(byte(u))
+			return
+		}
+		buf.WriteByte(byte(u&0x7F | 0x80))
+		u >>= 7
+	}
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var result uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(result), nil
+		}
+	}
+	return 0, errors.New("varint too long")
+}
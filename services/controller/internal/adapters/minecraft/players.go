@@ -0,0 +1,146 @@
+package minecraft
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type playerSample struct {
+	At     time.Time
+	Online int
+}
+
+// playerWindow is a fixed-size ring buffer of player-count samples.
+type playerWindow struct {
+	mu      sync.Mutex
+	samples []playerSample
+	next    int
+	full    bool
+}
+
+func newPlayerWindow(size int) *playerWindow {
+	if size < 1 {
+		size = 1
+	}
+	return &playerWindow{samples: make([]playerSample, size)}
+}
+
+func (w *playerWindow) add(s playerSample) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples[w.next] = s
+	w.next = (w.next + 1) % len(w.samples)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+func (w *playerWindow) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	clear(w.samples)
+	w.next = 0
+	w.full = false
+}
+
+// latest returns the newest sample's player count, false when empty.
+func (w *playerWindow) latest() (int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.next == 0 && !w.full {
+		return 0, false
+	}
+	return w.samples[(w.next-1+len(w.samples))%len(w.samples)].Online, true
+}
+
+// summary returns min/max/avg over the buffered samples, or nil when empty.
+func (w *playerWindow) summary() map[string]any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := w.next
+	if w.full {
+		n = len(w.samples)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	lo, hi, sum := w.samples[0].Online, w.samples[0].Online, 0
+	for i := 0; i < n; i++ {
+		s := w.samples[i]
+		lo = min(lo, s.Online)
+		hi = max(hi, s.Online)
+		sum += s.Online
+	}
+	newest := w.samples[(w.next-1+len(w.samples))%len(w.samples)]
+	oldest := w.samples[0]
+	if w.full {
+		oldest = w.samples[w.next]
+	}
+
+	return map[string]any{
+		"samples": n,
+		"current": newest.Online,
+		"min":     lo,
+		"max":     hi,
+		"avg":     float64(sum) / float64(n),
+		"since":   oldest.At,
+	}
+}
+
+// startPlayerPoller samples the player count every playerInterval until
+// stopPlayerPoller is called. It is a no-op when GAME_HOST is unset.
+func (a *Adapter) startPlayerPoller() {
+	if a.gameHost == "" {
+		return
+	}
+
+	a.mu.Lock()
+	if a.stopPoller != nil {
+		a.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopPoller = cancel
+	a.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(a.playerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			res, err := serverListPing(pingCtx, a.gameHost, a.gamePort)
+			cancel()
+			if err != nil {
+				a.log.Debug("minecraft player ping failed", "err", err)
+				continue
+			}
+			a.players.add(playerSample{At: a.clock.Now().UTC(), Online: res.Players.Online})
+		}
+	}()
+}
+
+func (a *Adapter) stopPlayerPoller() {
+	a.mu.Lock()
+	stop := a.stopPoller
+	a.stopPoller = nil
+	a.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	a.players.reset()
+}
+
+// PlayerCount is the most recent sampled player count; false until the
+// poller has a sample (or when GAME_HOST is unset).
+func (a *Adapter) PlayerCount() (int, bool) {
+	return a.players.latest()
+}
@@ -0,0 +1,203 @@
+package minecraft
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RCON packet types (Source RCON protocol, as implemented by Minecraft).
+const (
+	rconTypeResponse = 0
+	rconTypeCommand  = 2
+	rconTypeAuth     = 3
+
+	rconMaxPayload = 4096
+)
+
+var errRCONAuth = errors.New("rcon authentication failed")
+
+// rconConn is an authenticated RCON session. It is not safe for concurrent
+// use; each caller dials its own.
+type rconConn struct {
+	conn   net.Conn
+	nextID int32
+
+	// onExec and onClose, when set, report to the adapter's rconStats.
+	onExec  func(err error)
+	onClose func()
+}
+
+// dialRCON connects to host:port and authenticates with password. The
+// context deadline (or 5s) bounds the whole session.
+func dialRCON(ctx context.Context, host string, port int, password string) (*rconConn, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial rcon %s: %w", addr, err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	c := &rconConn{conn: conn}
+	id, err := c.write(rconTypeAuth, password)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// Some servers send an empty response value ahead of the auth response.
+	for {
+		gotID, typ, _, err := c.read()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("read rcon auth: %w", err)
+		}
+		if typ != rconTypeCommand { // auth response shares the command type
+			continue
+		}
+		if gotID == -1 || gotID != id {
+			conn.Close()
+			return nil, errRCONAuth
+		}
+		return c, nil
+	}
+}
+
+// Exec runs one command and returns its (single packet) output.
+func (c *rconConn) Exec(command string) (out string, err error) {
+	if c.onExec != nil {
+		defer func() { c.onExec(err) }()
+	}
+	id, err := c.write(rconTypeCommand, command)
+	if err != nil {
+		return "", err
+	}
+	for {
+		gotID, typ, body, err := c.read()
+		if err != nil {
+			return "", fmt.Errorf("read rcon response: %w", err)
+		}
+		if gotID == id && typ == rconTypeResponse {
+			return body, nil
+		}
+	}
+}
+
+func (c *rconConn) Close() error {
+	if c.onClose != nil {
+		c.onClose()
+		c.onClose = nil
+	}
+	return c.conn.Close()
+}
+
+func (c *rconConn) write(typ int32, body string) (int32, error) {
+	if len(body) > rconMaxPayload-10 {
+		return 0, fmt.Errorf("rcon payload too large (%d bytes)", len(body))
+	}
+	c.nextID++
+	id := c.nextID
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	_ = binary.Write(&buf, binary.LittleEndian, id)
+	_ = binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("write rcon packet: %w", err)
+	}
+	return id, nil
+}
+
+func (c *rconConn) read() (id, typ int32, body string, err error) {
+	var size int32
+	if err := binary.Read(c.conn, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", err
+	}
+	if size < 10 || size > rconMaxPayload+10 {
+		return 0, 0, "", fmt.Errorf("invalid rcon packet size %d", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, payload); err != nil {
+		return 0, 0, "", err
+	}
+	id = int32(binary.LittleEndian.Uint32(payload[0:4]))
+	typ = int32(binary.LittleEndian.Uint32(payload[4:8]))
+	return id, typ, string(bytes.TrimRight(payload[8:], "\x00")), nil
+}
+
+func (a *Adapter) rconConfigured() bool {
+	return a.gameHost != "" && a.rconPassword != ""
+}
+
+// SendCommandOutput runs command over RCON and returns the server's reply.
+// Without RCON configured the command is only logged.
+func (a *Adapter) SendCommandOutput(ctx context.Context, command string) (string, error) {
+	if !a.rconConfigured() {
+		a.log.Info("minecraft command (stub)", "cmd", command)
+		return "", nil
+	}
+	rconCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	conn, err := a.dialRCON(rconCtx)
+	if err != nil {
+		return "", fmt.Errorf("send command: %w", err)
+	}
+	defer conn.Close()
+	return conn.Exec(command)
+}
+
+// quiesce prepares a hot backup when BACKUP_FLUSH_SAVE is on and the server
+// is running: autosave is turned off and the world flushed so region files
+// are consistent on disk. The returned func turns saving back on and must run
+// whether or not the backup succeeds; it is a no-op when nothing was paused.
+func (a *Adapter) quiesce(ctx context.Context) (func(), error) {
+	a.mu.Lock()
+	running := a.running
+	a.mu.Unlock()
+	if !a.flushSave || !running || !a.rconConfigured() {
+		return func() {}, nil
+	}
+
+	rconCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	conn, err := a.dialRCON(rconCtx)
+	if err != nil {
+		return nil, fmt.Errorf("flush save: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec("save-off"); err != nil {
+		return nil, fmt.Errorf("flush save: save-off: %w", err)
+	}
+	resume := func() {
+		// The backup context may already be cancelled; saving must come back.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		conn, err := a.dialRCON(ctx)
+		if err == nil {
+			_, err = conn.Exec("save-on")
+			conn.Close()
+		}
+		if err != nil {
+			a.log.Error("minecraft save-on failed; autosave is still off", "err", err)
+		}
+	}
+	if _, err := conn.Exec("save-all flush"); err != nil {
+		resume()
+		return nil, fmt.Errorf("flush save: save-all flush: %w", err)
+	}
+	a.log.Info("minecraft world flushed for backup")
+	return resume, nil
+}
@@ -0,0 +1,112 @@
+package minecraft
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// rconStats tracks the RCON sessions the adapter opens. Every command dials
+// its own session, so "connected" means a session is open right now or the
+// last attempt worked, and a reconnect is a session that succeeded after a
+// failure.
+type rconStats struct {
+	mu          sync.Mutex
+	open        int
+	dials       int64
+	failures    int64
+	reconnects  int64
+	failing     bool
+	lastCommand time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// dialRCON opens an RCON session to the configured server, recording the
+// attempt for ConnectionState.
+func (a *Adapter) dialRCON(ctx context.Context) (*rconConn, error) {
+	conn, err := dialRCON(ctx, a.gameHost, a.rconPort, a.rconPassword)
+	s := &a.rconStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dials++
+	if err != nil {
+		s.recordFailure(a.redact(err), a.clock.Now())
+		return nil, err
+	}
+	if s.failing {
+		s.reconnects++
+		s.failing = false
+	}
+	s.open++
+	conn.onExec, conn.onClose = a.rconExecDone, a.rconClosed
+	return conn, nil
+}
+
+// rconExecDone records the outcome of one command.
+func (a *Adapter) rconExecDone(err error) {
+	s := &a.rconStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.recordFailure(a.redact(err), a.clock.Now())
+		return
+	}
+	s.failing = false
+	s.lastCommand = a.clock.Now()
+}
+
+func (a *Adapter) rconClosed() {
+	a.rconStats.mu.Lock()
+	a.rconStats.open--
+	a.rconStats.mu.Unlock()
+}
+
+func (s *rconStats) recordFailure(msg string, at time.Time) {
+	s.failures++
+	s.failing = true
+	s.lastError = msg
+	s.lastErrorAt = at
+}
+
+// redact keeps the RCON password out of an error that is shown over the API.
+func (a *Adapter) redact(err error) string {
+	msg := err.Error()
+	if a.rconPassword != "" {
+		msg = strings.ReplaceAll(msg, a.rconPassword, "[redacted]")
+	}
+	return msg
+}
+
+// ConnectionState reports the RCON sessions seen since the controller
+// started, for /v1/minecraft/connection.
+func (a *Adapter) ConnectionState() domain.ConnectionState {
+	a.mu.Lock()
+	running := a.running
+	a.mu.Unlock()
+
+	s := &a.rconStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := domain.ConnectionState{
+		Configured:  a.rconConfigured(),
+		Running:     running,
+		Connected:   s.open > 0 || (s.dials > 0 && !s.failing),
+		Open:        s.open,
+		Dials:       s.dials,
+		Failures:    s.failures,
+		Reconnects:  s.reconnects,
+		LastError:   s.lastError,
+		LastCommand: s.lastCommand,
+		LastErrorAt: s.lastErrorAt,
+	}
+	if state.Configured {
+		state.Address = net.JoinHostPort(a.gameHost, strconv.Itoa(a.rconPort))
+	}
+	return state
+}
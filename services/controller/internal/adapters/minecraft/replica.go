@@ -0,0 +1,126 @@
+package minecraft
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/awsruntime"
+)
+
+// Backups are optionally mirrored to BACKUP_BUCKET_SECONDARY in
+// BACKUP_SECONDARY_REGION for disaster recovery. Writes go to both buckets;
+// reads fall back to the secondary when the primary fails.
+
+func (a *Adapter) secondaryConfigured() bool {
+	return a.secondaryBucket != "" && a.secondaryRegion != ""
+}
+
+func (a *Adapter) secondaryAWSClient(ctx context.Context) (*awsruntime.Client, error) {
+	a.mu.Lock()
+	existing := a.awsSecondary
+	a.mu.Unlock()
+	if existing != nil {
+		return existing, nil
+	}
+
+	client, err := awsruntime.New(ctx, a.secondaryRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	if a.awsSecondary == nil {
+		a.awsSecondary = client
+	} else {
+		client = a.awsSecondary
+	}
+	a.mu.Unlock()
+	return client, nil
+}
+
+// replicateBackup copies a finished backup and its marker to the secondary
+// bucket. Failures are logged but do not fail the backup, since the primary
+// copy is already durable.
+func (a *Adapter) replicateBackup(ctx context.Context, key, path string, opts awsruntime.PutOptions, markerKey, markerValue string) {
+	if !a.secondaryConfigured() {
+		return
+	}
+
+	client, err := a.secondaryAWSClient(ctx)
+	if err == nil {
+		err = client.UploadFile(ctx, a.secondaryBucket, key, path, opts)
+	}
+	if err == nil {
+		err = client.PutString(ctx, a.secondaryBucket, markerKey, markerValue)
+	}
+	if err != nil {
+		a.log.Warn("minecraft backup replication failed",
+			"bucket", a.secondaryBucket, "region", a.secondaryRegion, "key", key, "err", err)
+		return
+	}
+	a.log.Info("minecraft backup replicated", "bucket", a.secondaryBucket, "region", a.secondaryRegion, "key", key)
+}
+
+// downloadBackup downloads bucket/key, falling back to the secondary bucket
+// when the object lives in the primary bucket and the primary read fails. It
+// returns the region that served the object.
+func (a *Adapter) downloadBackup(ctx context.Context, bucket, key, path string) (string, error) {
+	if a.secondaryConfigured() && bucket == a.secondaryBucket {
+		client, err := a.secondaryAWSClient(ctx)
+		if err != nil {
+			return "", err
+		}
+		if err := client.DownloadFile(ctx, bucket, key, path); err != nil {
+			return "", err
+		}
+		return a.secondaryRegion, nil
+	}
+
+	client, err := a.awsClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	primaryErr := client.DownloadFile(ctx, bucket, key, path)
+	if primaryErr == nil {
+		return a.awsRegion, nil
+	}
+	if !a.secondaryConfigured() || bucket != a.bucket {
+		return "", primaryErr
+	}
+
+	a.log.Warn("minecraft primary backup download failed, trying secondary",
+		"bucket", bucket, "key", key, "err", primaryErr)
+	secondary, err := a.secondaryAWSClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w (secondary: %v)", primaryErr, err)
+	}
+	if err := secondary.DownloadFile(ctx, a.secondaryBucket, key, path); err != nil {
+		return "", fmt.Errorf("%w (secondary: %v)", primaryErr, err)
+	}
+	return a.secondaryRegion, nil
+}
+
+// readMarker reads a latest marker from the primary bucket, falling back to
+// the secondary bucket.
+func (a *Adapter) readMarker(ctx context.Context, markerKey string) (string, error) {
+	client, err := a.awsClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	value, primaryErr := client.GetString(ctx, a.bucket, markerKey)
+	if primaryErr == nil || !a.secondaryConfigured() {
+		return value, primaryErr
+	}
+
+	a.log.Warn("minecraft primary latest marker read failed, trying secondary",
+		"key", markerKey, "err", primaryErr)
+	secondary, err := a.secondaryAWSClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w (secondary: %v)", primaryErr, err)
+	}
+	value, err = secondary.GetString(ctx, a.secondaryBucket, markerKey)
+	if err != nil {
+		return "", fmt.Errorf("%w (secondary: %v)", primaryErr, err)
+	}
+	return value, nil
+}
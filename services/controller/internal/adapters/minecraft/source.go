@@ -0,0 +1,80 @@
+package minecraft
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// ValidateSource checks a source URL the way a seed would use it, without
+// touching the data dir: git ls-remote (with the current token) resolves the
+// ref to a commit, and when the URL names a path a blobless, no-checkout
+// clone of that ref confirms the path is in its tree.
+func (a *Adapter) ValidateSource(ctx context.Context, sourceURL string) (domain.SourceCheck, error) {
+	sourceURL = strings.TrimSpace(sourceURL)
+	repoURL, ref, repoPath := parseSourceURL(sourceURL)
+	check := domain.SourceCheck{Source: sourceURL, Ref: ref, Path: repoPath}
+
+	if err := a.checkGitHost(repoURL); err != nil {
+		return check, err
+	}
+	token := a.currentGitToken()
+	authURL, err := gitAuthURL(repoURL, token)
+	if err != nil {
+		return check, fmt.Errorf("%w: %v", domain.ErrInvalidSourceURL, err)
+	}
+	out, err := a.runGitNetwork(ctx, "", "ls-remote", authURL, ref)
+	if err != nil {
+		return check, fmt.Errorf("%w: %s", domain.ErrSourceUnreachable, scrubToken(err, token))
+	}
+	commit, ok := resolveRemoteRef(out, ref)
+	if !ok {
+		return check, fmt.Errorf("%w: %s", domain.ErrSourceRefNotFound, ref)
+	}
+	check.Commit = commit
+
+	if repoPath == "" {
+		return check, nil
+	}
+	tmpDir, err := os.MkdirTemp(a.tmpDir, "minecraft-validate-*")
+	if err != nil {
+		return check, fmt.Errorf("create temp validate dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	repoDir := filepath.Join(tmpDir, "repo")
+	if _, err := a.runGitNetwork(ctx, repoDir, "clone", "--depth", "1", "--filter=blob:none", "--no-checkout", "--branch", ref, authURL, repoDir); err != nil {
+		return check, fmt.Errorf("%w: %s", domain.ErrSourceUnreachable, scrubToken(err, token))
+	}
+	tree, err := a.run(ctx, "git", "-C", repoDir, "ls-tree", "--name-only", "HEAD", "--", strings.TrimSuffix(repoPath, "/"))
+	if err != nil {
+		return check, err
+	}
+	if strings.TrimSpace(tree) == "" {
+		return check, fmt.Errorf("%w: %s", domain.ErrSourcePathNotFound, repoPath)
+	}
+	return check, nil
+}
+
+// resolveRemoteRef picks ref's commit from git ls-remote output: a branch
+// first, then a tag, preferring an annotated tag's peeled commit (^{}).
+// ls-remote matches patterns by suffix, so names are compared exactly.
+func resolveRemoteRef(lsRemote, ref string) (string, bool) {
+	shas := map[string]string{}
+	for _, line := range strings.Split(lsRemote, "\n") {
+		sha, name, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if ok {
+			shas[name] = sha
+		}
+	}
+	for _, name := range []string{"refs/heads/" + ref, "refs/tags/" + ref + "^{}", "refs/tags/" + ref} {
+		if sha, ok := shas[name]; ok {
+			return sha, true
+		}
+	}
+	return "", false
+}
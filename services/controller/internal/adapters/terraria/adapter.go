@@ -0,0 +1,305 @@
+package terraria
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/archive"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/ecsgame"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// worldExtensions are the files that make up a Terraria world: the vanilla
+// .wld, tModLoader's .twld mod data, and the game's own .bak copies of both.
+var worldExtensions = []string{".wld", ".twld", ".wld.bak", ".twld.bak"}
+
+type Adapter struct {
+	log        *slog.Logger
+	mu         sync.Mutex
+	running    bool
+	lastBackup string
+
+	rt        *ecsgame.Runtime
+	worldsDir string
+
+	restURL   string // TShock REST API, e.g. http://terraria:7878
+	restToken string
+}
+
+func NewAdapter(log *slog.Logger) *Adapter {
+	return &Adapter{
+		log:       log,
+		rt:        ecsgame.New(log, "terraria", "ECS_SERVICE_TERRARIA"),
+		worldsDir: env.OrDefault("TERRARIA_WORLDS_DIR", "/srv/terraria-data/Worlds"),
+		restURL:   strings.TrimRight(strings.TrimSpace(os.Getenv("TERRARIA_REST_URL")), "/"),
+		restToken: strings.TrimSpace(os.Getenv("TERRARIA_REST_TOKEN")),
+	}
+}
+
+func (a *Adapter) SetClock(clock domain.Clock) { a.rt.Clock = clock }
+
+func (a *Adapter) Type() domain.GameType { return domain.GameTerraria }
+
+func (a *Adapter) Validate() error {
+	errs := []error{a.rt.Validate()}
+	if a.restURL == "" {
+		errs = append(errs, fmt.Errorf("%w: TERRARIA_REST_URL not set, commands are not delivered", domain.ErrAdapterDegraded))
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Adapter) Preflight(ctx context.Context) error { return a.rt.Preflight(ctx) }
+
+func (a *Adapter) HealthChecks(string) map[string]func(ctx context.Context) error {
+	return a.rt.HealthChecks()
+}
+
+func (a *Adapter) Start(ctx context.Context) error {
+	if err := a.rt.SetDesiredCount(ctx, 1); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.running = true
+	a.mu.Unlock()
+	a.log.Info("terraria start", "cluster", a.rt.Cluster, "service", a.rt.Service)
+	return nil
+}
+
+func (a *Adapter) Stop(ctx context.Context) error {
+	if err := a.rt.SetDesiredCount(ctx, 0); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.running = false
+	a.mu.Unlock()
+	a.log.Info("terraria stop", "cluster", a.rt.Cluster, "service", a.rt.Service)
+	return nil
+}
+
+func (a *Adapter) Backup(ctx context.Context) (string, error) {
+	report, err := a.BackupReport(ctx)
+	return report.Backup, err
+}
+
+// BackupReport zips the world files in the worlds dir. Other files there
+// (configs, player data) belong to the server image and are not backed up.
+func (a *Adapter) BackupReport(ctx context.Context) (domain.BackupReport, error) {
+	worlds, err := a.worldFiles()
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+	if len(worlds) == 0 {
+		return domain.BackupReport{}, fmt.Errorf("no world files in %s", a.worldsDir)
+	}
+
+	report, err := a.rt.ZipBackup(ctx, a.worldsDir, func(rel string, d fs.DirEntry) bool {
+		return !d.IsDir() && isWorldFile(rel)
+	})
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+
+	a.mu.Lock()
+	a.lastBackup = report.Backup
+	a.mu.Unlock()
+	a.log.Info("terraria backup complete", "backup", report.Backup, "worlds", len(worlds),
+		"files", report.Files, "bytes", report.Bytes, "archive_bytes", report.ArchiveBytes)
+	return report, nil
+}
+
+// WorldModTime is the newest modification time of the world files a backup
+// would capture, or zero when there are none.
+func (a *Adapter) WorldModTime(ctx context.Context) (time.Time, error) {
+	return archive.NewestModTime(a.worldsDir, func(rel string, d fs.DirEntry) bool {
+		return !d.IsDir() && isWorldFile(rel)
+	})
+}
+
+func (a *Adapter) Restore(ctx context.Context, backupKey string) error {
+	return a.RestoreWithOptions(ctx, backupKey, domain.RestoreOptions{})
+}
+
+// RestoreWithOptions replaces the world files with those in the backup.
+// Non-world files in the worlds dir are left alone. The archive is checked,
+// and the ECS task must be stopped unless forced, before any world file is
+// removed.
+func (a *Adapter) RestoreWithOptions(ctx context.Context, backupKey string, opts domain.RestoreOptions) error {
+	tmpZipPath, err := a.rt.TempFile("terraria-restore-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpZipPath)
+
+	backup, err := a.rt.DownloadBackup(ctx, backupKey, tmpZipPath)
+	if err != nil {
+		return err
+	}
+
+	if err := archive.Validate(tmpZipPath); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidArchive, err)
+	}
+	entries, err := archive.Entries(tmpZipPath)
+	if err != nil {
+		return err
+	}
+	if err := checkWorldEntries(entries); err != nil {
+		return fmt.Errorf("refusing restore of %s: %w", backup, err)
+	}
+
+	if !opts.Force {
+		if err := a.rt.EnsureIdle(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(a.worldsDir, 0o755); err != nil {
+		return fmt.Errorf("create worlds dir: %w", err)
+	}
+	existing, err := a.worldFiles()
+	if err != nil {
+		return err
+	}
+	for _, name := range existing {
+		if err := os.Remove(filepath.Join(a.worldsDir, name)); err != nil {
+			return fmt.Errorf("remove old world file %s: %w", name, err)
+		}
+	}
+	if err := archive.UnzipToDirectory(tmpZipPath, a.worldsDir); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.lastBackup = backup
+	a.mu.Unlock()
+	a.log.Info("terraria restore complete", "backup", backup)
+	return nil
+}
+
+func (a *Adapter) LatestBackup(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	lastBackup := a.lastBackup
+	a.mu.Unlock()
+	if lastBackup != "" {
+		return lastBackup, nil
+	}
+	return a.rt.LatestBackup(ctx)
+}
+
+// Terraria worlds are generated by the server, not kept in git.
+func (a *Adapter) SeedFromSource(ctx context.Context, sourceURL string) error {
+	return domain.ErrNotSupported
+}
+
+func (a *Adapter) SyncToSource(ctx context.Context, sourceURL string) error {
+	return domain.ErrNotSupported
+}
+
+// Capabilities: commands need the TShock REST API; seed and sync are not
+// implemented.
+func (a *Adapter) Capabilities() domain.Capabilities {
+	return domain.Capabilities{
+		Backup:  a.rt.S3Configured(),
+		Command: a.restURL != "",
+	}
+}
+
+func (a *Adapter) SendCommand(ctx context.Context, command string) error {
+	_, err := a.SendCommandOutput(ctx, command)
+	return err
+}
+
+// SendCommandOutput runs command through the TShock REST API when configured.
+// Vanilla and tModLoader servers only read commands from the container's
+// stdin, which the controller can't reach, so without REST this is a stub.
+func (a *Adapter) SendCommandOutput(ctx context.Context, command string) (string, error) {
+	if a.restURL == "" {
+		a.log.Info("terraria command (stub)", "cmd", command)
+		return "", nil
+	}
+	return a.restCommand(ctx, command)
+}
+
+func (a *Adapter) Status(ctx context.Context) (map[string]any, error) {
+	a.mu.Lock()
+	running := a.running
+	lastBackup := a.lastBackup
+	a.mu.Unlock()
+
+	channel := "stub"
+	if a.restURL != "" {
+		channel = "tshock-rest"
+	}
+	out := map[string]any{
+		"adapter":         "terraria",
+		"ready":           true,
+		"running":         running,
+		"last_backup":     lastBackup,
+		"worlds_dir":      a.worldsDir,
+		"command_channel": channel,
+	}
+	if worlds, err := a.worldFiles(); err == nil {
+		out["world_files"] = worlds
+	}
+	if len(a.rt.CapacityProviders) > 0 {
+		out["capacity_providers"] = a.rt.CapacityProviders
+	}
+	return out, nil
+}
+
+// worldFiles lists the world files directly in the worlds dir.
+func (a *Adapter) worldFiles() ([]string, error) {
+	entries, err := os.ReadDir(a.worldsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read worlds dir: %w", err)
+	}
+	var out []string
+	for _, e := range entries {
+		if !e.IsDir() && isWorldFile(e.Name()) {
+			out = append(out, e.Name())
+		}
+	}
+	return out, nil
+}
+
+// checkWorldEntries refuses a backup with anything but world files in it,
+// or without a .wld to load.
+func checkWorldEntries(entries []string) error {
+	worlds := 0
+	for _, name := range entries {
+		if !isWorldFile(name) {
+			return fmt.Errorf("%w: unexpected file %q in terraria backup", domain.ErrIncompleteBackup, name)
+		}
+		if strings.HasSuffix(strings.ToLower(name), ".wld") {
+			worlds++
+		}
+	}
+	if worlds == 0 {
+		return fmt.Errorf("%w: no .wld world in terraria backup", domain.ErrIncompleteBackup)
+	}
+	return nil
+}
+
+func isWorldFile(name string) bool {
+	if strings.Contains(name, "/") {
+		return false
+	}
+	lower := strings.ToLower(name)
+	for _, ext := range worldExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
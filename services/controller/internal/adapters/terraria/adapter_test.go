@@ -0,0 +1,30 @@
+package terraria
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+func TestCheckWorldEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr error
+	}{
+		{name: "vanilla world", entries: []string{"World.wld", "World.wld.bak"}},
+		{name: "tmodloader world", entries: []string{"World.wld", "World.twld", "World.twld.bak"}},
+		{name: "empty", wantErr: domain.ErrIncompleteBackup},
+		{name: "backup copies only", entries: []string{"World.wld.bak", "World.twld"}, wantErr: domain.ErrIncompleteBackup},
+		{name: "unexpected file", entries: []string{"World.wld", "serverconfig.txt"}, wantErr: domain.ErrIncompleteBackup},
+		{name: "nested world", entries: []string{"old/World.wld"}, wantErr: domain.ErrIncompleteBackup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkWorldEntries(tt.entries); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkWorldEntries(%q) = %v, want %v", tt.entries, err, tt.wantErr)
+			}
+		})
+	}
+}
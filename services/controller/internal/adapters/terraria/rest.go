@@ -0,0 +1,54 @@
+package terraria
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var restClient = &http.Client{Timeout: 15 * time.Second}
+
+// restResponse is TShock's /v2/server/rawcmd reply. status is a string
+// ("200") in TShock's JSON.
+type restResponse struct {
+	Status   string   `json:"status"`
+	Response []string `json:"response"`
+	Error    string   `json:"error"`
+}
+
+// restCommand runs a server command through TShock's REST API and returns the
+// output lines.
+func (a *Adapter) restCommand(ctx context.Context, command string) (string, error) {
+	command = strings.TrimSpace(command)
+	if !strings.HasPrefix(command, "/") {
+		command = "/" + command
+	}
+	q := url.Values{"cmd": {command}, "token": {a.restToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.restURL+"/v2/server/rawcmd?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("terraria rest command: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out restResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode terraria rest response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || out.Status != "200" {
+		msg := out.Error
+		if msg == "" {
+			msg = resp.Status
+		}
+		return "", fmt.Errorf("terraria rest command failed: %s", msg)
+	}
+	return strings.Join(out.Response, "\n"), nil
+}
@@ -0,0 +1,373 @@
+package valheim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/adapters/archive"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/ecsgame"
+	"github.com/esuEdu/game-infra/controller/internal/adapters/env"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// A Valheim world is a <name>.db (terrain/objects) plus a <name>.fwl
+// (metadata). The two must always move together: the server refuses to load,
+// or silently regenerates, a world whose halves don't match.
+const (
+	extDB  = ".db"
+	extFWL = ".fwl"
+)
+
+type Adapter struct {
+	log        *slog.Logger
+	mu         sync.Mutex
+	running    bool
+	lastBackup string
+
+	rt        *ecsgame.Runtime
+	worldsDir string
+	world     string // server -world name; "" accepts any paired world
+}
+
+func NewAdapter(log *slog.Logger) *Adapter {
+	return &Adapter{
+		log:       log,
+		rt:        ecsgame.New(log, "valheim", "ECS_SERVICE_VALHEIM"),
+		worldsDir: env.OrDefault("VALHEIM_WORLDS_DIR", "/srv/valheim-data/worlds_local"),
+		world:     strings.TrimSpace(os.Getenv("VALHEIM_WORLD")),
+	}
+}
+
+func (a *Adapter) SetClock(clock domain.Clock) { a.rt.Clock = clock }
+
+func (a *Adapter) Type() domain.GameType { return domain.GameValheim }
+
+func (a *Adapter) Validate() error { return a.rt.Validate() }
+
+func (a *Adapter) Preflight(ctx context.Context) error { return a.rt.Preflight(ctx) }
+
+func (a *Adapter) HealthChecks(string) map[string]func(ctx context.Context) error {
+	return a.rt.HealthChecks()
+}
+
+func (a *Adapter) Start(ctx context.Context) error {
+	if err := a.rt.SetDesiredCount(ctx, 1); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.running = true
+	a.mu.Unlock()
+	a.log.Info("valheim start", "cluster", a.rt.Cluster, "service", a.rt.Service)
+	return nil
+}
+
+func (a *Adapter) Stop(ctx context.Context) error {
+	if err := a.rt.SetDesiredCount(ctx, 0); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.running = false
+	a.mu.Unlock()
+	a.log.Info("valheim stop", "cluster", a.rt.Cluster, "service", a.rt.Service)
+	return nil
+}
+
+func (a *Adapter) Backup(ctx context.Context) (string, error) {
+	report, err := a.BackupReport(ctx)
+	return report.Backup, err
+}
+
+// BackupReport zips every .db/.fwl pair in the worlds dir. It refuses to
+// write a backup containing half a world.
+func (a *Adapter) BackupReport(ctx context.Context) (domain.BackupReport, error) {
+	names, err := a.worldFiles()
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+	worlds, err := a.checkPairs(names)
+	if err != nil {
+		return domain.BackupReport{}, fmt.Errorf("refusing backup of %s: %w", a.worldsDir, err)
+	}
+
+	report, err := a.rt.ZipBackup(ctx, a.worldsDir, func(rel string, d fs.DirEntry) bool {
+		return !d.IsDir() && isWorldFile(rel)
+	})
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+
+	a.mu.Lock()
+	a.lastBackup = report.Backup
+	a.mu.Unlock()
+	a.log.Info("valheim backup complete", "backup", report.Backup, "worlds", worlds,
+		"files", report.Files, "bytes", report.Bytes, "archive_bytes", report.ArchiveBytes)
+	return report, nil
+}
+
+// WorldModTime is the newest modification time of the world files a backup
+// would capture, or zero when there are none.
+func (a *Adapter) WorldModTime(ctx context.Context) (time.Time, error) {
+	return archive.NewestModTime(a.worldsDir, func(rel string, d fs.DirEntry) bool {
+		return !d.IsDir() && isWorldFile(rel)
+	})
+}
+
+func (a *Adapter) Restore(ctx context.Context, backupKey string) error {
+	return a.RestoreWithOptions(ctx, backupKey, domain.RestoreOptions{})
+}
+
+// RestoreWithOptions checks the backup's pairing before touching the worlds
+// dir, so an orphaned .db or .fwl never replaces a working world.
+func (a *Adapter) RestoreWithOptions(ctx context.Context, backupKey string, opts domain.RestoreOptions) error {
+	tmpZipPath, err := a.rt.TempFile("valheim-restore-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpZipPath)
+
+	backup, err := a.rt.DownloadBackup(ctx, backupKey, tmpZipPath)
+	if err != nil {
+		return err
+	}
+
+	entries, err := archive.Entries(tmpZipPath)
+	if err != nil {
+		return err
+	}
+	for _, name := range entries {
+		if !isWorldFile(name) {
+			return fmt.Errorf("%w: unexpected file %q in valheim backup", domain.ErrIncompleteBackup, name)
+		}
+	}
+	worlds, err := a.checkPairs(entries)
+	if err != nil {
+		return fmt.Errorf("refusing restore of %s: %w", backup, err)
+	}
+
+	if !opts.Force {
+		if err := a.rt.EnsureIdle(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(a.worldsDir, 0o755); err != nil {
+		return fmt.Errorf("create worlds dir: %w", err)
+	}
+	// Extract beside the worlds dir (so the swap is renames on one
+	// filesystem) and check what landed before touching the live worlds.
+	stage, err := os.MkdirTemp(filepath.Dir(a.worldsDir), ".valheim-restore-*")
+	if err != nil {
+		return fmt.Errorf("create restore staging dir: %w", err)
+	}
+	defer os.RemoveAll(stage)
+	if err := archive.UnzipToDirectory(tmpZipPath, stage); err != nil {
+		return err
+	}
+	staged, err := worldFilesIn(stage)
+	if err != nil {
+		return err
+	}
+	if _, err := a.checkPairs(staged); err != nil {
+		return fmt.Errorf("refusing restore of %s: %w", backup, err)
+	}
+	if err := a.swapWorlds(stage, staged); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.lastBackup = backup
+	a.mu.Unlock()
+	a.log.Info("valheim restore complete", "backup", backup, "worlds", worlds)
+	return nil
+}
+
+func (a *Adapter) LatestBackup(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	lastBackup := a.lastBackup
+	a.mu.Unlock()
+	if lastBackup != "" {
+		return lastBackup, nil
+	}
+	return a.rt.LatestBackup(ctx)
+}
+
+// Valheim worlds are generated by the server, not kept in git.
+func (a *Adapter) SeedFromSource(ctx context.Context, sourceURL string) error {
+	return domain.ErrNotSupported
+}
+
+func (a *Adapter) SyncToSource(ctx context.Context, sourceURL string) error {
+	return domain.ErrNotSupported
+}
+
+func (a *Adapter) Capabilities() domain.Capabilities {
+	return domain.Capabilities{Backup: a.rt.S3Configured()}
+}
+
+// Valheim dedicated servers have no command channel.
+func (a *Adapter) SendCommand(ctx context.Context, command string) error {
+	a.log.Info("valheim command (stub)", "cmd", command)
+	return nil
+}
+
+func (a *Adapter) Status(ctx context.Context) (map[string]any, error) {
+	a.mu.Lock()
+	running := a.running
+	lastBackup := a.lastBackup
+	a.mu.Unlock()
+
+	out := map[string]any{
+		"adapter":     "valheim",
+		"ready":       true,
+		"running":     running,
+		"last_backup": lastBackup,
+		"worlds_dir":  a.worldsDir,
+	}
+	if len(a.rt.CapacityProviders) > 0 {
+		out["capacity_providers"] = a.rt.CapacityProviders
+	}
+	if a.world != "" {
+		out["world"] = a.world
+	}
+	if names, err := a.worldFiles(); err == nil {
+		worlds, err := a.checkPairs(names)
+		out["worlds"] = worlds
+		if err != nil {
+			out["worlds_error"] = err.Error()
+		}
+	}
+	return out, nil
+}
+
+// checkPairs groups world files by name and fails with ErrIncompleteBackup if
+// any world is missing its .db or .fwl, or if the configured world is absent.
+// It returns the paired world names.
+func (a *Adapter) checkPairs(names []string) ([]string, error) {
+	halves := map[string][2]bool{} // world -> {has .db, has .fwl}
+	for _, name := range names {
+		ext := filepath.Ext(name)
+		world := strings.TrimSuffix(name, ext)
+		h := halves[world]
+		switch strings.ToLower(ext) {
+		case extDB:
+			h[0] = true
+		case extFWL:
+			h[1] = true
+		default:
+			continue
+		}
+		halves[world] = h
+	}
+
+	var worlds, orphans []string
+	for world, h := range halves {
+		switch {
+		case h[0] && h[1]:
+			worlds = append(worlds, world)
+		case h[0]:
+			orphans = append(orphans, world+extDB+" (no "+extFWL+")")
+		default:
+			orphans = append(orphans, world+extFWL+" (no "+extDB+")")
+		}
+	}
+	sort.Strings(worlds)
+	sort.Strings(orphans)
+
+	if len(orphans) > 0 {
+		return worlds, fmt.Errorf("%w: unpaired world files: %s", domain.ErrIncompleteBackup, strings.Join(orphans, ", "))
+	}
+	if len(worlds) == 0 {
+		return nil, fmt.Errorf("%w: no valheim worlds", domain.ErrIncompleteBackup)
+	}
+	if a.world != "" && !slices.Contains(worlds, a.world) {
+		return worlds, fmt.Errorf("%w: world %q not found", domain.ErrIncompleteBackup, a.world)
+	}
+	return worlds, nil
+}
+
+// worldFiles lists the .db/.fwl files directly in the worlds dir.
+func (a *Adapter) worldFiles() ([]string, error) { return worldFilesIn(a.worldsDir) }
+
+// swapWorlds replaces the world files in the worlds dir with names from
+// stage. The old worlds are moved aside first and moved back if any rename
+// fails, so the dir never holds half of one world and half of another.
+func (a *Adapter) swapWorlds(stage string, names []string) (err error) {
+	existing, err := a.worldFiles()
+	if err != nil {
+		return err
+	}
+	aside, err := os.MkdirTemp(filepath.Dir(a.worldsDir), ".valheim-old-*")
+	if err != nil {
+		return fmt.Errorf("create dir for the old worlds: %w", err)
+	}
+	var movedAside, placed []string
+	defer func() {
+		if err != nil {
+			for _, name := range placed {
+				_ = os.Remove(filepath.Join(a.worldsDir, name))
+			}
+			for _, name := range movedAside {
+				if rerr := os.Rename(filepath.Join(aside, name), filepath.Join(a.worldsDir, name)); rerr != nil {
+					a.log.Error("valheim restore: put back old world file", "file", name, "kept_in", aside, "err", rerr)
+					return // leave aside in place; it holds the only copy
+				}
+			}
+		}
+		_ = os.RemoveAll(aside)
+	}()
+
+	for _, name := range existing {
+		if err := os.Rename(filepath.Join(a.worldsDir, name), filepath.Join(aside, name)); err != nil {
+			return fmt.Errorf("move old world file %s aside: %w", name, err)
+		}
+		movedAside = append(movedAside, name)
+	}
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(stage, name), filepath.Join(a.worldsDir, name)); err != nil {
+			return fmt.Errorf("move restored world file %s into place: %w", name, err)
+		}
+		placed = append(placed, name)
+	}
+	return nil
+}
+
+// worldFilesIn lists the world files directly in dir.
+func worldFilesIn(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read worlds dir: %w", err)
+	}
+	var out []string
+	for _, e := range entries {
+		if !e.IsDir() && isWorldFile(e.Name()) {
+			out = append(out, e.Name())
+		}
+	}
+	return out, nil
+}
+
+// isWorldFile matches live world halves only; Valheim's own .old copies and
+// timestamped auto-backups are left out of our backups.
+func isWorldFile(name string) bool {
+	if strings.Contains(name, "/") || strings.Contains(name, "_backup_") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case extDB, extFWL:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package valheim
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+func TestIsWorldFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Dedicated.db", true},
+		{"Dedicated.fwl", true},
+		{"Dedicated.DB", true},
+		{"Dedicated.db.old", false},
+		{"Dedicated.fwl.old", false},
+		{"Dedicated_backup_auto-20250101000000.db", false},
+		{"Dedicated_backup_auto-20250101000000.fwl", false},
+		{"worlds/Dedicated.db", false},
+		{"Dedicated", false},
+		{"adminlist.txt", false},
+	}
+	for _, tt := range tests {
+		if got := isWorldFile(tt.name); got != tt.want {
+			t.Errorf("isWorldFile(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPairs(t *testing.T) {
+	tests := []struct {
+		name       string
+		world      string
+		names      []string
+		wantWorlds []string
+		wantErr    bool
+	}{
+		{name: "one pair", names: []string{"Dedicated.db", "Dedicated.fwl"}, wantWorlds: []string{"Dedicated"}},
+		{name: "two pairs", names: []string{"B.fwl", "A.db", "B.db", "A.fwl"}, wantWorlds: []string{"A", "B"}},
+		{name: "orphaned db", names: []string{"Dedicated.db", "Dedicated.fwl", "Other.db"}, wantWorlds: []string{"Dedicated"}, wantErr: true},
+		{name: "orphaned fwl", names: []string{"Dedicated.fwl"}, wantErr: true},
+		{name: "no worlds", names: nil, wantErr: true},
+		{name: "auto-backup is not the other half", names: []string{"Dedicated.db", "Dedicated_backup_auto-1.fwl"}, wantErr: true},
+		{name: "configured world present", world: "Dedicated", names: []string{"Dedicated.db", "Dedicated.fwl"}, wantWorlds: []string{"Dedicated"}},
+		{name: "configured world missing", world: "Main", names: []string{"Dedicated.db", "Dedicated.fwl"}, wantWorlds: []string{"Dedicated"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Adapter{world: tt.world}
+			worlds, err := a.checkPairs(tt.names)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("checkPairs error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrIncompleteBackup) {
+				t.Fatalf("error %v is not ErrIncompleteBackup", err)
+			}
+			if !slices.Equal(worlds, tt.wantWorlds) {
+				t.Fatalf("worlds = %q, want %q", worlds, tt.wantWorlds)
+			}
+		})
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]string{}
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[e.Name()] = string(b)
+	}
+	return out
+}
+
+func TestSwapWorlds(t *testing.T) {
+	tests := []struct {
+		name   string
+		staged []string // names passed to swapWorlds; one missing from stage fails it
+		want   map[string]string
+	}{
+		{
+			name:   "replaces the worlds and keeps other files",
+			staged: []string{"New.db", "New.fwl"},
+			want:   map[string]string{"New.db": "new db", "New.fwl": "new fwl", "Old.db.old": "old copy", "adminlist.txt": "admins"},
+		},
+		{
+			name:   "failed move puts the old worlds back",
+			staged: []string{"New.db", "New.fwl", "Missing.fwl"},
+			want:   map[string]string{"Old.db": "old db", "Old.fwl": "old fwl", "Old.db.old": "old copy", "adminlist.txt": "admins"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			worlds := filepath.Join(root, "worlds_local")
+			stage := filepath.Join(root, "stage")
+			for _, dir := range []string{worlds, stage} {
+				if err := os.Mkdir(dir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			writeFiles(t, worlds, map[string]string{"Old.db": "old db", "Old.fwl": "old fwl", "Old.db.old": "old copy", "adminlist.txt": "admins"})
+			writeFiles(t, stage, map[string]string{"New.db": "new db", "New.fwl": "new fwl"})
+
+			a := &Adapter{log: slog.New(slog.DiscardHandler), worldsDir: worlds}
+			err := a.swapWorlds(stage, tt.staged)
+			if wantErr := slices.Contains(tt.staged, "Missing.fwl"); wantErr != (err != nil) {
+				t.Fatalf("swapWorlds error = %v, wantErr %v", err, wantErr)
+			}
+			got := readFiles(t, worlds)
+			if len(got) != len(tt.want) {
+				t.Fatalf("worlds dir = %v, want %v", got, tt.want)
+			}
+			for name, body := range tt.want {
+				if got[name] != body {
+					t.Fatalf("worlds dir = %v, want %v", got, tt.want)
+				}
+			}
+			if leftovers, _ := filepath.Glob(filepath.Join(root, ".valheim-old-*")); len(leftovers) != 0 {
+				t.Fatalf("left %v behind", leftovers)
+			}
+		})
+	}
+}
@@ -1,10 +1,23 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"os"
+	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/esuEdu/game-infra/controller/internal/app"
+	"github.com/esuEdu/game-infra/controller/internal/buildinfo"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/metrics"
+	"github.com/esuEdu/game-infra/controller/internal/service"
 )
 
 type appHandler func(*app.App, http.ResponseWriter, *http.Request) error
@@ -12,6 +25,9 @@ type appHandler func(*app.App, http.ResponseWriter, *http.Request) error
 func wrap(a *app.App, h appHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if wantsPrettyJSON(r) {
+			w = prettyJSONWriter{ResponseWriter: w}
+		}
 
 		if err := h(a, w, r); err != nil {
 			// include rid in logs via middleware logger fields
@@ -27,31 +43,325 @@ func handleHealth() appHandler {
 	}
 }
 
+// handleReady reports the game probe but is 200 whatever it finds, unless
+// ?game=true asks for 503 while the active game is not accepting
+// connections.
+func handleReady() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		requireGame, err := boolQuery(r, "game")
+		if err != nil {
+			return err
+		}
+		report := a.Controller.Ready(r.Context(), requireGame)
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+		return nil
+	}
+}
+
+// handleHealthDetail reports every subsystem with latency and last error,
+// for status pages. It is always 200; /healthz and /readyz remain the probes.
+func handleHealthDetail() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		extra := map[string]func(ctx context.Context) error{
+			"http": listenerCheck(a.Config.HTTPAddr),
+		}
+		if a.Config.GRPCAddr != "" {
+			extra["grpc"] = listenerCheck(a.Config.GRPCAddr)
+		}
+		writeJSON(w, http.StatusOK, a.Controller.HealthDetail(r.Context(), extra))
+		return nil
+	}
+}
+
+// handleVersion reports the running build, so a deploy can check the
+// expected image is live.
+func handleVersion() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		writeJSON(w, http.StatusOK, buildinfo.Get())
+		return nil
+	}
+}
+
+// listenerCheck dials one of the controller's own listeners over loopback.
+func listenerCheck(addr string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return err
+		}
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// handleMetrics is a plain http.Handler: the text format isn't JSON.
+func handleMetrics() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = metrics.Default.WriteText(w)
+	})
+}
+
+func handleGames() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		writeJSON(w, http.StatusOK, map[string]any{"games": a.Controller.Games(r.Context())})
+		return nil
+	}
+}
+
+func handleGame() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		if err := domain.ValidateGameName(r.PathValue("game")); err != nil {
+			return err
+		}
+		info, err := a.Controller.Game(r.Context(), r.PathValue("game"))
+		if errors.Is(err, domain.ErrUnknownGameType) {
+			return httpError{Status: http.StatusNotFound, Message: err.Error()}
+		}
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, info)
+		return nil
+	}
+}
+
 func handleStatus() appHandler {
+	const maxWait = 60 * time.Second
 	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		if raw := r.URL.Query().Get("wait"); raw != "" {
+			wait, err := time.ParseDuration(raw)
+			if err != nil || wait <= 0 {
+				return badRequest("invalid query param: wait")
+			}
+			wait = min(wait, maxWait)
+
+			var since time.Time
+			if rawSince := r.URL.Query().Get("since"); rawSince != "" {
+				since, err = time.Parse(time.RFC3339Nano, rawSince)
+				if err != nil {
+					return badRequest("invalid query param: since (expected RFC3339 updated_at)")
+				}
+			} else {
+				since = time.Now().UTC()
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), wait)
+			changed, err := a.Controller.WaitForChange(ctx, since)
+			cancel()
+			if err != nil {
+				return err
+			}
+			if !changed {
+				w.WriteHeader(http.StatusNotModified)
+				return nil
+			}
+		}
+
 		st, err := a.Controller.Status(r.Context())
 		if err != nil {
 			return err
 		}
+		etag, err := jsonETag(st)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if etagMatches(r, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
 		writeJSON(w, http.StatusOK, st)
 		return nil
 	}
 }
 
+func handleStatusSummary() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		sum, err := a.Controller.StatusSummary(r.Context())
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		writeJSON(w, http.StatusOK, sum)
+		return nil
+	}
+}
+
+// ecsTarget reads the optional cluster/service override of start, stop and
+// switch; the allowlist itself is checked by the service.
+func ecsTarget(cluster, service string) (domain.ECSTarget, error) {
+	t := domain.ECSTarget{Cluster: strings.TrimSpace(cluster), Service: strings.TrimSpace(service)}
+	if (t.Cluster == "") != (t.Service == "") {
+		return domain.ECSTarget{}, badRequest("cluster and service must be given together")
+	}
+	return t, nil
+}
+
 func handleStart() appHandler {
 	type req struct {
-		Game    string `json:"game"`
-		DataURL string `json:"data_url"`
+		Game         string `json:"game"`
+		DataURL      string `json:"data_url"`
+		Stream       string `json:"stream"`
+		DesiredCount *int32 `json:"desired_count"`
+		Force        bool   `json:"force"`
+		Cluster      string `json:"cluster"`
+		Service      string `json:"service"`
+
+		AllowCrossGame bool `json:"allow_cross_game"`
 	}
 	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
 		var body req
 		if err := decodeJSON(w, r, &body); err != nil {
-			return badRequest("invalid json body")
+			return invalidJSON(err)
+		}
+		if err := requireGame(body.Game, "field"); err != nil {
+			return err
+		}
+		if err := optionalSourceURL(body.DataURL); err != nil {
+			return err
+		}
+		var desired int32
+		if body.DesiredCount != nil {
+			if *body.DesiredCount < 1 {
+				return badRequest("desired_count must be >= 1")
+			}
+			desired = *body.DesiredCount
+		}
+		dryRun, err := boolQuery(r, "dry_run")
+		if err != nil {
+			return err
+		}
+		target, err := ecsTarget(body.Cluster, body.Service)
+		if err != nil {
+			return err
+		}
+		out, err := a.Controller.Start(r.Context(), service.StartRequest{
+			Game:         body.Game,
+			DataURL:      body.DataURL,
+			Stream:       strings.TrimSpace(body.Stream),
+			DesiredCount: desired,
+			Force:        body.Force,
+			DryRun:       dryRun,
+			ECSTarget:    target,
+
+			AllowCrossGame: body.AllowCrossGame,
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, out)
+		return nil
+	}
+}
+
+// handleStop stops the active game. A non-empty order pins the backup
+// ordering (backup-and-stop); otherwise the body may choose it.
+func handleStop(order string) appHandler {
+	type req struct {
+		BackupOrder string `json:"backup_order"`
+		Cluster     string `json:"cluster"`
+		Service     string `json:"service"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeOptionalJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		stop := service.StopRequest{BackupOrder: order}
+		if stop.BackupOrder == "" {
+			stop.BackupOrder = strings.TrimSpace(body.BackupOrder)
+		}
+		if !service.ValidBackupOrder(stop.BackupOrder) {
+			return badRequest("backup_order must be before or after")
+		}
+		var err error
+		if stop.ECSTarget, err = ecsTarget(body.Cluster, body.Service); err != nil {
+			return err
+		}
+		out, err := a.Controller.Stop(r.Context(), stop)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, out)
+		return nil
+	}
+}
+
+// handleStopAll answers 200 when every running game stopped and 207 when some
+// failed; either way the body lists each game's result or error.
+func handleStopAll() appHandler {
+	type req struct {
+		BackupOrder string `json:"backup_order"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeOptionalJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		order := strings.TrimSpace(body.BackupOrder)
+		if !service.ValidBackupOrder(order) {
+			return badRequest("backup_order must be before or after")
+		}
+		out, err := a.Controller.StopAll(r.Context(), order)
+		if err != nil && out.Failed == 0 {
+			return err
+		}
+		status := http.StatusOK
+		if out.Failed > 0 {
+			status = http.StatusMultiStatus
+			a.Log.Warn("stop all partially failed", "rid", getRID(r.Context()), "failed", out.Failed, "err", err)
+		}
+		writeJSON(w, status, out)
+		return nil
+	}
+}
+
+func handleRestore() appHandler {
+	type req struct {
+		Backup string `json:"backup"`
+		Stream string `json:"stream"`
+		Hot    bool   `json:"hot"`
+		Force  bool   `json:"force"`
+
+		AllowCrossGame   bool `json:"allow_cross_game"`
+		SkipSafetyBackup bool `json:"skip_safety_backup"`
+		AllowLocal       bool `json:"allow_local"`
+		OnlyIfNewer      bool `json:"only_if_newer"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeOptionalJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
 		}
-		if body.Game == "" {
-			return badRequest("missing field: game")
+		refresh, err := boolQuery(r, "refresh")
+		if err != nil {
+			return err
 		}
-		out, err := a.Controller.Start(r.Context(), body.Game, body.DataURL)
+		out, err := a.Controller.Restore(r.Context(), service.RestoreRequest{
+			Backup:  body.Backup,
+			Stream:  strings.TrimSpace(body.Stream),
+			Hot:     body.Hot,
+			Force:   body.Force,
+			Refresh: refresh,
+
+			AllowCrossGame:   body.AllowCrossGame,
+			SkipSafetyBackup: body.SkipSafetyBackup,
+			AllowLocal:       body.AllowLocal,
+			OnlyIfNewer:      body.OnlyIfNewer,
+		})
 		if err != nil {
 			return err
 		}
@@ -60,9 +370,46 @@ func handleStart() appHandler {
 	}
 }
 
-func handleStop() appHandler {
+// handleRestorePath restores the files at path (or paths) from a backup
+// without a full restore.
+func handleRestorePath() appHandler {
+	type req struct {
+		Game  string   `json:"game"`
+		Key   string   `json:"key"`
+		Path  string   `json:"path"`
+		Paths []string `json:"paths"`
+		Force bool     `json:"force"`
+
+		AllowCrossGame bool `json:"allow_cross_game"`
+	}
 	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
-		out, err := a.Controller.Stop(r.Context())
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if strings.TrimSpace(body.Key) == "" {
+			return badRequest("missing field: key")
+		}
+		if body.Game != "" {
+			if err := domain.ValidateGameName(body.Game); err != nil {
+				return err
+			}
+		}
+		paths := body.Paths
+		if body.Path != "" {
+			paths = append(paths, body.Path)
+		}
+		if len(paths) == 0 {
+			return badRequest("missing field: path")
+		}
+		out, err := a.Controller.RestorePaths(r.Context(), service.PathRestoreRequest{
+			Game:   body.Game,
+			Backup: strings.TrimSpace(body.Key),
+			Paths:  paths,
+			Force:  body.Force,
+
+			AllowCrossGame: body.AllowCrossGame,
+		})
 		if err != nil {
 			return err
 		}
@@ -73,17 +420,23 @@ func handleStop() appHandler {
 
 func handleSwitch() appHandler {
 	type req struct {
-		Game string `json:"game"`
+		Game    string `json:"game"`
+		Cluster string `json:"cluster"`
+		Service string `json:"service"`
 	}
 	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
 		var body req
 		if err := decodeJSON(w, r, &body); err != nil {
-			return badRequest("invalid json body")
+			return invalidJSON(err)
 		}
-		if body.Game == "" {
-			return badRequest("missing field: game")
+		if err := requireGame(body.Game, "field"); err != nil {
+			return err
+		}
+		target, err := ecsTarget(body.Cluster, body.Service)
+		if err != nil {
+			return err
 		}
-		if err := a.Controller.Switch(r.Context(), body.Game); err != nil {
+		if err := a.Controller.Switch(r.Context(), body.Game, target); err != nil {
 			return err
 		}
 		writeJSON(w, http.StatusOK, map[string]any{"switched_to": body.Game})
@@ -91,13 +444,349 @@ func handleSwitch() appHandler {
 	}
 }
 
+// handleSwitchPreview reports what POST /v1/server/switch would do for ?to=
+// without doing any of it.
+func handleSwitchPreview() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		to := strings.TrimSpace(r.URL.Query().Get("to"))
+		if to == "" {
+			return badRequest("missing query param: to")
+		}
+		if err := domain.ValidateGameName(to); err != nil {
+			return err
+		}
+		preview, err := a.Controller.PreviewSwitch(r.Context(), to)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, preview)
+		return nil
+	}
+}
+
 func handleBackup() appHandler {
+	type req struct {
+		Stream string `json:"stream"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeOptionalJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		stream := strings.TrimSpace(body.Stream)
+		report, err := a.Controller.BackupReport(r.Context(), stream)
+		if err != nil {
+			return err
+		}
+		out := map[string]any{
+			"backup":        report.Backup,
+			"files":         report.Files,
+			"bytes":         report.Bytes,
+			"archive_bytes": report.ArchiveBytes,
+		}
+		if stream != "" {
+			out["stream"] = stream
+		}
+		writeJSON(w, http.StatusOK, out)
+		return nil
+	}
+}
+
+func handleListBackups() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		game := r.URL.Query().Get("game")
+		if err := requireGame(game, "query param"); err != nil {
+			return err
+		}
+		stream := strings.TrimSpace(r.URL.Query().Get("stream"))
+		refresh, err := boolQuery(r, "refresh")
+		if err != nil {
+			return err
+		}
+		offset, limit, err := pageParams(r, a.Config.MaxListResults)
+		if err != nil {
+			return err
+		}
+		backups, err := a.Controller.ListBackups(r.Context(), game, stream)
+		if err != nil {
+			return err
+		}
+		total := len(backups)
+		backups, next := page(backups, offset, limit)
+		resp := map[string]any{
+			"game":    game,
+			"stream":  stream,
+			"backups": backups,
+			"total":   total,
+		}
+		if next != "" {
+			resp["next_page_token"] = next
+		}
+		// The latest key is informational; a missing marker shouldn't fail
+		// the listing.
+		if latest, err := a.Controller.LatestBackup(r.Context(), game, stream, refresh); err == nil && latest != "" {
+			resp["latest"] = latest
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return nil
+	}
+}
+
+// handleRetentionPreview shows which of a stream's backups the retention
+// policy would keep and prune; nothing is deleted. keep and prune are paged
+// together (the same window of each); the counts and prune_bytes cover the
+// whole plan.
+func handleRetentionPreview() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		game := r.URL.Query().Get("game")
+		if err := requireGame(game, "query param"); err != nil {
+			return err
+		}
+		offset, limit, err := pageParams(r, a.Config.MaxListResults)
+		if err != nil {
+			return err
+		}
+		preview, err := a.Controller.PreviewRetention(r.Context(), game, strings.TrimSpace(r.URL.Query().Get("stream")))
+		if err != nil {
+			return err
+		}
+		resp := struct {
+			service.RetentionPreview
+			KeepCount     int    `json:"keep_count"`
+			PruneCount    int    `json:"prune_count"`
+			NextPageToken string `json:"next_page_token,omitempty"`
+		}{RetentionPreview: preview, KeepCount: len(preview.Keep), PruneCount: len(preview.Prune)}
+		var nextKeep, nextPrune string
+		resp.Keep, nextKeep = page(preview.Keep, offset, limit)
+		resp.Prune, nextPrune = page(preview.Prune, offset, limit)
+		// Both lists end at the same offset, so either token resumes both.
+		resp.NextPageToken = nextKeep
+		if nextPrune != "" {
+			resp.NextPageToken = nextPrune
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return nil
+	}
+}
+
+// handleRetentionApply prunes what a preview listed, given its token. A
+// partial failure is 207 with every backup's outcome.
+func handleRetentionApply() appHandler {
+	type req struct {
+		Token string `json:"token"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if strings.TrimSpace(body.Token) == "" {
+			return badRequest("missing field: token")
+		}
+		out, err := a.Controller.ApplyRetention(r.Context(), body.Token)
+		if err != nil && out.Failed == 0 {
+			return err
+		}
+		status := http.StatusOK
+		if out.Failed > 0 {
+			status = http.StatusMultiStatus
+			a.Log.Warn("retention apply partially failed", "rid", getRID(r.Context()), "failed", out.Failed, "err", err)
+		}
+		writeJSON(w, status, out)
+		return nil
+	}
+}
+
+// handleBackupContent streams a backup through the controller for clients
+// that can't reach S3. slots caps concurrent transfers; each one is a plain
+// io.Copy from the S3 body, so memory stays flat regardless of size.
+func handleBackupContent(slots chan struct{}) appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		game := r.URL.Query().Get("game")
+		if err := requireGame(game, "query param"); err != nil {
+			return err
+		}
+		key := strings.TrimSpace(r.URL.Query().Get("key"))
+		if key == "" {
+			return badRequest("missing query param: key")
+		}
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		default:
+			return httpError{Status: http.StatusTooManyRequests, Message: "too many backup transfers in progress"}
+		}
+
+		content, err := a.Controller.OpenBackup(r.Context(), game, key)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+
+		contentType := "application/zip"
+		if strings.HasSuffix(content.Key, ".tar.gz") {
+			contentType = "application/gzip"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(content.Key)}))
+		if content.Size > 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(content.Size, 10))
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, content); err != nil {
+			a.Log.Warn("backup download interrupted", "rid", getRID(r.Context()), "key", content.Key, "err", err)
+		}
+		return nil
+	}
+}
+
+// handleBackupUpload accepts a world zip and registers it as the game's latest
+// backup. The body is spooled to TMP_DIR first so the archive can be checked
+// before anything reaches S3; it shares the transfer slots with downloads.
+func handleBackupUpload(slots chan struct{}) appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		game := r.URL.Query().Get("game")
+		if err := requireGame(game, "query param"); err != nil {
+			return err
+		}
+		if r.ContentLength == 0 {
+			return badRequest("empty body")
+		}
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/zip" && mediaType != "application/octet-stream" {
+			return httpError{Status: http.StatusUnsupportedMediaType, Message: "content-type must be application/zip"}
+		}
+		if r.ContentLength > a.Config.BackupUploadMax {
+			return httpError{Status: http.StatusRequestEntityTooLarge, Message: "backup exceeds BACKUP_UPLOAD_MAX"}
+		}
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		default:
+			return httpError{Status: http.StatusTooManyRequests, Message: "too many backup transfers in progress"}
+		}
+
+		// The server-wide ReadTimeout is sized for JSON bodies.
+		_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(10 * time.Minute))
+		body := http.MaxBytesReader(w, r.Body, a.Config.BackupUploadMax)
+
+		tmp, err := os.CreateTemp(a.Config.TmpDir, "backup-upload-*.zip")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		_, err = io.Copy(tmp, body)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return httpError{Status: http.StatusRequestEntityTooLarge, Message: "backup exceeds BACKUP_UPLOAD_MAX"}
+			}
+			if errors.Is(err, errBodyDigest) {
+				return httpError{Status: http.StatusUnauthorized, Message: err.Error()}
+			}
+			return badRequest("read body: " + err.Error())
+		}
+
+		key, err := a.Controller.ImportBackup(r.Context(), game, tmp.Name())
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusCreated, map[string]any{"game": game, "backup": key})
+		return nil
+	}
+}
+
+func handleSync() appHandler {
+	type req struct {
+		Game string `json:"game"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if err := requireGame(body.Game, "field"); err != nil {
+			return err
+		}
+		out, err := a.Controller.Sync(r.Context(), body.Game)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, out)
+		return nil
+	}
+}
+
+func handleSeed() appHandler {
+	type req struct {
+		Game    string `json:"game"`
+		DataURL string `json:"data_url"`
+		Force   bool   `json:"force"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if err := requireGame(body.Game, "field"); err != nil {
+			return err
+		}
+		if strings.TrimSpace(body.DataURL) == "" {
+			return badRequest("missing field: data_url")
+		}
+		if err := optionalSourceURL(body.DataURL); err != nil {
+			return err
+		}
+		out, err := a.Controller.Seed(r.Context(), body.Game, body.DataURL, body.Force)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, out)
+		return nil
+	}
+}
+
+func handleAdopt() appHandler {
+	type req struct {
+		Game string `json:"game"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if err := requireGame(body.Game, "field"); err != nil {
+			return err
+		}
+		out, err := a.Controller.Adopt(r.Context(), body.Game)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, out)
+		return nil
+	}
+}
+
+func handleRedeploy() appHandler {
+	type req struct {
+		Game string `json:"game"`
+	}
 	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
-		key, err := a.Controller.Backup(r.Context())
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if err := requireGame(body.Game, "field"); err != nil {
+			return err
+		}
+		out, err := a.Controller.Redeploy(r.Context(), body.Game)
 		if err != nil {
 			return err
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"backup": key})
+		writeJSON(w, http.StatusOK, map[string]any{"redeployed": body.Game, "deployment": out})
 		return nil
 	}
 }
@@ -109,15 +798,327 @@ func handleCommand() appHandler {
 	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
 		var body req
 		if err := decodeJSON(w, r, &body); err != nil {
-			return badRequest("invalid json body")
+			return invalidJSON(err)
 		}
 		if strings.TrimSpace(body.Command) == "" {
 			return badRequest("missing field: command")
 		}
-		if err := a.Controller.Command(r.Context(), body.Command); err != nil {
+		res, err := a.Controller.Command(r.Context(), service.CommandRequest{
+			Command:  body.Command,
+			RemoteIP: getIP(r.Context()),
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res)
+		return nil
+	}
+}
+
+func handleCommandHistory() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		writeJSON(w, http.StatusOK, map[string]any{"commands": a.Controller.CommandHistory()})
+		return nil
+	}
+}
+
+func handleEvents() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		recent := []domain.Event{}
+		if a.Events != nil {
+			recent = a.Events.Recent()
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"events": recent})
+		return nil
+	}
+}
+
+// handleOperations is the activity feed: the in-flight operation, if any,
+// followed by the most recent finished ones.
+func handleOperations() appHandler {
+	const defaultLimit = 20
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		limit := defaultLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil || v < 1 {
+				return badRequest("invalid query param: limit")
+			}
+			limit = min(v, a.Config.MaxListResults)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"operations": a.Controller.Operations(limit)})
+		return nil
+	}
+}
+
+// handleCancelOperation cancels a running operation. The response only
+// confirms the request; poll /v1/operations for how the operation ended.
+func handleCancelOperation() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			return badRequest("invalid operation id")
+		}
+		op, err := a.Controller.CancelOperation(id)
+		if err != nil {
+			return err
+		}
+		a.Log.Warn("operation cancel requested", "rid", getRID(r.Context()), "op", id, "kind", op.Kind, "game", op.Game)
+		writeJSON(w, http.StatusAccepted, op)
+		return nil
+	}
+}
+
+func handleDeleteBackup() appHandler {
+	type req struct {
+		Game string `json:"game"`
+		Key  string `json:"key"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if err := requireGame(body.Game, "field"); err != nil {
 			return err
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"sent": true})
+		if strings.TrimSpace(body.Key) == "" {
+			return badRequest("missing field: key")
+		}
+		out, err := a.Controller.DeleteBackup(r.Context(), body.Game, strings.TrimSpace(body.Key))
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, out)
+		return nil
+	}
+}
+
+// handleCopyBackup copies a backup to another bucket or prefix without it
+// passing through the controller, for promoting worlds between environments.
+func handleCopyBackup() appHandler {
+	type req struct {
+		Game       string `json:"game"`
+		SourceKey  string `json:"source_key"`
+		DestBucket string `json:"dest_bucket"`
+		DestPrefix string `json:"dest_prefix"`
+		SetLatest  bool   `json:"set_latest"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if err := requireGame(body.Game, "field"); err != nil {
+			return err
+		}
+		if strings.TrimSpace(body.SourceKey) == "" {
+			return badRequest("missing field: source_key")
+		}
+		if strings.TrimSpace(body.DestBucket) == "" && strings.TrimSpace(body.DestPrefix) == "" {
+			return badRequest("missing field: dest_bucket or dest_prefix")
+		}
+		out, err := a.Controller.CopyBackup(r.Context(), service.BackupCopyRequest{
+			Game:       body.Game,
+			Source:     strings.TrimSpace(body.SourceKey),
+			DestBucket: strings.TrimSpace(body.DestBucket),
+			DestPrefix: strings.TrimSpace(body.DestPrefix),
+			SetLatest:  body.SetLatest,
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusCreated, out)
+		return nil
+	}
+}
+
+func handleRestoreDeletedBackup() appHandler {
+	type req struct {
+		Game string `json:"game"`
+		Key  string `json:"key"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if err := requireGame(body.Game, "field"); err != nil {
+			return err
+		}
+		if strings.TrimSpace(body.Key) == "" {
+			return badRequest("missing field: key")
+		}
+		restored, err := a.Controller.RestoreDeletedBackup(r.Context(), body.Game, strings.TrimSpace(body.Key))
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"restored": restored})
+		return nil
+	}
+}
+
+func handleAdminReset() appHandler {
+	type req struct {
+		Confirm bool `json:"confirm"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if !body.Confirm {
+			return badRequest("confirm must be true")
+		}
+		previous, err := a.Controller.Reset(r.Context())
+		if err != nil {
+			return err
+		}
+		a.Log.Warn("controller state reset",
+			"rid", getRID(r.Context()),
+			"ip", getIP(r.Context()),
+			"previous_active_game", previous.ActiveGame,
+			"previous_phase", previous.Phase,
+		)
+		writeJSON(w, http.StatusOK, map[string]any{"reset": true})
+		return nil
+	}
+}
+
+// handleClearPhase is the manual way out of a phase left stuck by a crash.
+func handleClearPhase() appHandler {
+	type req struct {
+		Confirm bool `json:"confirm"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if !body.Confirm {
+			return badRequest("confirm must be true")
+		}
+		res, err := a.Controller.ClearPhase(r.Context())
+		if err != nil {
+			return err
+		}
+		a.Log.Warn("controller phase cleared",
+			"rid", getRID(r.Context()),
+			"ip", getIP(r.Context()),
+			"before_active_game", res.BeforeGame,
+			"before_phase", res.BeforePhase,
+			"active_game", res.ActiveGame,
+			"phase", res.Phase,
+		)
+		writeJSON(w, http.StatusOK, res)
+		return nil
+	}
+}
+
+func handleRotateGitToken() appHandler {
+	type req struct {
+		Game  string `json:"game"`
+		Token string `json:"token"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if err := requireGame(body.Game, "field"); err != nil {
+			return err
+		}
+		if strings.TrimSpace(body.Token) == "" {
+			return badRequest("missing field: token")
+		}
+		if err := a.Controller.RotateGitToken(r.Context(), body.Game, body.Token); err != nil {
+			return err
+		}
+		a.Log.Warn("git token rotated",
+			"rid", getRID(r.Context()),
+			"ip", getIP(r.Context()),
+			"game", body.Game,
+		)
+		writeJSON(w, http.StatusOK, map[string]any{"rotated": true})
+		return nil
+	}
+}
+
+func handleValidateSource() appHandler {
+	type req struct {
+		Game string `json:"game"`
+		URL  string `json:"url"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if body.Game != "" {
+			if err := domain.ValidateGameName(body.Game); err != nil {
+				return err
+			}
+		}
+		if strings.TrimSpace(body.URL) == "" {
+			return badRequest("missing field: url")
+		}
+		if err := optionalSourceURL(body.URL); err != nil {
+			return err
+		}
+		check, err := a.Controller.ValidateSource(r.Context(), body.Game, body.URL)
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, check)
+		return nil
+	}
+}
+
+// handleMinecraftConnection reports the RCON connection state, for
+// commands that seem to do nothing.
+func handleMinecraftConnection() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		state, err := a.Controller.ConnectionState(r.Context(), string(domain.GameMinecraft))
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, state)
+		return nil
+	}
+}
+
+func handleSetLogLevel() appHandler {
+	type req struct {
+		Level string `json:"level"`
+	}
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		var body req
+		if err := decodeJSON(w, r, &body); err != nil {
+			return invalidJSON(err)
+		}
+		if strings.TrimSpace(body.Level) == "" {
+			return badRequest("missing field: level")
+		}
+		level, err := app.ParseLogLevel(body.Level)
+		if err != nil {
+			return badRequest("invalid field: level (want debug, info, warn or error)")
+		}
+		if a.LogLevel == nil {
+			return domain.ErrNotSupported
+		}
+		previous := a.LogLevel.Level()
+		a.LogLevel.Set(level)
+		// Logged at warn so the change shows up at every level.
+		a.Log.Warn("log level changed",
+			"rid", getRID(r.Context()),
+			"ip", getIP(r.Context()),
+			"previous", previous.String(),
+			"level", level.String(),
+		)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"level":    strings.ToLower(level.String()),
+			"previous": strings.ToLower(previous.String()),
+		})
 		return nil
 	}
 }
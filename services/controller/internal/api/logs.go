@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/app"
+)
+
+const logPollInterval = 500 * time.Millisecond
+
+// handleLogs streams the active game's log as server-sent events, following
+// appends like `tail -f` until the client goes away or LOG_STREAM_MAX passes.
+func handleLogs() appHandler {
+	return func(a *app.App, w http.ResponseWriter, r *http.Request) error {
+		path, err := a.Controller.LogFile(r.Context())
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), a.Config.LogStreamMax)
+		defer cancel()
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no") // nginx router
+		w.WriteHeader(http.StatusOK)
+		_ = rc.Flush()
+
+		err = tailFile(ctx, path, func(line string) error {
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", line); err != nil {
+				return err
+			}
+			return rc.Flush()
+		})
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+			a.Log.Warn("log stream ended", "path", path, "err", err)
+		}
+		return nil
+	}
+}
+
+// tailFile calls emit for every line appended to path after it is opened.
+// When the file is replaced (log rotation) it reopens the new file from the
+// start; when it is truncated in place it rewinds.
+func tailFile(ctx context.Context, path string, emit func(line string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(f)
+
+	var partial strings.Builder
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			chunk, err := reader.ReadString('\n')
+			partial.WriteString(chunk)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+			if err := emit(strings.TrimRight(partial.String(), "\r\n")); err != nil {
+				return err
+			}
+			partial.Reset()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := os.Stat(path)
+		if err != nil {
+			continue // mid-rotation; try again next tick
+		}
+		opened, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if !os.SameFile(opened, current) {
+			next, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			_ = f.Close()
+			f = next
+			reader.Reset(f)
+			partial.Reset()
+			continue
+		}
+		if pos, err := f.Seek(0, io.SeekCurrent); err == nil && current.Size() < pos-int64(reader.Buffered()) {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			reader.Reset(f)
+			partial.Reset()
+		}
+	}
+}
@@ -6,26 +6,40 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"mime"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/esuEdu/game-infra/controller/internal/app"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/metrics"
+	"github.com/esuEdu/game-infra/controller/internal/tracing"
 )
 
 type ctxKey string
 
 const (
-	ctxRequestID ctxKey = "rid"
-	ctxRealIP    ctxKey = "real_ip"
+	ctxRealIP ctxKey = "real_ip"
 )
 
-// request id
+// request id: a well-formed client X-Request-Id is kept so a retried
+// request reuses it (see the awsruntime idempotency notes).
 func requestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rid := newRID()
+		rid := strings.TrimSpace(r.Header.Get("X-Request-Id"))
+		if !ValidRequestID(rid) {
+			rid = newRID()
+		}
 		w.Header().Set("X-Request-Id", rid)
-		ctx := context.WithValue(r.Context(), ctxRequestID, rid)
+		ctx := domain.WithRequestID(r.Context(), rid)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -38,13 +52,54 @@ func newRID() string {
 	return hex.EncodeToString(b[:])
 }
 
+// ValidRequestID reports whether a caller-supplied request id is kept: up to
+// 128 letters, digits, '-', '_' or '.'. Other transports use it so an id
+// that reaches logs and AWS requests is checked the same way everywhere.
+func ValidRequestID(rid string) bool {
+	if rid == "" || len(rid) > 128 {
+		return false
+	}
+	for _, c := range rid {
+		ok := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.'
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
 func getRID(ctx context.Context) string {
-	if v, ok := ctx.Value(ctxRequestID).(string); ok && v != "" {
+	if v := domain.RequestID(ctx); v != "" {
 		return v
 	}
 	return "unknown"
 }
 
+// traceRequest opens the server span for a request, continuing the caller's
+// trace when it sends a traceparent header. The span is renamed to the
+// matched route ("POST /v1/server/start") once the mux has run.
+func traceRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.StartServer(ctx, r.Method,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		)
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(sw, r)
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(attribute.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+		if sw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.status))
+		}
+		span.End()
+	})
+}
+
 // real ip
 func realIP(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -76,25 +131,59 @@ func clientIP(r *http.Request) string {
 	return r.RemoteAddr
 }
 
-// access log (LOG LAYER)
-func accessLog(log *slog.Logger, next http.Handler) http.Handler {
+// access log (LOG LAYER). Requests slower than their class threshold are
+// logged at warn with slow=true and counted in metrics.
+func accessLog(log *slog.Logger, slow app.SlowThresholds, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(sw, r)
+		dur := time.Since(start)
 
-		log.Info("http request",
+		class := routeClass(r)
+		level := slog.LevelInfo
+		attrs := []any{
 			"rid", getRID(r.Context()),
 			"ip", getIP(r.Context()),
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status", sw.status,
 			"bytes", sw.bytes,
-			"dur_ms", time.Since(start).Milliseconds(),
-		)
+			"dur_ms", dur.Milliseconds(),
+		}
+		if threshold := slow.For(class); threshold > 0 && dur > threshold {
+			level = slog.LevelWarn
+			attrs = append(attrs, "slow", true, "slow_threshold_ms", threshold.Milliseconds())
+			slowRequests.Inc("class", class)
+		}
+		requestsTotal.Inc("class", class, "status", strconv.Itoa(sw.status))
+		maxRequestSeconds.SetMax(dur.Seconds(), "class", class)
+		log.Log(r.Context(), level, "http request", attrs...)
 	})
 }
 
+var (
+	requestsTotal     = metrics.Default.Counter("controller_http_requests_total", "HTTP requests by route class and status.")
+	requestsRejected  = metrics.Default.Counter("controller_http_requests_rejected_total", "HTTP requests refused with 429 because their route class was at its limit.")
+	requestsQueued    = metrics.Default.Counter("controller_http_requests_queued_total", "HTTP requests that waited for a slot in a full route class.")
+	slowRequests      = metrics.Default.Counter("controller_http_slow_requests_total", "HTTP requests slower than their class threshold.")
+	maxRequestSeconds = metrics.Default.Gauge("controller_http_request_duration_max_seconds", "Longest HTTP request seen, by route class.")
+)
+
+// routeClass buckets a request for slow-request thresholds and in-flight
+// limits: "read" for plain GETs, "stream" for long-polls and log tails (slow
+// by design), and "operation" for everything that mutates.
+func routeClass(r *http.Request) string {
+	switch {
+	case r.URL.Path == "/v1/logs" || r.URL.Path == "/v1/backups/content" || r.URL.Query().Get("wait") != "":
+		return "stream"
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return "read"
+	default:
+		return "operation"
+	}
+}
+
 // panic recovery
 func recoverPanic(log *slog.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -108,20 +197,143 @@ func recoverPanic(log *slog.Logger, next http.Handler) http.Handler {
 	})
 }
 
-// backpressure
-func limitInFlight(max int, next http.Handler) http.Handler {
-	sem := make(chan struct{}, max)
+// scoped bearer-token auth: 401 for a missing/unknown token, 403 when the
+// token's scope is too low. Without scoped auth, read and write routes stay
+// open; admin routes always fail closed.
+func requireScope(cfg app.Config, need app.Scope, next http.Handler) http.Handler {
+	tokens := cfg.TokenScopes()
+	scoped := cfg.ScopedAuth()
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		select {
-		case sem <- struct{}{}:
-			defer func() { <-sem }()
+		if !scoped && need != app.ScopeAdmin {
 			next.ServeHTTP(w, r)
-		default:
+			return
+		}
+		scope := app.LookupToken(tokens, bearerToken(r))
+		if scope == app.ScopeNone {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		if !scope.Allows(need) {
+			http.Error(w, `{"error":"insufficient scope"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	h := strings.TrimSpace(r.Header.Get("Authorization"))
+	if token, ok := strings.CutPrefix(h, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// requireJSONBody rejects mutating requests whose body isn't declared as
+// application/json (a charset parameter is fine). Bodyless requests, like a
+// plain POST /v1/server/stop, don't need a Content-Type. Backup uploads carry
+// a zip instead and are checked by their handler.
+func requireJSONBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			if r.ContentLength != 0 && r.URL.Path != "/v1/backups/content" {
+				mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if err != nil || mediaType != "application/json" {
+					http.Error(w, `{"error":"content-type must be application/json"}`, http.StatusUnsupportedMediaType)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyClass buckets a request for body size limits. Uploads are the only
+// route that takes more than a small JSON document.
+func bodyClass(r *http.Request) string {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/backups/content":
+		return "upload"
+	case routeClass(r) == "operation":
+		return "operation"
+	default:
+		return "read"
+	}
+}
+
+// limitBody caps every request body by its class. A declared length over
+// the limit is refused before anything is read; a body that only turns out
+// too long fails its read with *http.MaxBytesError, which handlers report
+// through bodyTooLarge.
+func limitBody(limits app.BodyLimits, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := limits.For(bodyClass(r))
+		if r.ContentLength > limit {
+			he := bodyTooLarge(limit)
+			writeJSON(w, he.Status, map[string]any{"error": he.Message, "limit": limit})
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyTooLarge is the 413 for a body over its class limit.
+func bodyTooLarge(limit int64) httpError {
+	return httpError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Message: fmt.Sprintf("request body too large (limit %d bytes)", limit),
+		Details: map[string]any{"limit": limit},
+	}
+}
+
+// backpressure: each route class has its own pool, so writes queued behind
+// a long operation can't starve reads. With QueueWait set, a request to a
+// full class waits that long for a slot before it is refused, which absorbs
+// short bursts without client retries.
+func limitInFlight(limits app.RequestLimits, next http.Handler) http.Handler {
+	sems := map[string]chan struct{}{}
+	for _, class := range []string{"read", "operation", "stream"} {
+		sems[class] = make(chan struct{}, limits.For(class))
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class := routeClass(r)
+		if !acquireSlot(r.Context(), sems[class], limits.QueueWait, class) {
+			requestsRejected.Inc("class", class)
+			w.Header().Set("Retry-After", "1")
 			http.Error(w, `{"error":"too many requests"}`, http.StatusTooManyRequests)
+			return
 		}
+		defer func() { <-sems[class] }()
+		next.ServeHTTP(w, r)
 	})
 }
 
+// acquireSlot takes a slot in sem, waiting up to wait (or until ctx ends)
+// when it is full.
+func acquireSlot(ctx context.Context, sem chan struct{}, wait time.Duration, class string) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+	}
+	if wait <= 0 {
+		return false
+	}
+	requestsQueued.Inc("class", class)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // request timeout
 func withTimeout(d time.Duration, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -146,6 +358,9 @@ func (w *statusWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer (Flush).
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
 func (w *statusWriter) Write(p []byte) (int, error) {
 	n, err := w.ResponseWriter.Write(p)
 	w.mu.Lock()
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+)
+
+// Listings are capped at MAX_LIST_RESULTS entries per response whatever
+// limit the client asks for. A response that stops short carries
+// next_page_token; passing it back as page_token returns the next page.
+// Tokens are opaque to clients but are just an offset into the listing, so
+// backups added or deleted between pages can shift entries across them.
+
+// pageParams reads limit and page_token, clamping limit to maxResults.
+func pageParams(r *http.Request, maxResults int) (offset, limit int, err error) {
+	limit = maxResults
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 {
+			return 0, 0, badRequest("invalid query param: limit")
+		}
+		limit = min(v, maxResults)
+	}
+	if raw := r.URL.Query().Get("page_token"); raw != "" {
+		b, err := base64.RawURLEncoding.DecodeString(raw)
+		if err != nil {
+			return 0, 0, badRequest("invalid query param: page_token")
+		}
+		if offset, err = strconv.Atoi(string(b)); err != nil || offset < 0 {
+			return 0, 0, badRequest("invalid query param: page_token")
+		}
+	}
+	return offset, limit, nil
+}
+
+// page returns items[offset:offset+limit] and the token for the rest, ""
+// when there is none.
+func page[T any](items []T, offset, limit int) ([]T, string) {
+	if offset >= len(items) {
+		return []T{}, ""
+	}
+	end := min(offset+limit, len(items))
+	next := ""
+	if end < len(items) {
+		next = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end)))
+	}
+	return items[offset:end], next
+}
@@ -3,7 +3,12 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/esuEdu/game-infra/controller/internal/domain"
 )
@@ -11,41 +16,215 @@ import (
 type httpError struct {
 	Status  int
 	Message string
+	Details map[string]any // extra fields merged into the error body
 }
 
 func (e httpError) Error() string { return e.Message }
 
 func badRequest(msg string) error { return httpError{Status: http.StatusBadRequest, Message: msg} }
 
+// requireGame checks a game name taken from a request; where says whether it
+// came from a body "field" or a "query param".
+func requireGame(game, where string) error {
+	if game == "" {
+		return badRequest("missing " + where + ": game")
+	}
+	return domain.ValidateGameName(game)
+}
+
+// optionalSourceURL checks a data/source URL when one was given.
+func optionalSourceURL(raw string) error {
+	if raw = strings.TrimSpace(raw); raw == "" {
+		return nil
+	}
+	return domain.ValidateSourceURL(raw)
+}
+
+// prettyJSONWriter marks a response whose JSON bodies should be indented.
+type prettyJSONWriter struct {
+	http.ResponseWriter
+}
+
+func wantsPrettyJSON(r *http.Request) bool {
+	v, err := strconv.ParseBool(r.URL.Query().Get("pretty"))
+	return err == nil && v
+}
+
+// boolQuery parses an optional boolean query param; absent is false.
+func boolQuery(r *http.Request, name string) (bool, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, badRequest("invalid query param: " + name)
+	}
+	return v, nil
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(v)
+	enc := json.NewEncoder(w)
+	if _, ok := w.(prettyJSONWriter); ok {
+		enc.SetIndent("", "  ")
+	}
+	_ = enc.Encode(v)
 }
 
 func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1MB
+	// The body is already capped by limitBody.
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
 	return dec.Decode(dst)
 }
 
+// invalidJSON turns a decodeJSON error into a 400 naming what was wrong:
+// the unknown or mistyped field and the expected type, or where the syntax
+// error is.
+func invalidJSON(err error) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		sizeErr   *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &sizeErr):
+		return bodyTooLarge(sizeErr.Limit)
+	case errors.Is(err, io.EOF):
+		return badRequest("empty json body")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return badRequest("truncated json body")
+	case errors.As(err, &syntaxErr):
+		return httpError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("malformed json at offset %d", syntaxErr.Offset),
+			Details: map[string]any{"offset": syntaxErr.Offset},
+		}
+	case errors.As(err, &typeErr):
+		expected := jsonTypeName(typeErr.Type)
+		return httpError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("field %q must be %s, got %s", typeErr.Field, expected, typeErr.Value),
+			Details: map[string]any{"field": typeErr.Field, "expected": expected, "got": typeErr.Value},
+		}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field = strings.Trim(field, `"`)
+		return httpError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("unknown field %q", field),
+			Details: map[string]any{"field": field},
+		}
+	}
+	return badRequest("invalid json body")
+}
+
+// jsonTypeName names a Go type the way an API client thinks of it.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	}
+	return t.String()
+}
+
+// decodeOptionalJSON is decodeJSON for endpoints whose body may be omitted.
+func decodeOptionalJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	if err := decodeJSON(w, r, dst); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// domainErrorStatus maps domain errors to HTTP status codes. The first match
+// wins, so list more specific errors first.
+var domainErrorStatus = []struct {
+	err    error
+	status int
+}{
+	{domain.ErrUnknownGameType, http.StatusBadRequest},
+	{domain.ErrInvalidStream, http.StatusBadRequest},
+	{domain.ErrInvalidArchive, http.StatusBadRequest},
+	{domain.ErrInvalidPath, http.StatusBadRequest},
+	{domain.ErrInvalidGameName, http.StatusBadRequest},
+	{domain.ErrBackupOtherGame, http.StatusBadRequest},
+	{domain.ErrLocalBackup, http.StatusBadRequest},
+	{domain.ErrRetentionTokenInvalid, http.StatusBadRequest},
+	{domain.ErrInvalidSourceURL, http.StatusBadRequest},
+	{domain.ErrNoSourceForGame, http.StatusBadRequest},
+	{domain.ErrSourceRefNotFound, http.StatusBadRequest},
+	{domain.ErrSourcePathNotFound, http.StatusBadRequest},
+	{domain.ErrGitTokenRejected, http.StatusBadRequest},
+	{domain.ErrNoBackupForGame, http.StatusBadRequest},
+	{domain.ErrBucketAccessDenied, http.StatusForbidden},
+	{domain.ErrECSTargetNotAllowed, http.StatusForbidden},
+	{domain.ErrGitHostNotAllowed, http.StatusForbidden},
+	{domain.ErrECSAccessDenied, http.StatusForbidden},
+	{domain.ErrBackupNotInTrash, http.StatusNotFound},
+	{domain.ErrLogUnavailable, http.StatusNotFound},
+	{domain.ErrPathNotInBackup, http.StatusNotFound},
+	{domain.ErrOperationNotFound, http.StatusNotFound},
+	{domain.ErrECSServiceNotFound, http.StatusNotFound},
+	{domain.ErrECSClusterNotFound, http.StatusNotFound},
+	{domain.ErrNotSupported, http.StatusNotImplemented},
+	{domain.ErrInsufficientDisk, http.StatusInsufficientStorage},
+	{domain.ErrChecksumMismatch, http.StatusBadGateway},
+	{domain.ErrSourceUnreachable, http.StatusBadGateway},
+	{domain.ErrBackupArchived, http.StatusConflict},
+	{domain.ErrIncompleteBackup, http.StatusConflict},
+	{domain.ErrEmptyBackup, http.StatusConflict},
+	{domain.ErrBackupNotNewer, http.StatusConflict},
+	{domain.ErrRetentionTokenStale, http.StatusConflict},
+	{domain.ErrAnotherInFlight, http.StatusConflict},
+	{domain.ErrOperationLimit, http.StatusConflict},
+	{domain.ErrOperationQueueFull, http.StatusTooManyRequests},
+	{domain.ErrGameStillRunning, http.StatusConflict},
+	{domain.ErrGameActive, http.StatusConflict},
+	{domain.ErrDataDirInUse, http.StatusConflict},
+	{domain.ErrGameNotActive, http.StatusConflict},
+	{domain.ErrNoActiveGame, http.StatusConflict},
+	{domain.ErrOperationCancelled, http.StatusConflict},
+	{domain.ErrMisconfigured, http.StatusInternalServerError},
+}
+
+// DomainErrorStatus reports the HTTP status for a known domain error. Other
+// transports use it so every API maps errors the same way.
+func DomainErrorStatus(err error) (int, bool) {
+	for _, m := range domainErrorStatus {
+		if errors.Is(err, m.err) {
+			return m.status, true
+		}
+	}
+	return 0, false
+}
+
 func writeError(aLog func(msg string, args ...any), w http.ResponseWriter, err error) {
 	var he httpError
 	if errors.As(err, &he) {
-		writeJSON(w, he.Status, map[string]any{"error": he.Message})
+		body := map[string]any{"error": he.Message}
+		for k, v := range he.Details {
+			body[k] = v
+		}
+		writeJSON(w, he.Status, body)
 		return
 	}
 
-	if errors.Is(err, domain.ErrUnknownGameType) {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
-		return
-	}
-	if errors.Is(err, domain.ErrNoBackupForGame) {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
-		return
-	}
-	if errors.Is(err, domain.ErrNoActiveGame) {
-		writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error()})
+	if status, ok := DomainErrorStatus(err); ok {
+		writeJSON(w, status, map[string]any{"error": err.Error()})
 		return
 	}
 
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func errorBody(t *testing.T, err error) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	writeError(func(string, ...any) {}, rec, err)
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, body
+}
+
+func TestWriteErrorDomainErrors(t *testing.T) {
+	for _, m := range domainErrorStatus {
+		t.Run(m.err.Error(), func(t *testing.T) {
+			err := fmt.Errorf("start survival: %w", m.err)
+			status, body := errorBody(t, err)
+			if status != m.status {
+				t.Fatalf("status = %d, want %d", status, m.status)
+			}
+			want := map[string]any{"error": err.Error()}
+			if !reflect.DeepEqual(body, want) {
+				t.Fatalf("body = %v, want %v", body, want)
+			}
+		})
+	}
+}
+
+func TestWriteErrorHTTPError(t *testing.T) {
+	err := httpError{
+		Status:  http.StatusBadRequest,
+		Message: "unknown field \"gmae\"",
+		Details: map[string]any{"field": "gmae"},
+	}
+	status, body := errorBody(t, fmt.Errorf("decode: %w", err))
+	if status != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", status, http.StatusBadRequest)
+	}
+	want := map[string]any{"error": "unknown field \"gmae\"", "field": "gmae"}
+	if !reflect.DeepEqual(body, want) {
+		t.Fatalf("body = %v, want %v", body, want)
+	}
+}
+
+func TestWriteErrorUnknown(t *testing.T) {
+	logged := 0
+	rec := httptest.NewRecorder()
+	writeError(func(string, ...any) { logged++ }, rec, errors.New("dial tcp: secret-host:5432"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"error":"internal server error"}` {
+		t.Fatalf("body = %s", got)
+	}
+	if logged != 1 {
+		t.Fatalf("logged %d times, want 1", logged)
+	}
+}
+
+func TestInvalidJSONBodies(t *testing.T) {
+	type req struct {
+		Game  string `json:"game"`
+		Count int    `json:"count"`
+	}
+	tests := []struct {
+		name       string
+		body       string
+		limit      int64
+		wantStatus int
+		want       map[string]any
+	}{
+		{
+			name:       "unknown field",
+			body:       `{"gmae":"survival"}`,
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]any{"error": `unknown field "gmae"`, "field": "gmae"},
+		},
+		{
+			name:       "mistyped field",
+			body:       `{"count":"two"}`,
+			wantStatus: http.StatusBadRequest,
+			want: map[string]any{
+				"error": `field "count" must be integer, got string`, "field": "count", "expected": "integer", "got": "string",
+			},
+		},
+		{
+			name:       "syntax error",
+			body:       `{"game":}`,
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]any{"error": "malformed json at offset 9", "offset": float64(9)},
+		},
+		{
+			name:       "empty",
+			body:       ``,
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]any{"error": "empty json body"},
+		},
+		{
+			name:       "truncated",
+			body:       `{"game":"surv`,
+			wantStatus: http.StatusBadRequest,
+			want:       map[string]any{"error": "truncated json body"},
+		},
+		{
+			name:       "too large",
+			body:       `{"game":"` + strings.Repeat("a", 64) + `"}`,
+			limit:      16,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			want:       map[string]any{"error": "request body too large (limit 16 bytes)", "limit": float64(16)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(tt.body))
+			if tt.limit > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, tt.limit)
+			}
+			var dst req
+			err := decodeJSON(w, r, &dst)
+			if err == nil {
+				t.Fatal("decodeJSON accepted the body")
+			}
+			status, body := errorBody(t, invalidJSON(err))
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(body, tt.want) {
+				t.Fatalf("body = %v, want %v", body, tt.want)
+			}
+		})
+	}
+}
@@ -4,17 +4,70 @@ import (
 	"net/http"
 
 	"github.com/esuEdu/game-infra/controller/internal/app"
+	"github.com/esuEdu/game-infra/controller/internal/service"
 )
 
 func registerRoutes(a *app.App, mux *http.ServeMux) {
+	protect := func(need app.Scope, h http.Handler) http.Handler {
+		if a.Config.AuthMode == app.AuthHMAC {
+			return requireSignature(a.Config.HMACSecret, a.Config.HMACMaxSkew, a.Config.BodyLimits, h)
+		}
+		return requireScope(a.Config, need, h)
+	}
+	guard := func(need app.Scope) func(appHandler) http.Handler {
+		return func(h appHandler) http.Handler { return protect(need, wrap(a, h)) }
+	}
+	read, write, admin := guard(app.ScopeRead), guard(app.ScopeWrite), guard(app.ScopeAdmin)
+
 	mux.Handle("GET /healthz", wrap(a, handleHealth()))
-	mux.Handle("GET /v1/status", wrap(a, handleStatus()))
+	mux.Handle("GET /readyz", wrap(a, handleReady()))
+	mux.Handle("GET /v1/health/detail", read(handleHealthDetail()))
+	mux.Handle("GET /v1/version", read(handleVersion()))
+	mux.Handle("GET /metrics", protect(app.ScopeRead, handleMetrics()))
+	mux.Handle("GET /v1/status", read(handleStatus()))
+	mux.Handle("GET /v1/status/summary", read(handleStatusSummary()))
+	mux.Handle("GET /v1/games", read(handleGames()))
+	mux.Handle("GET /v1/games/{game}", read(handleGame()))
+
+	mux.Handle("POST /v1/server/start", write(handleStart()))
+	mux.Handle("POST /v1/server/stop", write(handleStop("")))
+	mux.Handle("POST /v1/server/backup-and-stop", write(handleStop(service.BackupBeforeStop)))
+	// Stopping everything is always guarded, even without API_TOKENS.
+	mux.Handle("POST /v1/server/stop-all", admin(handleStopAll()))
+	mux.Handle("POST /v1/server/restore", write(handleRestore()))
+	mux.Handle("POST /v1/server/restore/path", write(handleRestorePath()))
+	mux.Handle("POST /v1/server/switch", write(handleSwitch()))
+	mux.Handle("GET /v1/server/switch/preview", read(handleSwitchPreview()))
+	mux.Handle("POST /v1/server/backup", write(handleBackup()))
+	mux.Handle("POST /v1/server/command", write(handleCommand()))
+	mux.Handle("POST /v1/server/sync", write(handleSync()))
+	mux.Handle("POST /v1/server/seed", write(handleSeed()))
+	mux.Handle("POST /v1/source/validate", write(handleValidateSource()))
+	mux.Handle("POST /v1/server/adopt", write(handleAdopt()))
+	mux.Handle("POST /v1/server/redeploy", write(handleRedeploy()))
+
+	mux.Handle("GET /v1/logs", read(handleLogs()))
+	mux.Handle("GET /v1/commands/history", read(handleCommandHistory()))
+	mux.Handle("GET /v1/minecraft/connection", read(handleMinecraftConnection()))
+	mux.Handle("GET /v1/events", read(handleEvents()))
+	mux.Handle("GET /v1/operations", read(handleOperations()))
+	mux.Handle("POST /v1/operations/{id}/cancel", write(handleCancelOperation()))
+
+	mux.Handle("GET /v1/backups", read(handleListBackups()))
+	mux.Handle("GET /v1/backups/retention/preview", read(handleRetentionPreview()))
+	mux.Handle("POST /v1/backups/retention/apply", write(handleRetentionApply()))
+	transfers := make(chan struct{}, a.Config.BackupTransferMax)
+	mux.Handle("GET /v1/backups/content", read(handleBackupContent(transfers)))
+	mux.Handle("POST /v1/backups/content", write(handleBackupUpload(transfers)))
+	mux.Handle("POST /v1/backups/delete", write(handleDeleteBackup()))
+	// Copies write outside this environment's prefix, so they need admin.
+	mux.Handle("POST /v1/backups/copy", admin(handleCopyBackup()))
+	mux.Handle("POST /v1/backups/restore-deleted", write(handleRestoreDeletedBackup()))
 
-	mux.Handle("POST /v1/server/start", wrap(a, handleStart()))
-	mux.Handle("POST /v1/server/stop", wrap(a, handleStop()))
-	mux.Handle("POST /v1/server/switch", wrap(a, handleSwitch()))
-	mux.Handle("POST /v1/server/backup", wrap(a, handleBackup()))
-	mux.Handle("POST /v1/server/command", wrap(a, handleCommand()))
+	mux.Handle("POST /v1/admin/reset", admin(handleAdminReset()))
+	mux.Handle("POST /v1/admin/clear-phase", admin(handleClearPhase()))
+	mux.Handle("POST /v1/admin/git-token", admin(handleRotateGitToken()))
+	mux.Handle("POST /v1/admin/log-level", admin(handleSetLogLevel()))
 
 	mux.Handle("/", wrap(a, handleNotFound()))
 }
@@ -12,21 +12,34 @@ func NewServer(a *app.App) *http.Server {
 	registerRoutes(a, mux)
 
 	var h http.Handler = mux
+	h = requireJSONBody(h)
+	h = limitBody(a.Config.BodyLimits, h)
 
 	// LOG LAYER + safety middleware (order matters)
+	h = traceRequest(h)
 	h = requestID(h)
 	h = realIP(h)
 	h = recoverPanic(a.Log, h)
-	h = accessLog(a.Log, h)
-	h = limitInFlight(256, h)
+	h = accessLog(a.Log, a.Config.Slow, h)
+	h = limitInFlight(a.Config.RequestLimits, h)
 	h = withTimeout(10*time.Minute, h)
 
+	// HTTP/2 comes with TLS; without it only when H2C says a proxy in
+	// front speaks cleartext HTTP/2.
+	cfg := a.Config.HTTP
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(cfg.H2C)
+
 	return &http.Server{
 		Addr:              a.Config.HTTPAddr,
 		Handler:           h,
-		ReadTimeout:       10 * time.Second,
-		ReadHeaderTimeout: 5 * time.Second,
-		WriteTimeout:      11 * time.Minute,
-		IdleTimeout:       60 * time.Second,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		Protocols:         protocols,
 	}
 }
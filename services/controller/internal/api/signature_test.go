@@ -0,0 +1,121 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/app"
+)
+
+const testSecret = "s3cret"
+
+var testLimits = app.BodyLimits{Read: 64 << 10, Operation: 1 << 20, Upload: 1 << 20}
+
+// signed builds a request signed over body, or over its digest when digest
+// is set, and then sends sent as the body.
+func signed(method, target, body, sent string, digest bool) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(sent))
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	payload := []byte(body)
+	if digest {
+		sum := sha256.Sum256([]byte(body))
+		payload = []byte(hex.EncodeToString(sum[:]))
+		r.Header.Set(headerContentSHA256, string(payload))
+	}
+	r.Header.Set(headerSignatureTimestamp, ts)
+	r.Header.Set(headerSignature, hex.EncodeToString(signRequest([]byte(testSecret), method, r.URL.RequestURI(), ts, payload)))
+	return r
+}
+
+// startHandler decodes a start body the way the JSON handlers do and
+// records the game it would act on.
+func startHandler(got *string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Game string `json:"game"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeError(func(string, ...any) {}, w, invalidJSON(err))
+			return
+		}
+		*got = req.Game
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestSignatureJSONRoutes(t *testing.T) {
+	const original = `{"game":"minecraft"}`
+	tests := []struct {
+		name   string
+		signed string
+		sent   string
+		digest bool
+		want   int
+	}{
+		{name: "body signature", signed: original, sent: original, want: http.StatusOK},
+		{name: "swapped body", signed: original, sent: `{"game":"terraria"}`, want: http.StatusUnauthorized},
+		{name: "digest signature", signed: original, sent: original, digest: true, want: http.StatusUnauthorized},
+		{name: "swapped body under a digest signature", signed: original, sent: `{"game":"terraria"}` + strings.Repeat(" ", 64), digest: true, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var game string
+			h := requireSignature(testSecret, time.Minute, testLimits, startHandler(&game))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, signed(http.MethodPost, "/v1/start", tt.signed, tt.sent, tt.digest))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want != http.StatusOK && game != "" {
+				t.Fatalf("handler acted on game %q", game)
+			}
+		})
+	}
+}
+
+func TestSignatureUploadDigest(t *testing.T) {
+	const original = "world bytes"
+	tests := []struct {
+		name    string
+		sent    string
+		digest  bool
+		want    int
+		wantErr error
+	}{
+		{name: "matching body", sent: original, digest: true, want: http.StatusOK},
+		{name: "swapped body", sent: "other bytes", digest: true, want: http.StatusOK, wantErr: errBodyDigest},
+		{name: "no digest", sent: original, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var readErr error
+			called := false
+			h := requireSignature(testSecret, time.Minute, testLimits, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				_, readErr = io.Copy(io.Discard, r.Body)
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, signed(http.MethodPost, "/v1/backups/content?game=minecraft", original, tt.sent, tt.digest))
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want != http.StatusOK {
+				if called {
+					t.Fatal("handler ran without a valid signature")
+				}
+				return
+			}
+			if !errors.Is(readErr, tt.wantErr) {
+				t.Fatalf("read error = %v, want %v", readErr, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,13 +3,16 @@ package app
 import (
 	"log/slog"
 
+	"github.com/esuEdu/game-infra/controller/internal/events"
 	"github.com/esuEdu/game-infra/controller/internal/service"
 )
 
 type App struct {
 	Log        *slog.Logger
+	LogLevel   *slog.LevelVar // minimum level of Log; nil when fixed
 	Config     Config
 	Controller *service.ControllerService
+	Events     *events.Buffer // recent events for /v1/events; nil when disabled
 }
 
 func New(log *slog.Logger, cfg Config, controller *service.ControllerService) *App {
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+)
+
+// Scope is the access level granted to a bearer token. Higher scopes include
+// the lower ones: admin can do everything write can, and write everything
+// read can.
+type Scope int
+
+const (
+	ScopeNone Scope = iota
+	ScopeRead
+	ScopeWrite
+	ScopeAdmin
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeRead:
+		return "read"
+	case ScopeWrite:
+		return "write"
+	case ScopeAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+func (s Scope) Allows(need Scope) bool { return s >= need }
+
+func ParseScope(v string) (Scope, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "read":
+		return ScopeRead, nil
+	case "write":
+		return ScopeWrite, nil
+	case "admin":
+		return ScopeAdmin, nil
+	}
+	return ScopeNone, fmt.Errorf("unknown scope %q (want read, write or admin)", v)
+}
+
+// parseTokens parses API_TOKENS, a comma-separated list of token:scope pairs.
+func parseTokens(raw string) (map[string]Scope, error) {
+	tokens := map[string]Scope{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		token, scopeName, ok := strings.Cut(entry, ":")
+		token = strings.TrimSpace(token)
+		if !ok || token == "" {
+			return nil, fmt.Errorf("invalid entry %q (want token:scope)", entry)
+		}
+		scope, err := ParseScope(scopeName)
+		if err != nil {
+			return nil, err
+		}
+		tokens[token] = scope
+	}
+	return tokens, nil
+}
+
+// TokenScopes returns every configured token with its scope. API_KEY is an
+// admin token. Invalid API_TOKENS entries are rejected by Validate.
+func (c Config) TokenScopes() map[string]Scope {
+	tokens, _ := parseTokens(c.APITokens)
+	if tokens == nil {
+		tokens = map[string]Scope{}
+	}
+	if c.APIKey != "" {
+		tokens[c.APIKey] = ScopeAdmin
+	}
+	return tokens
+}
+
+// ScopedAuth reports whether read/write operations require a token. It is
+// off until API_TOKENS is set, so an API_KEY on its own only guards admin
+// operations, as before scopes existed.
+func (c Config) ScopedAuth() bool { return strings.TrimSpace(c.APITokens) != "" }
+
+// LookupToken returns the scope of token, comparing in constant time.
+func LookupToken(tokens map[string]Scope, token string) Scope {
+	found := ScopeNone
+	for t, s := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			found = s
+		}
+	}
+	return found
+}
@@ -1,9 +1,64 @@
 package app
 
-import "os"
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AuthToken = "token" // bearer tokens, see TokenScopes
+	AuthHMAC  = "hmac"  // signed requests, see api.requireSignature
+)
 
 type Config struct {
-	HTTPAddr string
+	HTTPAddr  string
+	HTTP      HTTPServer
+	GRPCAddr  string // empty disables the gRPC server
+	APIKey    string
+	APITokens string // token:scope,... see TokenScopes
+	TmpDir    string
+
+	LogLevel  string // debug | info | warn | error; changeable at runtime
+	LogFormat string // json | text
+
+	LogStreamMax       time.Duration // cap on a single /v1/logs stream
+	CommandHistorySize int
+	StrictStartup      bool   // fail startup when an adapter is misconfigured
+	StopBackupOrder    string // before | after, default for /v1/server/stop
+	BackupTransferMax  int    // concurrent /v1/backups/content transfers
+	BackupUploadMax    int64  // bytes accepted by POST /v1/backups/content
+	MaxListResults     int    // entries per page of a listing, whatever the limit
+	Slow               SlowThresholds
+	RequestLimits      RequestLimits
+	BodyLimits         BodyLimits
+	OperationLimits    string        // kind:n,... see OperationLimitMap
+	OperationQueueMax  int           // operations waiting for the lock; 0 = unbounded
+	SwitchCooldown     time.Duration // pause between stop and start in a switch
+	StatusCacheTTL     time.Duration // live status lookups shared for this long; 0 disables
+	UnsafeHotRestore   bool          // allow {"hot": true} restores under a running server
+	ECSTargets         string        // cluster/service,... see ECSTargetList
+
+	EventWebhookURL string // POSTs every operation event when set
+	EventBufferSize int    // events kept for /v1/events; 0 disables
+	EventLog        bool   // log every event
+
+	RetentionKeep   int           // newest backups always kept; 0 = no floor
+	RetentionMaxAge time.Duration // older backups beyond the floor are pruned
+
+	PreRestoreRetentionKeep   int           // the same two for the pre-restore
+	PreRestoreRetentionMaxAge time.Duration // stream's safety snapshots
+
+	BackupInterval   time.Duration // 0 disables scheduled backups
+	BackupJitter     time.Duration
+	BackupJitterMode string // random | instance
+
+	AuthMode    string // token | hmac
+	HMACSecret  string
+	HMACMaxSkew time.Duration
 }
 
 func LoadConfig() Config {
@@ -11,5 +66,373 @@ func LoadConfig() Config {
 	if addr == "" {
 		addr = ":8080"
 	}
-	return Config{HTTPAddr: addr}
+	logLevel := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_LEVEL")))
+	if logLevel == "" {
+		logLevel = "info"
+	}
+	logFormat := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT")))
+	if logFormat == "" {
+		logFormat = "json"
+	}
+	authMode := strings.ToLower(strings.TrimSpace(os.Getenv("AUTH_MODE")))
+	if authMode == "" {
+		authMode = AuthToken
+	}
+	logMax := 5 * time.Minute
+	if v, err := time.ParseDuration(strings.TrimSpace(os.Getenv("LOG_STREAM_MAX"))); err == nil && v > 0 {
+		logMax = v
+	}
+	historySize := 50
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv("COMMAND_HISTORY_SIZE"))); err == nil && v >= 0 {
+		historySize = v
+	}
+	slow := SlowThresholds{
+		Read:      durationEnv("SLOW_REQUEST_READ", time.Second),
+		Operation: durationEnv("SLOW_REQUEST_OPERATION", 2*time.Minute),
+	}
+	limits := RequestLimits{
+		Read:      intEnv("REQUEST_LIMIT_READ", 200),
+		Operation: intEnv("REQUEST_LIMIT_OPERATION", 32),
+		Stream:    intEnv("REQUEST_LIMIT_STREAM", 24),
+		QueueWait: durationEnv("QUEUE_WAIT", 0),
+	}
+	opLimits, ok := os.LookupEnv("OPERATION_LIMITS")
+	if !ok {
+		opLimits = "backup:1"
+	}
+	backupInterval := durationEnv("AUTO_BACKUP_INTERVAL", 0)
+	jitterMode := strings.ToLower(strings.TrimSpace(os.Getenv("AUTO_BACKUP_JITTER_MODE")))
+	if jitterMode == "" {
+		jitterMode = "random"
+	}
+	transferMax := 2
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv("BACKUP_TRANSFER_CONCURRENCY"))); err == nil && v > 0 {
+		transferMax = v
+	}
+	uploadMax := int64(4 << 30)
+	if v, err := strconv.ParseInt(strings.TrimSpace(os.Getenv("BACKUP_UPLOAD_MAX")), 10, 64); err == nil && v > 0 {
+		uploadMax = v
+	}
+	bodyLimits := BodyLimits{
+		Read:      int64Env("BODY_LIMIT_READ", 64<<10),
+		Operation: int64Env("BODY_LIMIT_OPERATION", 1<<20),
+		Upload:    int64Env("BODY_LIMIT_UPLOAD", uploadMax),
+	}
+	eventBuffer := 100
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv("EVENT_BUFFER_SIZE"))); err == nil && v >= 0 {
+		eventBuffer = v
+	}
+	eventLog, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("EVENT_LOG")))
+	strict, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("STRICT_STARTUP")))
+	unsafeHot, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("UNSAFE_HOT_RESTORE")))
+	skew := 5 * time.Minute
+	if v, err := time.ParseDuration(strings.TrimSpace(os.Getenv("HMAC_MAX_SKEW"))); err == nil && v > 0 {
+		skew = v
+	}
+	httpServer := HTTPServer{
+		ReadTimeout:       strictDurationEnv("HTTP_READ_TIMEOUT", 10*time.Second),
+		ReadHeaderTimeout: strictDurationEnv("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      strictDurationEnv("HTTP_WRITE_TIMEOUT", 11*time.Minute),
+		IdleTimeout:       strictDurationEnv("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		MaxHeaderBytes:    intEnv("HTTP_MAX_HEADER_BYTES", 1<<20),
+		TLSCertFile:       strings.TrimSpace(os.Getenv("TLS_CERT_FILE")),
+		TLSKeyFile:        strings.TrimSpace(os.Getenv("TLS_KEY_FILE")),
+	}
+	httpServer.H2C, _ = strconv.ParseBool(strings.TrimSpace(os.Getenv("HTTP_H2C")))
+	return Config{
+		HTTPAddr:  addr,
+		HTTP:      httpServer,
+		GRPCAddr:  strings.TrimSpace(os.Getenv("GRPC_ADDR")),
+		APIKey:    strings.TrimSpace(os.Getenv("API_KEY")),
+		APITokens: os.Getenv("API_TOKENS"),
+		TmpDir:    strings.TrimSpace(os.Getenv("TMP_DIR")),
+
+		LogLevel:  logLevel,
+		LogFormat: logFormat,
+
+		LogStreamMax:       logMax,
+		CommandHistorySize: historySize,
+		StrictStartup:      strict,
+		StopBackupOrder:    strings.ToLower(strings.TrimSpace(os.Getenv("STOP_BACKUP_ORDER"))),
+		BackupTransferMax:  transferMax,
+		BackupUploadMax:    uploadMax,
+		MaxListResults:     intEnv("MAX_LIST_RESULTS", 1000),
+		Slow:               slow,
+		RequestLimits:      limits,
+		BodyLimits:         bodyLimits,
+		OperationLimits:    opLimits,
+		OperationQueueMax:  intEnv("OPERATION_QUEUE_MAX", 0),
+		SwitchCooldown:     durationEnv("SWITCH_COOLDOWN", 0),
+		StatusCacheTTL:     durationEnv("STATUS_CACHE_TTL", 3*time.Second),
+		UnsafeHotRestore:   unsafeHot,
+		ECSTargets:         os.Getenv("ECS_TARGET_ALLOWLIST"),
+
+		EventWebhookURL: strings.TrimSpace(os.Getenv("EVENT_WEBHOOK_URL")),
+		EventBufferSize: eventBuffer,
+		EventLog:        eventLog,
+
+		RetentionKeep:   intEnv("BACKUP_RETENTION_KEEP", 0),
+		RetentionMaxAge: durationEnv("BACKUP_RETENTION_MAX_AGE", 0),
+
+		PreRestoreRetentionKeep:   intEnv("PRE_RESTORE_RETENTION_KEEP", 3),
+		PreRestoreRetentionMaxAge: durationEnv("PRE_RESTORE_RETENTION_MAX_AGE", 7*24*time.Hour),
+
+		BackupInterval:   backupInterval,
+		BackupJitter:     durationEnv("AUTO_BACKUP_JITTER", backupInterval/10),
+		BackupJitterMode: jitterMode,
+
+		AuthMode:    authMode,
+		HMACSecret:  os.Getenv("HMAC_SECRET"),
+		HMACMaxSkew: skew,
+	}
+}
+
+// HTTPServer tunes the HTTP listener. A zero timeout means none.
+type HTTPServer struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration // must outlast the 10m request timeout
+	IdleTimeout       time.Duration // keep-alive connections
+	MaxHeaderBytes    int
+
+	H2C         bool // HTTP/2 without TLS, for a proxy that speaks it
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// TLS reports whether the server terminates TLS itself (HTTP/2 is then
+// negotiated with ALPN).
+func (h HTTPServer) TLS() bool {
+	return h.TLSCertFile != ""
+}
+
+func (h HTTPServer) validate() error {
+	for _, d := range []struct {
+		env string
+		v   time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", h.ReadTimeout},
+		{"HTTP_READ_HEADER_TIMEOUT", h.ReadHeaderTimeout},
+		{"HTTP_WRITE_TIMEOUT", h.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", h.IdleTimeout},
+	} {
+		if d.v < 0 {
+			return fmt.Errorf("%s: want a duration >= 0 (e.g. 30s)", d.env)
+		}
+	}
+	if (h.TLSCertFile == "") != (h.TLSKeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if h.TLS() {
+		if _, err := tls.LoadX509KeyPair(h.TLSCertFile, h.TLSKeyFile); err != nil {
+			return fmt.Errorf("TLS_CERT_FILE/TLS_KEY_FILE: %w", err)
+		}
+	}
+	return nil
+}
+
+// SlowThresholds are the durations past which a request is logged as slow,
+// per route class. Zero disables the check for that class.
+type SlowThresholds struct {
+	Read      time.Duration
+	Operation time.Duration
+}
+
+func (s SlowThresholds) For(class string) time.Duration {
+	switch class {
+	case "read":
+		return s.Read
+	case "operation":
+		return s.Operation
+	}
+	return 0
+}
+
+// RequestLimits caps concurrent HTTP requests per route class, so reads keep
+// being served while writes queue behind a long operation. A saturated class
+// answers 429.
+type RequestLimits struct {
+	Read      int
+	Operation int
+	Stream    int
+	// QueueWait is how long a request waits for a slot in a full class
+	// before it is refused; 0 refuses at once.
+	QueueWait time.Duration
+}
+
+func (l RequestLimits) For(class string) int {
+	switch class {
+	case "read":
+		return l.Read
+	case "stream":
+		return l.Stream
+	}
+	return l.Operation
+}
+
+// BodyLimits caps request bodies in bytes per body class: "upload" for
+// POST /v1/backups/content, "read" for GETs and streams, "operation" for
+// every other mutation. Anything larger is refused with 413.
+type BodyLimits struct {
+	Read      int64
+	Operation int64
+	Upload    int64
+}
+
+func (l BodyLimits) For(class string) int64 {
+	switch class {
+	case "read":
+		return l.Read
+	case "upload":
+		return l.Upload
+	}
+	return l.Operation
+}
+
+// OperationLimitMap parses OPERATION_LIMITS, a comma-separated list of
+// kind:n pairs capping how many operations of an event kind (backup,
+// restore, ...) may be running or waiting at once.
+func (c Config) OperationLimitMap() (map[string]int, error) {
+	limits := map[string]int{}
+	for _, entry := range strings.Split(c.OperationLimits, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, raw, ok := strings.Cut(entry, ":")
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if !ok || kind == "" || err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid entry %q (want kind:n, n >= 1)", entry)
+		}
+		limits[kind] = n
+	}
+	return limits, nil
+}
+
+// ECSTargetList parses ECS_TARGET_ALLOWLIST, a comma-separated list of
+// cluster/service pairs that start, stop and switch may name in place of
+// an adapter's configured ECS service. cluster/* allows any service in
+// that cluster.
+func (c Config) ECSTargetList() ([][2]string, error) {
+	var targets [][2]string
+	for _, entry := range strings.Split(c.ECSTargets, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		cluster, svc, ok := strings.Cut(entry, "/")
+		cluster, svc = strings.TrimSpace(cluster), strings.TrimSpace(svc)
+		if !ok || cluster == "" || svc == "" {
+			return nil, fmt.Errorf("invalid entry %q (want cluster/service or cluster/*)", entry)
+		}
+		targets = append(targets, [2]string{cluster, svc})
+	}
+	return targets, nil
+}
+
+func intEnv(key string, fallback int) int {
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key))); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+// strictDurationEnv is durationEnv for settings Validate checks: a value
+// that is set but isn't a duration >= 0 comes back as -1 instead of being
+// ignored.
+func strictDurationEnv(key string, fallback time.Duration) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	if v, err := time.ParseDuration(raw); err == nil && v >= 0 {
+		return v
+	}
+	return -1
+}
+
+func int64Env(key string, fallback int64) int64 {
+	if v, err := strconv.ParseInt(strings.TrimSpace(os.Getenv(key)), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	if v, err := time.ParseDuration(strings.TrimSpace(os.Getenv(key))); err == nil && v >= 0 {
+		return v
+	}
+	return fallback
+}
+
+// Validate checks settings that would otherwise only fail mid-operation.
+func (c Config) Validate() error {
+	switch c.AuthMode {
+	case AuthToken:
+	case AuthHMAC:
+		if c.HMACSecret == "" {
+			return fmt.Errorf("AUTH_MODE=hmac requires HMAC_SECRET")
+		}
+		// gRPC calls aren't signed; without tokens they would run
+		// unauthenticated while HTTP requires a signature.
+		if c.GRPCAddr != "" && !c.ScopedAuth() {
+			return fmt.Errorf("AUTH_MODE=hmac with GRPC_ADDR requires API_TOKENS for gRPC callers")
+		}
+	default:
+		return fmt.Errorf("AUTH_MODE: unknown mode %q (want token or hmac)", c.AuthMode)
+	}
+	if err := c.HTTP.validate(); err != nil {
+		return err
+	}
+	if _, err := ParseLogLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+	switch c.LogFormat {
+	case "json", "text":
+	default:
+		return fmt.Errorf("LOG_FORMAT: unknown format %q (want json or text)", c.LogFormat)
+	}
+	switch c.StopBackupOrder {
+	case "", "before", "after":
+	default:
+		return fmt.Errorf("STOP_BACKUP_ORDER: unknown order %q (want before or after)", c.StopBackupOrder)
+	}
+	switch c.BackupJitterMode {
+	case "random", "instance":
+	default:
+		return fmt.Errorf("AUTO_BACKUP_JITTER_MODE: unknown mode %q (want random or instance)", c.BackupJitterMode)
+	}
+	if _, err := parseTokens(c.APITokens); err != nil {
+		return fmt.Errorf("API_TOKENS: %w", err)
+	}
+	if _, err := c.OperationLimitMap(); err != nil {
+		return fmt.Errorf("OPERATION_LIMITS: %w", err)
+	}
+	if _, err := c.ECSTargetList(); err != nil {
+		return fmt.Errorf("ECS_TARGET_ALLOWLIST: %w", err)
+	}
+	if c.TmpDir != "" {
+		if err := checkWritableDir(c.TmpDir); err != nil {
+			return fmt.Errorf("TMP_DIR: %w", err)
+		}
+	}
+	return nil
+}
+
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
 }
@@ -0,0 +1,53 @@
+// Package buildinfo reports which build of the controller is running. The
+// release build sets the variables with -ldflags, e.g.
+//
+//	-X github.com/esuEdu/game-infra/controller/internal/buildinfo.Version=v1.2.3
+//	-X github.com/esuEdu/game-infra/controller/internal/buildinfo.Commit=<sha>
+//	-X github.com/esuEdu/game-infra/controller/internal/buildinfo.BuildTime=<RFC 3339>
+//
+// A plain `go build` in a git checkout falls back to the VCS stamp Go embeds.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info is the build metadata served by /v1/version.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+	// Modified is true when the binary was built from a dirty checkout
+	// (VCS stamp only).
+	Modified bool `json:"modified,omitempty"`
+}
+
+// Get returns the running build's metadata.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	return info
+}
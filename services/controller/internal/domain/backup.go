@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type BackupInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// BackupContent is an open backup being streamed to a client.
+type BackupContent struct {
+	io.ReadCloser
+	Key  string // s3:// URI
+	Size int64
+}
+
+type SeedReport struct {
+	Source string `json:"data_url"`
+	Ref    string `json:"ref"`
+	Path   string `json:"path,omitempty"`
+	Commit string `json:"commit,omitempty"` // the ref's commit that was copied
+	Files  int    `json:"files"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// BackupReport describes a finished backup: its key, the files and bytes it
+// captured, and the size of the archive uploaded.
+type BackupReport struct {
+	Backup       string `json:"backup"`
+	Files        int    `json:"files"`
+	Bytes        int64  `json:"bytes"`
+	ArchiveBytes int64  `json:"archive_bytes"`
+}
+
+// SyncReport describes a push of the data dir to its source. Commit is the
+// ref's head afterwards, whether or not this sync created it.
+type SyncReport struct {
+	Committed bool   `json:"committed"`
+	Commit    string `json:"commit,omitempty"`
+}
+
+// SourceCheck is the result of validating a source URL before a seed: the
+// ref it names and the commit that ref currently points at.
+type SourceCheck struct {
+	Source string `json:"data_url"`
+	Ref    string `json:"ref"`
+	Path   string `json:"path,omitempty"`
+	Commit string `json:"commit"`
+}
+
+const backupTimeLayout = "20060102-150405"
+
+// LocalBackupScheme prefixes a backup ref naming an archive on the
+// controller's disk instead of an object in S3. Restoring one is break-glass
+// recovery for when S3 or its markers are unusable; it needs
+// RestoreOptions.AllowLocal and an adapter configured for it.
+const LocalBackupScheme = "file://"
+
+// LocalBackupPath returns the path of a file:// backup ref, false for any
+// other ref.
+func LocalBackupPath(ref string) (string, bool) {
+	return strings.CutPrefix(strings.TrimSpace(ref), LocalBackupScheme)
+}
+
+// BackupName returns the object name for a backup taken at t:
+// 20060102-150405-<6 hex><ext>, where ext is the archive extension (".zip" or
+// ".tar.gz"). The random suffix keeps two backups in the same second (manual
+// + scheduled) from overwriting each other, while the timestamp prefix keeps
+// names sorting by time.
+func BackupName(t time.Time, ext string) string {
+	var b [3]byte
+	_, _ = rand.Read(b[:])
+	return t.UTC().Format(backupTimeLayout) + "-" + hex.EncodeToString(b[:]) + ext
+}
+
+// BackupTime parses the timestamp BackupName puts at the start of a
+// backup's object name. It accepts a bare name, a key or an s3:// URI.
+func BackupTime(ref string) (time.Time, bool) {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	if len(name) < len(backupTimeLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(backupTimeLayout, name[:len(backupTimeLayout)])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// BackupForGame reports whether a backup key or s3:// URI lies under
+// <prefix>/<game>/, which is how every adapter lays out its keys
+// (<prefix>/<game>/[<stream>/]<name>). Only the segment right after prefix
+// counts, so a stream or file named after another game doesn't match it.
+func BackupForGame(ref, prefix, game string) bool {
+	key := strings.TrimSpace(ref)
+	if rest, ok := strings.CutPrefix(key, "s3://"); ok {
+		_, key, _ = strings.Cut(rest, "/")
+	}
+	if game == "" || strings.Contains(game, "/") {
+		return false
+	}
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		var ok bool
+		if key, ok = strings.CutPrefix(key, prefix+"/"); !ok {
+			return false
+		}
+	}
+	return strings.HasPrefix(key, game+"/")
+}
+
+// IsBackupKey reports whether key names a backup archive, as opposed to a
+// latest marker or anything else stored under the backup prefix.
+func IsBackupKey(key string) bool {
+	return strings.HasSuffix(key, ".zip") || strings.HasSuffix(key, ".tar.gz")
+}
+
+// PreRestoreStream is the stream restores snapshot the current world into
+// before replacing it, so a bad restore can be rolled back. It has its own
+// retention policy.
+const PreRestoreStream = "pre-restore"
+
+var streamNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ValidateStream checks a backup stream name. The empty string is the default
+// stream and is always valid.
+func ValidateStream(stream string) error {
+	if stream == "" {
+		return nil
+	}
+	if stream == "latest" || !streamNamePattern.MatchString(stream) {
+		return ErrInvalidStream
+	}
+	return nil
+}
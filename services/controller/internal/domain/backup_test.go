@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackupForGame(t *testing.T) {
+	tests := []struct {
+		name   string
+		ref    string
+		prefix string
+		game   string
+		want   bool
+	}{
+		{"key under prefix", "backups/minecraft/20240101-000000.zip", "backups", "minecraft", true},
+		{"stream under game", "backups/minecraft/pre-restore/20240101-000000.zip", "backups", "minecraft", true},
+		{"env prefix", "backups/staging/minecraft/20240101-000000.zip", "backups/staging", "minecraft", true},
+		{"s3 uri", "s3://bucket/backups/minecraft/20240101-000000.zip", "backups", "minecraft", true},
+		{"prefix with slashes", "backups/minecraft/20240101-000000.zip", "/backups/", "minecraft", true},
+		{"empty prefix", "minecraft/20240101-000000.zip", "", "minecraft", true},
+
+		{"other game", "backups/terraria/20240101-000000.zip", "backups", "minecraft", false},
+		{"game as a stream", "backups/terraria/minecraft/20240101-000000.zip", "backups", "minecraft", false},
+		{"game deeper in the path", "other/backups/minecraft/20240101-000000.zip", "backups", "minecraft", false},
+		{"other env", "backups/prod/minecraft/20240101-000000.zip", "backups/staging", "minecraft", false},
+		{"game as a prefix of the segment", "backups/minecraft-old/20240101-000000.zip", "backups", "minecraft", false},
+		{"prefix as a prefix of the segment", "backupsx/minecraft/20240101-000000.zip", "backups", "minecraft", false},
+		{"s3 bucket named after the prefix", "s3://backups/minecraft/20240101-000000.zip", "backups", "minecraft", false},
+		{"empty prefix, game deeper", "terraria/minecraft/20240101-000000.zip", "", "minecraft", false},
+		{"empty game", "backups/minecraft/20240101-000000.zip", "backups", "", false},
+		{"game with a slash", "backups/minecraft/x/1.zip", "backups", "minecraft/x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BackupForGame(tt.ref, tt.prefix, tt.game); got != tt.want {
+				t.Errorf("BackupForGame(%q, %q, %q) = %v, want %v", tt.ref, tt.prefix, tt.game, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackupNameSortsByTime(t *testing.T) {
+	clock := NewFakeClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	first := BackupName(clock.Now(), ".zip")
+	second := BackupName(clock.Now(), ".zip")
+	if first == second {
+		t.Fatalf("two backups in the same second are both named %q", first)
+	}
+	clock.Advance(time.Second)
+	later := BackupName(clock.Now(), ".tar.gz")
+	if later <= first || later <= second {
+		t.Fatalf("%q does not sort after %q and %q", later, first, second)
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source for what the controller records: backup keys,
+// state and result timestamps, markers, trash expiry and commit messages.
+// Network deadlines and request signing always use the real time.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the real clock, and the default everywhere.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// FakeClock only moves when Set or Advance is called, for deterministic
+// tests of key generation, retention and timestamps.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *FakeClock) Set(t time.Time) {
+	f.mu.Lock()
+	f.now = t
+	f.mu.Unlock()
+}
+
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
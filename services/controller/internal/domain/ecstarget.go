@@ -0,0 +1,55 @@
+package domain
+
+import "context"
+
+// ECSTarget is an ECS cluster and service an operation acts on in place of
+// the adapter's configured ones, for a controller that manages game services
+// in several clusters. The zero value means "use the configured service".
+type ECSTarget struct {
+	Cluster string `json:"cluster"`
+	Service string `json:"service"`
+}
+
+func (t ECSTarget) IsZero() bool { return t.Cluster == "" && t.Service == "" }
+
+func (t ECSTarget) String() string { return t.Cluster + "/" + t.Service }
+
+type ecsTargetKey struct{}
+
+// WithECSTarget returns ctx carrying t. A zero t leaves ctx as it is.
+func WithECSTarget(ctx context.Context, t ECSTarget) context.Context {
+	if t.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, ecsTargetKey{}, t)
+}
+
+// ECSTargetOr returns the cluster and service carried by ctx, or the given
+// defaults when there is no override.
+func ECSTargetOr(ctx context.Context, cluster, service string) (string, string) {
+	if t, ok := ctx.Value(ecsTargetKey{}).(ECSTarget); ok {
+		return t.Cluster, t.Service
+	}
+	return cluster, service
+}
+
+type preflightedKey struct{}
+
+// WithPreflighted marks ctx as having had its ECS service checked, so the
+// adapter's own pre-flight in Start doesn't repeat the DescribeService the
+// controller just made. The mark holds for the ECS target ctx carries now.
+func WithPreflighted(ctx context.Context) context.Context {
+	t, _ := ctx.Value(ecsTargetKey{}).(ECSTarget)
+	return context.WithValue(ctx, preflightedKey{}, t)
+}
+
+// Preflighted reports whether ctx was marked by WithPreflighted for the ECS
+// target it carries; a later WithECSTarget to another service clears it.
+func Preflighted(ctx context.Context) bool {
+	checked, ok := ctx.Value(preflightedKey{}).(ECSTarget)
+	if !ok {
+		return false
+	}
+	current, _ := ctx.Value(ecsTargetKey{}).(ECSTarget)
+	return checked == current
+}
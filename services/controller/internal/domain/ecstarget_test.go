@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPreflighted(t *testing.T) {
+	ctx := context.Background()
+	if Preflighted(ctx) {
+		t.Fatal("unmarked ctx is pre-flighted")
+	}
+	if !Preflighted(WithPreflighted(ctx)) {
+		t.Fatal("marked ctx is not pre-flighted")
+	}
+
+	target := WithECSTarget(ctx, ECSTarget{Cluster: "games", Service: "survival"})
+	marked := WithPreflighted(target)
+	if !Preflighted(marked) {
+		t.Fatal("marked ctx with a target is not pre-flighted")
+	}
+	if Preflighted(WithECSTarget(marked, ECSTarget{Cluster: "games", Service: "creative"})) {
+		t.Fatal("mark carried over to another ECS target")
+	}
+	if Preflighted(target) {
+		t.Fatal("mark leaked to the parent ctx")
+	}
+}
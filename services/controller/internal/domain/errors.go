@@ -3,9 +3,56 @@ package domain
 import "errors"
 
 var (
-	ErrNoActiveGame    = errors.New("no active game")
-	ErrUnknownGameType = errors.New("unknown game type")
-	ErrAnotherInFlight = errors.New("another operation is in progress")
-	ErrBadState        = errors.New("invalid state")
-	ErrNoBackupForGame = errors.New("no backup found for game")
+	ErrNoActiveGame     = errors.New("no active game")
+	ErrUnknownGameType  = errors.New("unknown game type")
+	ErrAnotherInFlight  = errors.New("another operation is in progress")
+	ErrBadState         = errors.New("invalid state")
+	ErrNoBackupForGame  = errors.New("no backup found for game")
+	ErrNotSupported     = errors.New("operation not supported for game")
+	ErrBackupNotInTrash = errors.New("backup not found in trash or trash window expired")
+	ErrInvalidStream    = errors.New("invalid backup stream name")
+	ErrGameStillRunning = errors.New("game service still has desired tasks")
+	ErrBackupArchived   = errors.New("backup is archived and must be restored from glacier first")
+	ErrNoSourceForGame  = errors.New("no source url recorded for game")
+	ErrGameActive       = errors.New("game is active; stop it first")
+	ErrGameNotActive    = errors.New("game is not active")
+	ErrInsufficientDisk = errors.New("insufficient ephemeral storage for backup")
+	ErrDataDirInUse     = errors.New("game tasks are running against the data dir; stop the game or pass force")
+	ErrLogUnavailable   = errors.New("game log is not available")
+	ErrIncompleteBackup = errors.New("backup is incomplete")
+	ErrMisconfigured    = errors.New("controller is misconfigured")
+	ErrInvalidArchive   = errors.New("invalid backup archive")
+	ErrInvalidPath      = errors.New("invalid path")
+	ErrPathNotInBackup  = errors.New("path not found in backup")
+	ErrChecksumMismatch = errors.New("downloaded backup does not match its checksum")
+	ErrGitTokenRejected = errors.New("git token was not accepted by the source repo")
+	ErrInvalidGameName  = errors.New("invalid game name")
+	ErrBackupOtherGame  = errors.New("backup belongs to another game")
+	ErrInvalidSourceURL = errors.New("invalid source url")
+	ErrLocalBackup      = errors.New("local backup restore is not allowed")
+	ErrEmptyBackup      = errors.New("backup captured no files")
+	ErrBackupNotNewer   = errors.New("backup is older than the current world")
+
+	ErrRetentionTokenInvalid = errors.New("invalid retention token")
+	ErrRetentionTokenStale   = errors.New("retention token expired or the prune set changed; preview again")
+
+	ErrSourceUnreachable  = errors.New("source repo could not be read")
+	ErrSourceRefNotFound  = errors.New("ref not found in source repo")
+	ErrSourcePathNotFound = errors.New("path not found in source repo")
+	ErrGitHostNotAllowed  = errors.New("git host is not in GIT_ALLOWED_HOSTS")
+
+	ErrBucketAccessDenied  = errors.New("access denied to backup bucket")
+	ErrECSTargetNotAllowed = errors.New("ecs cluster/service override is not allowed")
+	ErrECSAccessDenied     = errors.New("access denied to ecs")
+	ErrECSServiceNotFound  = errors.New("ecs service not found")
+	ErrECSClusterNotFound  = errors.New("ecs cluster not found")
+
+	ErrOperationNotFound  = errors.New("operation not found or already finished")
+	ErrOperationCancelled = errors.New("operation cancelled")
+	ErrOperationLimit     = errors.New("operation of this kind already in progress")
+	ErrOperationQueueFull = errors.New("too many operations waiting")
+
+	// ErrAdapterDegraded marks a Validate error that leaves the adapter usable
+	// with reduced functionality; anything else from Validate is a hard error.
+	ErrAdapterDegraded = errors.New("adapter is not fully functional")
 )
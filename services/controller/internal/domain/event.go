@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type EventKind string
+
+const (
+	EventStart          EventKind = "start"
+	EventStop           EventKind = "stop"
+	EventSwitch         EventKind = "switch"
+	EventBackup         EventKind = "backup"
+	EventRestore        EventKind = "restore"
+	EventSeed           EventKind = "seed"
+	EventSync           EventKind = "sync"
+	EventAdopt          EventKind = "adopt"
+	EventImport         EventKind = "import"
+	EventRestorePath    EventKind = "restore_path"
+	EventCopy           EventKind = "copy"
+	EventRetention      EventKind = "retention"
+	EventCommand        EventKind = "command"
+	EventRedeploy       EventKind = "redeploy"
+	EventDeleteBackup   EventKind = "delete_backup"
+	EventRestoreDeleted EventKind = "restore_deleted"
+)
+
+// Event describes a finished controller operation. Error is empty on success.
+type Event struct {
+	Kind      EventKind `json:"kind"`
+	Game      string    `json:"game,omitempty"`
+	Phase     string    `json:"phase,omitempty"`
+	Backup    string    `json:"backup,omitempty"`
+	Commit    string    `json:"commit,omitempty"` // source commit seeded from or synced to
+	Error     string    `json:"error,omitempty"`
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// EventSink receives controller events. Emit is called from a dedicated
+// goroutine per sink, so a slow sink only delays (and eventually drops) its
+// own events.
+type EventSink interface {
+	Emit(ev Event)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID attaches the caller's request id so events can be correlated
+// with access logs.
+func WithRequestID(ctx context.Context, rid string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, rid)
+}
+
+// RequestID returns the request id from ctx, or "".
+func RequestID(ctx context.Context) string {
+	rid, _ := ctx.Value(requestIDKey{}).(string)
+	return rid
+}
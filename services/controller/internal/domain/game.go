@@ -1,12 +1,17 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type GameType string
 
 const (
 	GameMinecraft GameType = "minecraft"
 	GameHytale    GameType = "hytale"
+	GameTerraria  GameType = "terraria"
+	GameValheim   GameType = "valheim"
 )
 
 type GameAdapter interface {
@@ -20,3 +25,49 @@ type GameAdapter interface {
 	SendCommand(ctx context.Context, command string) error
 	Status(ctx context.Context) (map[string]any, error)
 }
+
+// Capabilities reports which operations an adapter can actually perform with
+// its current configuration, so clients can hide what would fail or only be
+// logged by a stub.
+type Capabilities struct {
+	Backup      bool `json:"backup"`
+	Command     bool `json:"command"`
+	Seed        bool `json:"seed"`
+	Sync        bool `json:"sync"`
+	ListBackups bool `json:"list_backups"`
+}
+
+// RestoreOptions tunes an adapter restore. The zero value is the default,
+// safest behaviour.
+type RestoreOptions struct {
+	// Force skips safety checks that refuse a destructive restore, such as
+	// ECS tasks still running against a shared data dir.
+	Force bool
+	// AllowLocal permits a file:// backup ref (see LocalBackupScheme).
+	AllowLocal bool
+}
+
+// SeedOptions tunes an adapter seed from source.
+type SeedOptions struct {
+	Force bool // see RestoreOptions.Force
+}
+
+// ConnectionState describes an adapter's command connection (RCON for
+// Minecraft) as the controller has seen it since it started. Errors never
+// include the connection's password.
+type ConnectionState struct {
+	Configured bool   `json:"configured"`
+	Address    string `json:"address,omitempty"`
+	Running    bool   `json:"running"`
+	Active     bool   `json:"active"` // the game is the controller's active game
+	// Connected is true while a session is open or when the last attempt
+	// succeeded.
+	Connected   bool      `json:"connected"`
+	Open        int       `json:"open"`
+	Dials       int64     `json:"dials"`
+	Failures    int64     `json:"failures"`
+	Reconnects  int64     `json:"reconnects"` // sessions that succeeded after a failure
+	LastCommand time.Time `json:"last_command_at,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+}
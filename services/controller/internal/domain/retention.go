@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy decides which backups of a stream are kept. KeepLast is a
+// floor: the newest KeepLast backups always stay. Beyond it, backups older
+// than MaxAge are pruned; without MaxAge everything beyond KeepLast is. The
+// zero policy keeps everything.
+type RetentionPolicy struct {
+	KeepLast int
+	MaxAge   time.Duration
+}
+
+func (p RetentionPolicy) IsZero() bool { return p.KeepLast <= 0 && p.MaxAge <= 0 }
+
+// RetentionDecision is one backup's fate under a policy.
+type RetentionDecision struct {
+	BackupInfo
+	Reason string `json:"reason"`
+}
+
+// Plan sorts backups newest first and splits them into kept and pruned. The
+// backup latest names (the one a restore would pick) is always kept, so a
+// policy can never leave a stream without its latest world.
+func (p RetentionPolicy) Plan(backups []BackupInfo, latest string, now time.Time) (keep, prune []RetentionDecision) {
+	sorted := append([]BackupInfo(nil), backups...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].LastModified.Equal(sorted[j].LastModified) {
+			return sorted[i].LastModified.After(sorted[j].LastModified)
+		}
+		return sorted[i].Key > sorted[j].Key
+	})
+	for i, b := range sorted {
+		var reason string
+		switch {
+		case p.IsZero():
+			reason = "no retention policy"
+		case latest != "" && sameBackup(b.Key, latest):
+			reason = "latest"
+		case i < p.KeepLast:
+			reason = "within keep_last"
+		case p.MaxAge > 0 && now.Sub(b.LastModified) <= p.MaxAge:
+			reason = "younger than max_age"
+		}
+		if reason != "" {
+			keep = append(keep, RetentionDecision{BackupInfo: b, Reason: reason})
+			continue
+		}
+		reason = "beyond keep_last"
+		if p.MaxAge > 0 {
+			reason = "older than max_age"
+			if p.KeepLast > 0 {
+				reason += " and beyond keep_last"
+			}
+		}
+		prune = append(prune, RetentionDecision{BackupInfo: b, Reason: reason})
+	}
+	return keep, prune
+}
+
+// sameBackup compares a listed s3:// URI with a latest ref that may be a
+// bare key.
+func sameBackup(uri, ref string) bool {
+	return uri == ref || strings.HasSuffix(uri, "/"+strings.TrimPrefix(ref, "/"))
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var gameNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// ValidateGameName checks a game name from a request before it is used as a
+// state key, metric label or path component.
+func ValidateGameName(game string) error {
+	if len(game) > 64 || !gameNamePattern.MatchString(game) {
+		return fmt.Errorf("%w: must match ^[a-z0-9_-]+$", ErrInvalidGameName)
+	}
+	return nil
+}
+
+// maxSourceURLLen bounds a source URL; anything longer is not a repository
+// anyone typed.
+const maxSourceURLLen = 2048
+
+// sourceURLMetachars are refused anywhere in a source URL. Git is exec'd
+// without a shell, so this is defence in depth against a URL that would
+// mean something else to one.
+const sourceURLMetachars = " \t\r\n`$;|&<>(){}[]\\'\"!*?~^"
+
+// ValidateSourceURL checks a git source URL (repo[#ref[:path]]): an http,
+// https or ssh URL with a host, or scp-style user@host:path, and no shell
+// metacharacters or control characters. Anything else, including a leading
+// "-" that git would read as an option, is refused.
+func ValidateSourceURL(raw string) error {
+	invalid := func(reason string) error { return fmt.Errorf("%w: %s", ErrInvalidSourceURL, reason) }
+	if len(raw) > maxSourceURLLen {
+		return invalid(fmt.Sprintf("longer than %d bytes", maxSourceURLLen))
+	}
+	if strings.ContainsAny(raw, sourceURLMetachars) {
+		return invalid("contains whitespace or shell metacharacters")
+	}
+	if strings.ContainsFunc(raw, unicode.IsControl) || !utf8.ValidString(raw) {
+		return invalid("contains control characters or invalid utf-8")
+	}
+	repo, spec, _ := strings.Cut(raw, "#")
+	if strings.HasPrefix(spec, "-") {
+		return invalid("ref must not start with -")
+	}
+	if strings.Contains(spec, "..") {
+		return invalid("ref or path must not contain ..")
+	}
+	if scheme, _, ok := strings.Cut(repo, "://"); ok {
+		u, err := url.Parse(repo)
+		if err != nil || u.Host == "" || strings.HasPrefix(u.Host, "-") {
+			return invalid("not a valid url")
+		}
+		switch strings.ToLower(scheme) {
+		case "http", "https", "ssh":
+			return nil
+		}
+		return invalid("scheme must be http, https or ssh")
+	}
+	// scp-style ssh: user@host:path
+	userHost, path, ok := strings.Cut(repo, ":")
+	user, host, hasUser := strings.Cut(userHost, "@")
+	if !ok || !hasUser || user == "" || host == "" || path == "" || strings.HasPrefix(user, "-") {
+		return invalid("must be an http(s) or ssh url")
+	}
+	return nil
+}
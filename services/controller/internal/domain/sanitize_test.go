@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateGameName(t *testing.T) {
+	valid := []string{"minecraft", "terraria", "game_2", "a-b", strings.Repeat("a", 64)}
+	for _, name := range valid {
+		if err := ValidateGameName(name); err != nil {
+			t.Errorf("ValidateGameName(%q) = %v, want nil", name, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"..",
+		"../minecraft",
+		"minecraft/../terraria",
+		"mine/craft",
+		`mine\craft`,
+		".minecraft",
+		"Minecraft",
+		"mine craft",
+		"minecraft\n",
+		"minecraft\x00",
+		"mine\x1bcraft",
+		"minecraft​", // zero-width space
+		"mіnecraft",  // Cyrillic і
+		"ｍｉｎｅｃｒａｆｔ",  // fullwidth
+		"minecraft%2f..",
+		strings.Repeat("a", 65),
+		strings.Repeat("a", 10000),
+	}
+	for _, name := range invalid {
+		if err := ValidateGameName(name); !errors.Is(err, ErrInvalidGameName) {
+			t.Errorf("ValidateGameName(%q) = %v, want %v", name, err, ErrInvalidGameName)
+		}
+	}
+}
+
+func TestValidateSourceURL(t *testing.T) {
+	valid := []string{
+		"https://github.com/org/world.git",
+		"https://github.com/org/world.git#main",
+		"https://github.com/org/world.git#release/1.2:worlds/survival",
+		"http://git.example.com:8080/world.git",
+		"ssh://git@github.com/org/world.git",
+		"SSH://git@github.com/org/world.git",
+		"git@github.com:org/world.git",
+		"git@github.com:org/world.git#main:world",
+		"https://gít.example.com/world.git", // unicode host, resolved by git
+	}
+	for _, raw := range valid {
+		if err := ValidateSourceURL(raw); err != nil {
+			t.Errorf("ValidateSourceURL(%q) = %v, want nil", raw, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"world",
+		"/srv/world.git",
+		"../world.git",
+		"file:///srv/world.git",
+		"ftp://example.com/world.git",
+		"ext::sh -c touch% /tmp/pwned",
+		"-uhttps://github.com/org/world.git",
+		"https://-oProxyCommand=evil/world.git",
+		"-oProxyCommand=evil@host:world.git",
+		"git@github.com:",
+		"@github.com:org/world.git",
+		"https:///world.git",
+
+		// refs and paths
+		"https://github.com/org/world.git#--upload-pack=evil",
+		"https://github.com/org/world.git#main:../../etc",
+		"https://github.com/org/world.git#../main",
+
+		// shell metacharacters and whitespace
+		"https://github.com/org/world.git;rm -rf /",
+		"https://github.com/org/$(id).git",
+		"https://github.com/org/`id`.git",
+		"https://github.com/org/world.git|id",
+		"https://github.com/org/world.git\nhttps://evil.example/",
+		"https://github.com/org/world.git\t",
+		`https://github.com/org\world.git`,
+
+		// control characters
+		"https://github.com/org/world.git\x00",
+		"git@github.com:org/world\x00.git",
+		"git@github.com:org/world.git#main\x1b[2J",
+		"git@github.com:org/world.git#main\x7f",
+		"git@github.com:org/world.git#main\u0085",
+		"git@github.com:org/\xffworld.git",
+
+		// overlong
+		"https://github.com/" + strings.Repeat("a", 4096) + ".git",
+	}
+	for _, raw := range invalid {
+		if err := ValidateSourceURL(raw); !errors.Is(err, ErrInvalidSourceURL) {
+			t.Errorf("ValidateSourceURL(%q) = %v, want %v", raw, err, ErrInvalidSourceURL)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Timings collects how long the phases of an operation took, in
+// milliseconds, so a slow start can be pinned on ECS rather than the restore.
+// Adapters and clients record into the Timings carried by ctx; without one,
+// RecordTiming does nothing.
+type Timings struct {
+	mu sync.Mutex
+	ms map[string]int64
+}
+
+type timingsKey struct{}
+
+func WithTimings(ctx context.Context) (context.Context, *Timings) {
+	t := &Timings{ms: map[string]int64{}}
+	return context.WithValue(ctx, timingsKey{}, t), t
+}
+
+// RecordTiming adds d to the phase called name; repeated waits accumulate.
+func RecordTiming(ctx context.Context, name string, d time.Duration) {
+	if t, ok := ctx.Value(timingsKey{}).(*Timings); ok {
+		t.Add(name, d)
+	}
+}
+
+func (t *Timings) Add(name string, d time.Duration) {
+	t.mu.Lock()
+	t.ms[name] += d.Milliseconds()
+	t.mu.Unlock()
+}
+
+// Map returns a copy of the recorded phases.
+func (t *Timings) Map() map[string]int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]int64, len(t.ms))
+	for k, v := range t.ms {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/metrics"
+)
+
+var (
+	webhookCircuit      = metrics.Default.Gauge("controller_webhook_circuit_state", "Webhook circuit breaker state: 0 closed, 1 open, 2 half-open.")
+	webhookCircuitOpens = metrics.Default.Counter("controller_webhook_circuit_opens_total", "Times the webhook circuit breaker opened.")
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// breaker is a consecutive-failure circuit breaker. It opens after tripAfter
+// failed deliveries in a row, rejects everything for cooldown, then lets a
+// single probe through: success closes it, failure reopens it.
+type breaker struct {
+	log       *slog.Logger
+	tripAfter int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(log *slog.Logger, tripAfter int, cooldown time.Duration) *breaker {
+	webhookCircuit.Set(float64(breakerClosed))
+	return &breaker{log: log, tripAfter: tripAfter, cooldown: cooldown}
+}
+
+// allow reports whether a delivery may be attempted now.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerClosed:
+		return true
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(breakerHalfOpen)
+	}
+	if b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// open reports whether deliveries are currently being rejected, so retries
+// of an in-flight delivery can give up early.
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == breakerOpen
+}
+
+// record feeds the outcome of one delivery (after its retries).
+func (b *breaker) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.probing = false
+	}
+	if ok {
+		b.failures = 0
+		if b.state != breakerClosed {
+			b.setState(breakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || (b.state == breakerClosed && b.failures >= b.tripAfter) {
+		b.openedAt = time.Now()
+		b.setState(breakerOpen)
+		webhookCircuitOpens.Inc()
+	}
+}
+
+// setState is called with b.mu held.
+func (b *breaker) setState(s breakerState) {
+	level := slog.LevelWarn
+	if s == breakerClosed {
+		level = slog.LevelInfo
+	}
+	b.log.Log(context.Background(), level, "event webhook circuit "+s.String(), "failures", b.failures)
+	b.state = s
+	webhookCircuit.Set(float64(s))
+}
@@ -0,0 +1,172 @@
+// Package events provides the stock domain.EventSink implementations.
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/metrics"
+)
+
+// LogSink writes each event as a structured log line.
+type LogSink struct {
+	Log *slog.Logger
+}
+
+func (s LogSink) Emit(ev domain.Event) {
+	attrs := []any{"kind", ev.Kind, "game", ev.Game, "phase", ev.Phase, "rid", ev.RequestID}
+	if ev.Backup != "" {
+		attrs = append(attrs, "backup", ev.Backup)
+	}
+	if ev.Error != "" {
+		s.Log.Warn("event", append(attrs, "err", ev.Error)...)
+		return
+	}
+	s.Log.Info("event", attrs...)
+}
+
+// WebhookSink POSTs each event as JSON to URL. Failed deliveries are
+// retried with exponential backoff; after repeated failures a circuit
+// breaker drops events until a probe gets through. At most
+// webhookMaxInFlight deliveries run at once, so a slow endpoint backs up
+// the sink's queue (where overflow is dropped) rather than piling up
+// goroutines, and events may arrive out of order.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+	Log    *slog.Logger
+
+	breaker *breaker
+	slots   chan struct{}
+}
+
+const (
+	webhookTimeout     = 5 * time.Second
+	webhookMaxAttempts = 4
+	webhookBackoff     = 500 * time.Millisecond // doubles per retry
+	webhookMaxBackoff  = 10 * time.Second
+	webhookMaxInFlight = 4
+	webhookTripAfter   = 5 // consecutive failed deliveries
+	webhookCooldown    = 30 * time.Second
+)
+
+var webhookDeliveries = metrics.Default.Counter("controller_webhook_deliveries_total", "Webhook event deliveries by result: ok, failed, or rejected while the circuit was open.")
+
+// errWebhookRejected marks a 4xx reply, which a retry won't change.
+var errWebhookRejected = errors.New("webhook rejected event")
+
+func NewWebhookSink(log *slog.Logger, url string) *WebhookSink {
+	return &WebhookSink{
+		URL:     url,
+		Client:  &http.Client{Timeout: webhookTimeout},
+		Log:     log,
+		breaker: newBreaker(log, webhookTripAfter, webhookCooldown),
+		slots:   make(chan struct{}, webhookMaxInFlight),
+	}
+}
+
+func (s *WebhookSink) Emit(ev domain.Event) {
+	if !s.breaker.allow() {
+		webhookDeliveries.Inc("result", "rejected")
+		return
+	}
+	s.slots <- struct{}{}
+	go func() {
+		defer func() { <-s.slots }()
+		s.deliver(ev)
+	}()
+}
+
+func (s *WebhookSink) deliver(ev domain.Event) {
+	backoff := webhookBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = s.post(ev); err == nil {
+			s.breaker.record(true)
+			webhookDeliveries.Inc("result", "ok")
+			return
+		}
+		if attempt == webhookMaxAttempts || errors.Is(err, errWebhookRejected) || s.breaker.open() {
+			break
+		}
+		time.Sleep(backoff)
+		backoff = min(2*backoff, webhookMaxBackoff)
+	}
+	s.breaker.record(false)
+	webhookDeliveries.Inc("result", "failed")
+	s.Log.Warn("event webhook failed", "kind", ev.Kind, "err", err)
+}
+
+func (s *WebhookSink) post(ev domain.Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+		return fmt.Errorf("%w: webhook returned %s", errWebhookRejected, resp.Status)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Buffer keeps the last size events in memory for GET /v1/events.
+type Buffer struct {
+	mu     sync.Mutex
+	events []domain.Event
+	next   int
+	full   bool
+}
+
+func NewBuffer(size int) *Buffer {
+	return &Buffer{events: make([]domain.Event, size)}
+}
+
+func (b *Buffer) Emit(ev domain.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.events) == 0 {
+		return
+	}
+	b.events[b.next] = ev
+	b.next = (b.next + 1) % len(b.events)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Recent returns the buffered events, newest first.
+func (b *Buffer) Recent() []domain.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.next
+	if b.full {
+		n = len(b.events)
+	}
+	out := make([]domain.Event, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, b.events[(b.next-i+len(b.events))%len(b.events)])
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+// Package metrics is a small in-process metrics registry rendered in the
+// Prometheus text format at GET /metrics.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default is the registry served by the API.
+var Default = NewRegistry()
+
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*Family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]*Family{}}
+}
+
+// Family is a named metric with one value per label set.
+type Family struct {
+	name string
+	help string
+	kind string // counter | gauge
+
+	mu     sync.Mutex
+	values map[string]float64 // rendered label set -> value
+}
+
+// Counter returns the counter called name, creating it on first use.
+func (r *Registry) Counter(name, help string) *Family { return r.family(name, help, "counter") }
+
+// Gauge returns the gauge called name, creating it on first use.
+func (r *Registry) Gauge(name, help string) *Family { return r.family(name, help, "gauge") }
+
+func (r *Registry) family(name, help, kind string) *Family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		return f
+	}
+	f := &Family{name: name, help: help, kind: kind, values: map[string]float64{}}
+	r.families[name] = f
+	return f
+}
+
+// Inc adds one. labels are key, value pairs.
+func (f *Family) Inc(labels ...string) { f.Add(1, labels...) }
+
+func (f *Family) Add(v float64, labels ...string) {
+	key := labelSet(labels)
+	f.mu.Lock()
+	f.values[key] += v
+	f.mu.Unlock()
+}
+
+func (f *Family) Set(v float64, labels ...string) {
+	key := labelSet(labels)
+	f.mu.Lock()
+	f.values[key] = v
+	f.mu.Unlock()
+}
+
+// SetMax raises the value to v if v is larger.
+func (f *Family) SetMax(v float64, labels ...string) {
+	key := labelSet(labels)
+	f.mu.Lock()
+	if cur, ok := f.values[key]; !ok || v > cur {
+		f.values[key] = v
+	}
+	f.mu.Unlock()
+}
+
+// Value returns the current value for labels (0 if never set).
+func (f *Family) Value(labels ...string) float64 {
+	key := labelSet(labels)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values[key]
+}
+
+// WriteText renders every metric in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := r.families
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := families[name]
+		f.mu.Lock()
+		keys := make([]string, 0, len(f.values))
+		for k := range f.values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s%s %s\n", f.name, k, strconv.FormatFloat(f.values[k], 'g', -1, 64))
+		}
+		f.mu.Unlock()
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func labelSet(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	if len(labels)%2 != 0 {
+		panic("metrics: labels must be key, value pairs")
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(labels[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
@@ -0,0 +1,978 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: controller/v1/controller.proto
+
+package controllerv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StartRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Game    string                 `protobuf:"bytes,1,opt,name=game,proto3" json:"game,omitempty"`
+	DataUrl string                 `protobuf:"bytes,2,opt,name=data_url,json=dataUrl,proto3" json:"data_url,omitempty"`
+	Stream  string                 `protobuf:"bytes,3,opt,name=stream,proto3" json:"stream,omitempty"`
+	// 0 uses the adapter's configured count.
+	DesiredCount int32 `protobuf:"varint,4,opt,name=desired_count,json=desiredCount,proto3" json:"desired_count,omitempty"`
+	Force        bool  `protobuf:"varint,5,opt,name=force,proto3" json:"force,omitempty"`
+	// Resolve and return the plan without stopping, restoring or starting.
+	DryRun bool `protobuf:"varint,6,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// Optional ECS override; both or neither, and on the controller's allowlist.
+	Cluster string `protobuf:"bytes,7,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Service string `protobuf:"bytes,8,opt,name=service,proto3" json:"service,omitempty"`
+	// Restore a backup filed under another game's prefix.
+	AllowCrossGame bool `protobuf:"varint,9,opt,name=allow_cross_game,json=allowCrossGame,proto3" json:"allow_cross_game,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StartRequest) Reset() {
+	*x = StartRequest{}
+	mi := &file_controller_v1_controller_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRequest) ProtoMessage() {}
+
+func (x *StartRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRequest.ProtoReflect.Descriptor instead.
+func (*StartRequest) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartRequest) GetGame() string {
+	if x != nil {
+		return x.Game
+	}
+	return ""
+}
+
+func (x *StartRequest) GetDataUrl() string {
+	if x != nil {
+		return x.DataUrl
+	}
+	return ""
+}
+
+func (x *StartRequest) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *StartRequest) GetDesiredCount() int32 {
+	if x != nil {
+		return x.DesiredCount
+	}
+	return 0
+}
+
+func (x *StartRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+func (x *StartRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *StartRequest) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *StartRequest) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *StartRequest) GetAllowCrossGame() bool {
+	if x != nil {
+		return x.AllowCrossGame
+	}
+	return false
+}
+
+type StartResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Started string                 `protobuf:"bytes,1,opt,name=started,proto3" json:"started,omitempty"`
+	// data_url | backup
+	Source       string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Backup       string                 `protobuf:"bytes,3,opt,name=backup,proto3" json:"backup,omitempty"`
+	Stream       string                 `protobuf:"bytes,4,opt,name=stream,proto3" json:"stream,omitempty"`
+	DataUrl      string                 `protobuf:"bytes,5,opt,name=data_url,json=dataUrl,proto3" json:"data_url,omitempty"`
+	DesiredCount int32                  `protobuf:"varint,6,opt,name=desired_count,json=desiredCount,proto3" json:"desired_count,omitempty"`
+	FinishedAt   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	DurationMs   int64                  `protobuf:"varint,8,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	// Game stopped and backed up first, and the source it was synced to.
+	Replaced       string `protobuf:"bytes,9,opt,name=replaced,proto3" json:"replaced,omitempty"`
+	ReplacedSource string `protobuf:"bytes,10,opt,name=replaced_source,json=replacedSource,proto3" json:"replaced_source,omitempty"`
+	DryRun         bool   `protobuf:"varint,11,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// Phase durations: replace_ms, seed_ms | restore_ms, ecs_wait_ms, total_ms.
+	Timings map[string]int64 `protobuf:"bytes,12,rep,name=timings,proto3" json:"timings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Source commit the data dir was seeded from, when the adapter reports it.
+	Commit        string `protobuf:"bytes,13,opt,name=commit,proto3" json:"commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartResponse) Reset() {
+	*x = StartResponse{}
+	mi := &file_controller_v1_controller_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartResponse) ProtoMessage() {}
+
+func (x *StartResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartResponse.ProtoReflect.Descriptor instead.
+func (*StartResponse) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StartResponse) GetStarted() string {
+	if x != nil {
+		return x.Started
+	}
+	return ""
+}
+
+func (x *StartResponse) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *StartResponse) GetBackup() string {
+	if x != nil {
+		return x.Backup
+	}
+	return ""
+}
+
+func (x *StartResponse) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *StartResponse) GetDataUrl() string {
+	if x != nil {
+		return x.DataUrl
+	}
+	return ""
+}
+
+func (x *StartResponse) GetDesiredCount() int32 {
+	if x != nil {
+		return x.DesiredCount
+	}
+	return 0
+}
+
+func (x *StartResponse) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *StartResponse) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *StartResponse) GetReplaced() string {
+	if x != nil {
+		return x.Replaced
+	}
+	return ""
+}
+
+func (x *StartResponse) GetReplacedSource() string {
+	if x != nil {
+		return x.ReplacedSource
+	}
+	return ""
+}
+
+func (x *StartResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *StartResponse) GetTimings() map[string]int64 {
+	if x != nil {
+		return x.Timings
+	}
+	return nil
+}
+
+func (x *StartResponse) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+type StopRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// before | after; empty uses the controller's STOP_BACKUP_ORDER.
+	BackupOrder string `protobuf:"bytes,1,opt,name=backup_order,json=backupOrder,proto3" json:"backup_order,omitempty"`
+	// Optional ECS override; defaults to where the game was started.
+	Cluster       string `protobuf:"bytes,2,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Service       string `protobuf:"bytes,3,opt,name=service,proto3" json:"service,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	mi := &file_controller_v1_controller_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRequest) ProtoMessage() {}
+
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StopRequest) GetBackupOrder() string {
+	if x != nil {
+		return x.BackupOrder
+	}
+	return ""
+}
+
+func (x *StopRequest) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *StopRequest) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+type StopResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Game        string                 `protobuf:"bytes,1,opt,name=game,proto3" json:"game,omitempty"`
+	Stopped     bool                   `protobuf:"varint,2,opt,name=stopped,proto3" json:"stopped,omitempty"`
+	Backup      string                 `protobuf:"bytes,3,opt,name=backup,proto3" json:"backup,omitempty"`
+	Synced      bool                   `protobuf:"varint,4,opt,name=synced,proto3" json:"synced,omitempty"`
+	DataUrl     string                 `protobuf:"bytes,5,opt,name=data_url,json=dataUrl,proto3" json:"data_url,omitempty"`
+	FinishedAt  *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	DurationMs  int64                  `protobuf:"varint,7,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	BackupOrder string                 `protobuf:"bytes,8,opt,name=backup_order,json=backupOrder,proto3" json:"backup_order,omitempty"`
+	// Source commit after the sync, when the adapter reports it.
+	Commit        string `protobuf:"bytes,9,opt,name=commit,proto3" json:"commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopResponse) Reset() {
+	*x = StopResponse{}
+	mi := &file_controller_v1_controller_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopResponse) ProtoMessage() {}
+
+func (x *StopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopResponse.ProtoReflect.Descriptor instead.
+func (*StopResponse) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StopResponse) GetGame() string {
+	if x != nil {
+		return x.Game
+	}
+	return ""
+}
+
+func (x *StopResponse) GetStopped() bool {
+	if x != nil {
+		return x.Stopped
+	}
+	return false
+}
+
+func (x *StopResponse) GetBackup() string {
+	if x != nil {
+		return x.Backup
+	}
+	return ""
+}
+
+func (x *StopResponse) GetSynced() bool {
+	if x != nil {
+		return x.Synced
+	}
+	return false
+}
+
+func (x *StopResponse) GetDataUrl() string {
+	if x != nil {
+		return x.DataUrl
+	}
+	return ""
+}
+
+func (x *StopResponse) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *StopResponse) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *StopResponse) GetBackupOrder() string {
+	if x != nil {
+		return x.BackupOrder
+	}
+	return ""
+}
+
+func (x *StopResponse) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+type SwitchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Game  string                 `protobuf:"bytes,1,opt,name=game,proto3" json:"game,omitempty"`
+	// Optional ECS override for the game being started.
+	Cluster       string `protobuf:"bytes,2,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Service       string `protobuf:"bytes,3,opt,name=service,proto3" json:"service,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SwitchRequest) Reset() {
+	*x = SwitchRequest{}
+	mi := &file_controller_v1_controller_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SwitchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwitchRequest) ProtoMessage() {}
+
+func (x *SwitchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwitchRequest.ProtoReflect.Descriptor instead.
+func (*SwitchRequest) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SwitchRequest) GetGame() string {
+	if x != nil {
+		return x.Game
+	}
+	return ""
+}
+
+func (x *SwitchRequest) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *SwitchRequest) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+type SwitchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SwitchedTo    string                 `protobuf:"bytes,1,opt,name=switched_to,json=switchedTo,proto3" json:"switched_to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SwitchResponse) Reset() {
+	*x = SwitchResponse{}
+	mi := &file_controller_v1_controller_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SwitchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwitchResponse) ProtoMessage() {}
+
+func (x *SwitchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwitchResponse.ProtoReflect.Descriptor instead.
+func (*SwitchResponse) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SwitchResponse) GetSwitchedTo() string {
+	if x != nil {
+		return x.SwitchedTo
+	}
+	return ""
+}
+
+type BackupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stream        string                 `protobuf:"bytes,1,opt,name=stream,proto3" json:"stream,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupRequest) Reset() {
+	*x = BackupRequest{}
+	mi := &file_controller_v1_controller_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupRequest) ProtoMessage() {}
+
+func (x *BackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupRequest.ProtoReflect.Descriptor instead.
+func (*BackupRequest) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BackupRequest) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+type BackupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Backup        string                 `protobuf:"bytes,1,opt,name=backup,proto3" json:"backup,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupResponse) Reset() {
+	*x = BackupResponse{}
+	mi := &file_controller_v1_controller_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupResponse) ProtoMessage() {}
+
+func (x *BackupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupResponse.ProtoReflect.Descriptor instead.
+func (*BackupResponse) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BackupResponse) GetBackup() string {
+	if x != nil {
+		return x.Backup
+	}
+	return ""
+}
+
+type CommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandRequest) Reset() {
+	*x = CommandRequest{}
+	mi := &file_controller_v1_controller_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandRequest) ProtoMessage() {}
+
+func (x *CommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandRequest.ProtoReflect.Descriptor instead.
+func (*CommandRequest) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CommandRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+type CommandResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Sent  bool                   `protobuf:"varint,1,opt,name=sent,proto3" json:"sent,omitempty"`
+	// Empty unless the adapter returns command output.
+	Output        string `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandResponse) Reset() {
+	*x = CommandResponse{}
+	mi := &file_controller_v1_controller_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResponse) ProtoMessage() {}
+
+func (x *CommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResponse.ProtoReflect.Descriptor instead.
+func (*CommandResponse) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CommandResponse) GetSent() bool {
+	if x != nil {
+		return x.Sent
+	}
+	return false
+}
+
+func (x *CommandResponse) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_controller_v1_controller_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{10}
+}
+
+type StatusResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Same document as GET /v1/status.
+	Status        *structpb.Struct `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_controller_v1_controller_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controller_v1_controller_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_controller_v1_controller_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StatusResponse) GetStatus() *structpb.Struct {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+var File_controller_v1_controller_proto protoreflect.FileDescriptor
+
+const file_controller_v1_controller_proto_rawDesc = "" +
+	"\n" +
+	"\x1econtroller/v1/controller.proto\x12\rcontroller.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x87\x02\n" +
+	"\fStartRequest\x12\x12\n" +
+	"\x04game\x18\x01 \x01(\tR\x04game\x12\x19\n" +
+	"\bdata_url\x18\x02 \x01(\tR\adataUrl\x12\x16\n" +
+	"\x06stream\x18\x03 \x01(\tR\x06stream\x12#\n" +
+	"\rdesired_count\x18\x04 \x01(\x05R\fdesiredCount\x12\x14\n" +
+	"\x05force\x18\x05 \x01(\bR\x05force\x12\x17\n" +
+	"\adry_run\x18\x06 \x01(\bR\x06dryRun\x12\x18\n" +
+	"\acluster\x18\a \x01(\tR\acluster\x12\x18\n" +
+	"\aservice\x18\b \x01(\tR\aservice\x12(\n" +
+	"\x10allow_cross_game\x18\t \x01(\bR\x0eallowCrossGame\"\x86\x04\n" +
+	"\rStartResponse\x12\x18\n" +
+	"\astarted\x18\x01 \x01(\tR\astarted\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x16\n" +
+	"\x06backup\x18\x03 \x01(\tR\x06backup\x12\x16\n" +
+	"\x06stream\x18\x04 \x01(\tR\x06stream\x12\x19\n" +
+	"\bdata_url\x18\x05 \x01(\tR\adataUrl\x12#\n" +
+	"\rdesired_count\x18\x06 \x01(\x05R\fdesiredCount\x12;\n" +
+	"\vfinished_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x1f\n" +
+	"\vduration_ms\x18\b \x01(\x03R\n" +
+	"durationMs\x12\x1a\n" +
+	"\breplaced\x18\t \x01(\tR\breplaced\x12'\n" +
+	"\x0freplaced_source\x18\n" +
+	" \x01(\tR\x0ereplacedSource\x12\x17\n" +
+	"\adry_run\x18\v \x01(\bR\x06dryRun\x12C\n" +
+	"\atimings\x18\f \x03(\v2).controller.v1.StartResponse.TimingsEntryR\atimings\x12\x16\n" +
+	"\x06commit\x18\r \x01(\tR\x06commit\x1a:\n" +
+	"\fTimingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"d\n" +
+	"\vStopRequest\x12!\n" +
+	"\fbackup_order\x18\x01 \x01(\tR\vbackupOrder\x12\x18\n" +
+	"\acluster\x18\x02 \x01(\tR\acluster\x12\x18\n" +
+	"\aservice\x18\x03 \x01(\tR\aservice\"\xa0\x02\n" +
+	"\fStopResponse\x12\x12\n" +
+	"\x04game\x18\x01 \x01(\tR\x04game\x12\x18\n" +
+	"\astopped\x18\x02 \x01(\bR\astopped\x12\x16\n" +
+	"\x06backup\x18\x03 \x01(\tR\x06backup\x12\x16\n" +
+	"\x06synced\x18\x04 \x01(\bR\x06synced\x12\x19\n" +
+	"\bdata_url\x18\x05 \x01(\tR\adataUrl\x12;\n" +
+	"\vfinished_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x1f\n" +
+	"\vduration_ms\x18\a \x01(\x03R\n" +
+	"durationMs\x12!\n" +
+	"\fbackup_order\x18\b \x01(\tR\vbackupOrder\x12\x16\n" +
+	"\x06commit\x18\t \x01(\tR\x06commit\"W\n" +
+	"\rSwitchRequest\x12\x12\n" +
+	"\x04game\x18\x01 \x01(\tR\x04game\x12\x18\n" +
+	"\acluster\x18\x02 \x01(\tR\acluster\x12\x18\n" +
+	"\aservice\x18\x03 \x01(\tR\aservice\"1\n" +
+	"\x0eSwitchResponse\x12\x1f\n" +
+	"\vswitched_to\x18\x01 \x01(\tR\n" +
+	"switchedTo\"'\n" +
+	"\rBackupRequest\x12\x16\n" +
+	"\x06stream\x18\x01 \x01(\tR\x06stream\"(\n" +
+	"\x0eBackupResponse\x12\x16\n" +
+	"\x06backup\x18\x01 \x01(\tR\x06backup\"*\n" +
+	"\x0eCommandRequest\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\"=\n" +
+	"\x0fCommandResponse\x12\x12\n" +
+	"\x04sent\x18\x01 \x01(\bR\x04sent\x12\x16\n" +
+	"\x06output\x18\x02 \x01(\tR\x06output\"\x0f\n" +
+	"\rStatusRequest\"A\n" +
+	"\x0eStatusResponse\x12/\n" +
+	"\x06status\x18\x01 \x01(\v2\x17.google.protobuf.StructR\x06status2\xb7\x03\n" +
+	"\x11ControllerService\x12B\n" +
+	"\x05Start\x12\x1b.controller.v1.StartRequest\x1a\x1c.controller.v1.StartResponse\x12?\n" +
+	"\x04Stop\x12\x1a.controller.v1.StopRequest\x1a\x1b.controller.v1.StopResponse\x12E\n" +
+	"\x06Switch\x12\x1c.controller.v1.SwitchRequest\x1a\x1d.controller.v1.SwitchResponse\x12E\n" +
+	"\x06Backup\x12\x1c.controller.v1.BackupRequest\x1a\x1d.controller.v1.BackupResponse\x12H\n" +
+	"\aCommand\x12\x1d.controller.v1.CommandRequest\x1a\x1e.controller.v1.CommandResponse\x12E\n" +
+	"\x06Status\x12\x1c.controller.v1.StatusRequest\x1a\x1d.controller.v1.StatusResponseBPZNgithub.com/esuEdu/game-infra/controller/internal/rpc/controllerv1;controllerv1b\x06proto3"
+
+var (
+	file_controller_v1_controller_proto_rawDescOnce sync.Once
+	file_controller_v1_controller_proto_rawDescData []byte
+)
+
+func file_controller_v1_controller_proto_rawDescGZIP() []byte {
+	file_controller_v1_controller_proto_rawDescOnce.Do(func() {
+		file_controller_v1_controller_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_controller_v1_controller_proto_rawDesc), len(file_controller_v1_controller_proto_rawDesc)))
+	})
+	return file_controller_v1_controller_proto_rawDescData
+}
+
+var file_controller_v1_controller_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_controller_v1_controller_proto_goTypes = []any{
+	(*StartRequest)(nil),          // 0: controller.v1.StartRequest
+	(*StartResponse)(nil),         // 1: controller.v1.StartResponse
+	(*StopRequest)(nil),           // 2: controller.v1.StopRequest
+	(*StopResponse)(nil),          // 3: controller.v1.StopResponse
+	(*SwitchRequest)(nil),         // 4: controller.v1.SwitchRequest
+	(*SwitchResponse)(nil),        // 5: controller.v1.SwitchResponse
+	(*BackupRequest)(nil),         // 6: controller.v1.BackupRequest
+	(*BackupResponse)(nil),        // 7: controller.v1.BackupResponse
+	(*CommandRequest)(nil),        // 8: controller.v1.CommandRequest
+	(*CommandResponse)(nil),       // 9: controller.v1.CommandResponse
+	(*StatusRequest)(nil),         // 10: controller.v1.StatusRequest
+	(*StatusResponse)(nil),        // 11: controller.v1.StatusResponse
+	nil,                           // 12: controller.v1.StartResponse.TimingsEntry
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 14: google.protobuf.Struct
+}
+var file_controller_v1_controller_proto_depIdxs = []int32{
+	13, // 0: controller.v1.StartResponse.finished_at:type_name -> google.protobuf.Timestamp
+	12, // 1: controller.v1.StartResponse.timings:type_name -> controller.v1.StartResponse.TimingsEntry
+	13, // 2: controller.v1.StopResponse.finished_at:type_name -> google.protobuf.Timestamp
+	14, // 3: controller.v1.StatusResponse.status:type_name -> google.protobuf.Struct
+	0,  // 4: controller.v1.ControllerService.Start:input_type -> controller.v1.StartRequest
+	2,  // 5: controller.v1.ControllerService.Stop:input_type -> controller.v1.StopRequest
+	4,  // 6: controller.v1.ControllerService.Switch:input_type -> controller.v1.SwitchRequest
+	6,  // 7: controller.v1.ControllerService.Backup:input_type -> controller.v1.BackupRequest
+	8,  // 8: controller.v1.ControllerService.Command:input_type -> controller.v1.CommandRequest
+	10, // 9: controller.v1.ControllerService.Status:input_type -> controller.v1.StatusRequest
+	1,  // 10: controller.v1.ControllerService.Start:output_type -> controller.v1.StartResponse
+	3,  // 11: controller.v1.ControllerService.Stop:output_type -> controller.v1.StopResponse
+	5,  // 12: controller.v1.ControllerService.Switch:output_type -> controller.v1.SwitchResponse
+	7,  // 13: controller.v1.ControllerService.Backup:output_type -> controller.v1.BackupResponse
+	9,  // 14: controller.v1.ControllerService.Command:output_type -> controller.v1.CommandResponse
+	11, // 15: controller.v1.ControllerService.Status:output_type -> controller.v1.StatusResponse
+	10, // [10:16] is the sub-list for method output_type
+	4,  // [4:10] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_controller_v1_controller_proto_init() }
+func file_controller_v1_controller_proto_init() {
+	if File_controller_v1_controller_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_controller_v1_controller_proto_rawDesc), len(file_controller_v1_controller_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_controller_v1_controller_proto_goTypes,
+		DependencyIndexes: file_controller_v1_controller_proto_depIdxs,
+		MessageInfos:      file_controller_v1_controller_proto_msgTypes,
+	}.Build()
+	File_controller_v1_controller_proto = out.File
+	file_controller_v1_controller_proto_goTypes = nil
+	file_controller_v1_controller_proto_depIdxs = nil
+}
@@ -0,0 +1,345 @@
+// Package rpc serves the controller operations over gRPC, alongside the HTTP
+// API. The service is defined in proto/controller/v1/controller.proto; run
+// `buf generate` from services/controller after editing it.
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/esuEdu/game-infra/controller/internal/api"
+	"github.com/esuEdu/game-infra/controller/internal/app"
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	pb "github.com/esuEdu/game-infra/controller/internal/rpc/controllerv1"
+	"github.com/esuEdu/game-infra/controller/internal/service"
+	"github.com/esuEdu/game-infra/controller/internal/tracing"
+)
+
+func NewServer(a *app.App) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		accessLog(a),
+		traceRequest(),
+		recoverPanic(a),
+		requireScope(a.Config),
+	))
+	pb.RegisterControllerServiceServer(srv, &controllerServer{a: a})
+	return srv
+}
+
+type controllerServer struct {
+	pb.UnimplementedControllerServiceServer
+	a *app.App
+}
+
+func (s *controllerServer) Start(ctx context.Context, req *pb.StartRequest) (*pb.StartResponse, error) {
+	if req.GetGame() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing field: game")
+	}
+	if err := domain.ValidateGameName(req.GetGame()); err != nil {
+		return nil, s.toStatus(err)
+	}
+	if dataURL := strings.TrimSpace(req.GetDataUrl()); dataURL != "" {
+		if err := domain.ValidateSourceURL(dataURL); err != nil {
+			return nil, s.toStatus(err)
+		}
+	}
+	if req.GetDesiredCount() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "desired_count must be >= 1")
+	}
+	target, err := ecsTarget(req.GetCluster(), req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.a.Controller.Start(ctx, service.StartRequest{
+		Game:         req.GetGame(),
+		DataURL:      req.GetDataUrl(),
+		Stream:       strings.TrimSpace(req.GetStream()),
+		DesiredCount: req.GetDesiredCount(),
+		Force:        req.GetForce(),
+		DryRun:       req.GetDryRun(),
+		ECSTarget:    target,
+
+		AllowCrossGame: req.GetAllowCrossGame(),
+	})
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.StartResponse{
+		Started:      res.Started,
+		Source:       res.Source,
+		Backup:       res.Backup,
+		Stream:       res.Stream,
+		DataUrl:      res.DataURL,
+		Commit:       res.Commit,
+		DesiredCount: res.DesiredCount,
+		FinishedAt:   timestamppb.New(res.FinishedAt),
+		DurationMs:   res.DurationMS,
+
+		Replaced:       res.Replaced,
+		ReplacedSource: res.ReplacedSource,
+		DryRun:         res.DryRun,
+		Timings:        res.Timings,
+	}, nil
+}
+
+func (s *controllerServer) Stop(ctx context.Context, req *pb.StopRequest) (*pb.StopResponse, error) {
+	if !service.ValidBackupOrder(req.GetBackupOrder()) {
+		return nil, status.Error(codes.InvalidArgument, "backup_order must be before or after")
+	}
+	target, err := ecsTarget(req.GetCluster(), req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.a.Controller.Stop(ctx, service.StopRequest{BackupOrder: req.GetBackupOrder(), ECSTarget: target})
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.StopResponse{
+		Game:       res.Game,
+		Stopped:    res.Stopped,
+		Backup:     res.Backup,
+		Synced:     res.Synced,
+		DataUrl:    res.DataURL,
+		Commit:     res.Commit,
+		FinishedAt: timestamppb.New(res.FinishedAt),
+		DurationMs: res.DurationMS,
+
+		BackupOrder: res.BackupOrder,
+	}, nil
+}
+
+// ecsTarget mirrors the HTTP handlers: cluster and service come as a pair.
+func ecsTarget(cluster, svc string) (domain.ECSTarget, error) {
+	t := domain.ECSTarget{Cluster: strings.TrimSpace(cluster), Service: strings.TrimSpace(svc)}
+	if (t.Cluster == "") != (t.Service == "") {
+		return domain.ECSTarget{}, status.Error(codes.InvalidArgument, "cluster and service must be given together")
+	}
+	return t, nil
+}
+
+func (s *controllerServer) Switch(ctx context.Context, req *pb.SwitchRequest) (*pb.SwitchResponse, error) {
+	if req.GetGame() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing field: game")
+	}
+	if err := domain.ValidateGameName(req.GetGame()); err != nil {
+		return nil, s.toStatus(err)
+	}
+	target, err := ecsTarget(req.GetCluster(), req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.a.Controller.Switch(ctx, req.GetGame(), target); err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.SwitchResponse{SwitchedTo: req.GetGame()}, nil
+}
+
+func (s *controllerServer) Backup(ctx context.Context, req *pb.BackupRequest) (*pb.BackupResponse, error) {
+	key, err := s.a.Controller.Backup(ctx, strings.TrimSpace(req.GetStream()))
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.BackupResponse{Backup: key}, nil
+}
+
+func (s *controllerServer) Command(ctx context.Context, req *pb.CommandRequest) (*pb.CommandResponse, error) {
+	if strings.TrimSpace(req.GetCommand()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing field: command")
+	}
+	var remoteIP string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteIP, _, _ = net.SplitHostPort(p.Addr.String())
+	}
+	res, err := s.a.Controller.Command(ctx, service.CommandRequest{
+		Command:  req.GetCommand(),
+		RemoteIP: remoteIP,
+	})
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.CommandResponse{Sent: res.Sent, Output: res.Output}, nil
+}
+
+func (s *controllerServer) Status(ctx context.Context, _ *pb.StatusRequest) (*pb.StatusResponse, error) {
+	st, err := s.a.Controller.Status(ctx)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	// Round-trip through JSON so the document matches GET /v1/status exactly.
+	b, err := json.Marshal(st)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	doc := &structpb.Struct{}
+	if err := protojson.Unmarshal(b, doc); err != nil {
+		return nil, s.toStatus(err)
+	}
+	return &pb.StatusResponse{Status: doc}, nil
+}
+
+// httpToCode translates the statuses used by the HTTP error table.
+var httpToCode = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusInsufficientStorage: codes.ResourceExhausted,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusBadGateway:          codes.Unavailable,
+}
+
+func (s *controllerServer) toStatus(err error) error {
+	if httpStatus, ok := api.DomainErrorStatus(err); ok {
+		if code, ok := httpToCode[httpStatus]; ok {
+			return status.Error(code, err.Error())
+		}
+	}
+	s.a.Log.Error("internal error", "err", err)
+	return status.Error(codes.Internal, "internal server error")
+}
+
+// methodScopes lists methods that only need read access; everything else
+// mutates and needs write, matching the HTTP routes.
+var methodScopes = map[string]app.Scope{
+	pb.ControllerService_Status_FullMethodName: app.ScopeRead,
+}
+
+// requireScope checks the caller's bearer token. gRPC requests carry no HMAC
+// signature, so under AUTH_MODE=hmac they need API_TOKENS (Config.Validate
+// insists) and are refused outright without them.
+func requireScope(cfg app.Config) grpc.UnaryServerInterceptor {
+	tokens := cfg.TokenScopes()
+	scoped := cfg.ScopedAuth()
+	hmacOnly := cfg.AuthMode == app.AuthHMAC && !scoped
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if hmacOnly {
+			return nil, status.Error(codes.Unauthenticated, "unauthorized")
+		}
+		if !scoped {
+			return handler(ctx, req)
+		}
+		need, ok := methodScopes[info.FullMethod]
+		if !ok {
+			need = app.ScopeWrite
+		}
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("authorization"); len(v) > 0 {
+				token, _ = strings.CutPrefix(strings.TrimSpace(v[0]), "Bearer ")
+			}
+		}
+		scope := app.LookupToken(tokens, strings.TrimSpace(token))
+		if scope == app.ScopeNone {
+			return nil, status.Error(codes.Unauthenticated, "unauthorized")
+		}
+		if !scope.Allows(need) {
+			return nil, status.Error(codes.PermissionDenied, "insufficient scope")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// accessLog also assigns the request id (the caller's x-request-id metadata,
+// or a fresh one) that events, S3 metadata and git trailers carry.
+func accessLog(a *app.App) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		var rid string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("x-request-id"); len(v) > 0 {
+				rid = strings.TrimSpace(v[0])
+			}
+		}
+		if !api.ValidRequestID(rid) {
+			rid = newRID()
+		}
+		resp, err := handler(domain.WithRequestID(ctx, rid), req)
+		a.Log.Info("grpc request",
+			"rid", rid,
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"dur_ms", time.Since(start).Milliseconds(),
+		)
+		return resp, err
+	}
+}
+
+// traceRequest opens the server span for a call, continuing the caller's
+// trace from its traceparent metadata. It runs inside accessLog so the span
+// carries the request id.
+func traceRequest() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			ctx = tracing.Extract(ctx, metadataCarrier(md))
+		}
+		ctx, span := tracing.StartServer(ctx, info.FullMethod, attribute.String("rpc.system", "grpc"))
+		defer span.End()
+		resp, err := handler(ctx, req)
+		code := status.Code(err)
+		span.SetAttributes(attribute.String("rpc.grpc.status_code", code.String()))
+		if code == codes.Internal || code == codes.Unknown || code == codes.Unavailable {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		return resp, err
+	}
+}
+
+// metadataCarrier adapts incoming gRPC metadata to otel's TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (m metadataCarrier) Get(key string) string {
+	if v := metadata.MD(m).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (m metadataCarrier) Set(key, value string) { metadata.MD(m).Set(key, value) }
+
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func newRID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func recoverPanic(a *app.App) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				a.Log.Error("panic recovered", "method", info.FullMethod, "panic", v)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+type desiredCountReporter interface {
+	DesiredCount(ctx context.Context) (int32, error)
+}
+
+// Reset wipes the controller state back to its defaults. It refuses while an
+// operation is in flight or while any game service still wants tasks.
+func (c *ControllerService) Reset(ctx context.Context) (State, error) {
+	if !c.opMu.TryLock() {
+		return State{}, domain.ErrAnotherInFlight
+	}
+	defer c.opMu.Unlock()
+
+	// Games may run on an ECS target recorded in the state rather than the
+	// adapter's own, so ask each one about that.
+	previous, _ := c.state.Get(ctx)
+	st := ensureStateMaps(previous)
+	for name, ad := range c.adapters {
+		reporter, ok := ad.(desiredCountReporter)
+		if !ok {
+			continue
+		}
+		desired, err := reporter.DesiredCount(gameECSCtx(ctx, st, name))
+		if err != nil {
+			return State{}, err
+		}
+		if desired > 0 {
+			c.log.Warn("reset refused: game still running", "game", name, "desired_count", desired)
+			return State{}, domain.ErrGameStillRunning
+		}
+	}
+
+	if err := c.state.Set(ctx, defaultState(c.clock.Now())); err != nil {
+		return State{}, err
+	}
+	return previous, nil
+}
+
+// PhaseClear is the state ClearPhase found and the state it left.
+type PhaseClear struct {
+	BeforeGame  domain.GameType `json:"before_game"`
+	BeforePhase string          `json:"before_phase"`
+	ActiveGame  domain.GameType `json:"active_game"`
+	Phase       string          `json:"phase"`
+}
+
+// ClearPhase recovers a state machine wedged in "switching" or "error",
+// e.g. by a crash mid-switch. It asks ECS which games still want tasks and
+// records the result: the active game (or the one other running game, when a
+// switch got as far as starting its target) as running, or no game as
+// stopped. Several running games, or an active game whose desired count
+// can't be read, are refused; stop them first or reset the state. It
+// refuses while an operation is in flight, since that one is not stuck.
+func (c *ControllerService) ClearPhase(ctx context.Context) (PhaseClear, error) {
+	if !c.opMu.TryLock() {
+		return PhaseClear{}, domain.ErrAnotherInFlight
+	}
+	defer c.opMu.Unlock()
+
+	st, _ := c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	res := PhaseClear{BeforeGame: st.ActiveGame, BeforePhase: st.Phase}
+
+	var running []domain.GameType
+	activeKnown := st.ActiveGame == ""
+	for _, name := range slices.Sorted(maps.Keys(c.adapters)) {
+		ad := c.adapters[name]
+		reporter, ok := ad.(desiredCountReporter)
+		if !ok {
+			continue
+		}
+		desired, err := reporter.DesiredCount(gameECSCtx(ctx, st, string(ad.Type())))
+		if err != nil {
+			return PhaseClear{}, fmt.Errorf("read %s desired count: %w", ad.Type(), err)
+		}
+		if ad.Type() == st.ActiveGame {
+			activeKnown = true
+		}
+		if desired > 0 {
+			running = append(running, ad.Type())
+		}
+	}
+	if !activeKnown {
+		return PhaseClear{}, fmt.Errorf("%w: can't read the ECS state of %s; use /v1/admin/reset", domain.ErrNotSupported, st.ActiveGame)
+	}
+
+	switch {
+	case slices.Contains(running, st.ActiveGame):
+		st.Phase = "running"
+	case len(running) == 0:
+		delete(st.ECSTargets, string(st.ActiveGame))
+		st.ActiveGame = ""
+		st.Phase = "stopped"
+	case len(running) == 1:
+		delete(st.ECSTargets, string(st.ActiveGame))
+		st.ActiveGame = running[0]
+		st.Phase = "running"
+	default:
+		names := make([]string, len(running))
+		for i, g := range running {
+			names[i] = string(g)
+		}
+		return PhaseClear{}, fmt.Errorf("%w: %s all want tasks; stop them first", domain.ErrGameStillRunning, strings.Join(names, ", "))
+	}
+	if err := c.state.Set(ctx, st); err != nil {
+		return PhaseClear{}, err
+	}
+	res.ActiveGame, res.Phase = st.ActiveGame, st.Phase
+	return res, nil
+}
+
+// gitTokenSetter is implemented by adapters that clone and push with a git
+// token that can be replaced at runtime.
+type gitTokenSetter interface {
+	SetGitToken(ctx context.Context, token, sourceURL string) error
+}
+
+// RotateGitToken hands game's adapter a new git token, checked against the
+// game's recorded source repo before it replaces the old one.
+func (c *ControllerService) RotateGitToken(ctx context.Context, game, token string) error {
+	ad, ok := c.adapters[game]
+	if !ok {
+		return domain.ErrUnknownGameType
+	}
+	setter, ok := ad.(gitTokenSetter)
+	if !ok {
+		return domain.ErrNotSupported
+	}
+	st, _ := c.state.Get(ctx)
+	sourceURL := st.SourceByGame[game]
+	if sourceURL == "" {
+		return domain.ErrNoSourceForGame
+	}
+	return setter.SetGitToken(ctx, token, sourceURL)
+}
+
+// sourceValidator is implemented by adapters that can check a source URL
+// against its repo without seeding from it.
+type sourceValidator interface {
+	ValidateSource(ctx context.Context, sourceURL string) (domain.SourceCheck, error)
+}
+
+// ValidateSource checks that sourceURL's ref (and path, if any) exist in the
+// repo, as game's adapter would read it; game defaults to the active game.
+// Nothing on disk or in the state changes.
+func (c *ControllerService) ValidateSource(ctx context.Context, game, sourceURL string) (domain.SourceCheck, error) {
+	if game == "" {
+		st, _ := c.state.Get(ctx)
+		if st.ActiveGame == "" {
+			return domain.SourceCheck{}, domain.ErrNoActiveGame
+		}
+		game = string(st.ActiveGame)
+	}
+	ad, ok := c.adapters[game]
+	if !ok {
+		return domain.SourceCheck{}, domain.ErrUnknownGameType
+	}
+	validator, ok := ad.(sourceValidator)
+	if !ok {
+		return domain.SourceCheck{}, domain.ErrNotSupported
+	}
+	return validator.ValidateSource(ctx, sourceURL)
+}
+
+// connectionReporter is implemented by adapters that hold a command
+// connection (RCON) and track its health.
+type connectionReporter interface {
+	ConnectionState() domain.ConnectionState
+}
+
+// ConnectionState reports game's command connection as its adapter has
+// seen it. It makes no connection itself.
+func (c *ControllerService) ConnectionState(ctx context.Context, game string) (domain.ConnectionState, error) {
+	ad, ok := c.adapters[game]
+	if !ok {
+		return domain.ConnectionState{}, domain.ErrUnknownGameType
+	}
+	reporter, ok := ad.(connectionReporter)
+	if !ok {
+		return domain.ConnectionState{}, domain.ErrNotSupported
+	}
+	state := reporter.ConnectionState()
+	st, _ := c.state.Get(ctx)
+	state.Active = st.ActiveGame == ad.Type()
+	return state, nil
+}
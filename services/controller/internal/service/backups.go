@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+type backupTrasher interface {
+	DeleteBackup(ctx context.Context, backupRef string) (trashRef string, err error)
+	RestoreDeletedBackup(ctx context.Context, backupRef string) (string, error)
+}
+
+type backupLister interface {
+	ListBackups(ctx context.Context, stream string) ([]domain.BackupInfo, error)
+}
+
+type backupImporter interface {
+	ImportBackup(ctx context.Context, zipPath string) (string, error)
+}
+
+type backupCopier interface {
+	CopyBackup(ctx context.Context, sourceRef, destBucket, destPrefix string, setLatest bool) (string, error)
+}
+
+type backupOpener interface {
+	OpenBackup(ctx context.Context, backupRef string) (domain.BackupContent, error)
+}
+
+// backupRefParser resolves a backup ref (bare key or s3:// URI) to the
+// bucket and key it names, filling in the adapter's default bucket.
+type backupRefParser interface {
+	ParseBackupRef(backupRef string) (bucket, key string, err error)
+}
+
+// sameBackup reports whether two refs to ad's backups name the same object.
+// Both are resolved by the adapter and compared exactly, so a bare key
+// matches its s3:// URI in the default bucket but nothing in another bucket
+// and no key that merely ends the same way. Adapters that can't resolve refs
+// only match identical keys.
+func sameBackup(ad Adapter, a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == "" || b == "" {
+		return false
+	}
+	parser, ok := ad.(backupRefParser)
+	if !ok {
+		return strings.TrimPrefix(a, "/") == strings.TrimPrefix(b, "/")
+	}
+	aBucket, aKey, err := parser.ParseBackupRef(a)
+	if err != nil {
+		return false
+	}
+	bBucket, bKey, err := parser.ParseBackupRef(b)
+	if err != nil {
+		return false
+	}
+	return aBucket == bBucket && aKey == bKey
+}
+
+type DeleteBackupResult struct {
+	Deleted string `json:"deleted"`
+	Trash   string `json:"trash"`
+}
+
+func (c *ControllerService) ListBackups(ctx context.Context, game string, stream string) ([]domain.BackupInfo, error) {
+	if err := domain.ValidateStream(stream); err != nil {
+		return nil, err
+	}
+
+	ad, ok := c.adapters[game]
+	if !ok {
+		return nil, domain.ErrUnknownGameType
+	}
+	lister, ok := ad.(backupLister)
+	if !ok {
+		return nil, domain.ErrNotSupported
+	}
+	return lister.ListBackups(ctx, stream)
+}
+
+// LatestBackup returns the key a restore would pick for a game's stream. With
+// refresh the adapter re-reads its latest marker rather than its cache.
+func (c *ControllerService) LatestBackup(ctx context.Context, game string, stream string, refresh bool) (string, error) {
+	if err := domain.ValidateStream(stream); err != nil {
+		return "", err
+	}
+	ad, ok := c.adapters[game]
+	if !ok {
+		return "", domain.ErrUnknownGameType
+	}
+	if refresher, ok := ad.(latestBackupRefresher); ok && refresh {
+		return refresher.RefreshLatestBackup(ctx, stream)
+	}
+	if provider, ok := ad.(streamBackupProvider); ok {
+		return provider.LatestBackupStream(ctx, stream)
+	}
+	if provider, ok := ad.(latestBackupProvider); ok && stream == "" {
+		return provider.LatestBackup(ctx)
+	}
+	return "", domain.ErrNotSupported
+}
+
+// OpenBackup opens a game's backup for streaming. It doesn't take the
+// operation lock: downloads are read-only and may be long.
+func (c *ControllerService) OpenBackup(ctx context.Context, game string, backupRef string) (domain.BackupContent, error) {
+	ad, ok := c.adapters[game]
+	if !ok {
+		return domain.BackupContent{}, domain.ErrUnknownGameType
+	}
+	opener, ok := ad.(backupOpener)
+	if !ok {
+		return domain.BackupContent{}, domain.ErrNotSupported
+	}
+	return opener.OpenBackup(ctx, backupRef)
+}
+
+// DeleteBackup soft-deletes a backup. The object is kept under the trash
+// prefix until the bucket lifecycle rule purges it.
+func (c *ControllerService) DeleteBackup(ctx context.Context, game string, backupRef string) (res DeleteBackupResult, err error) {
+	unlock, err := c.lockOp(ctx, domain.EventDeleteBackup)
+	if err != nil {
+		return DeleteBackupResult{}, err
+	}
+	defer unlock()
+
+	ctx = c.beginOp(ctx, domain.EventDeleteBackup, game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventDeleteBackup, Game: game, Backup: backupRef}, &err) }()
+
+	ad, ok := c.adapters[game]
+	if !ok {
+		return DeleteBackupResult{}, domain.ErrUnknownGameType
+	}
+	trasher, ok := ad.(backupTrasher)
+	if !ok {
+		return DeleteBackupResult{}, domain.ErrNotSupported
+	}
+
+	trashRef, err := trasher.DeleteBackup(ctx, backupRef)
+	if err != nil {
+		return DeleteBackupResult{}, err
+	}
+
+	st, _ := c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	if sameBackup(ad, st.LastBackups[game], backupRef) {
+		delete(st.LastBackups, game)
+		_ = c.state.Set(ctx, st)
+	}
+
+	c.log.Info("backup deleted", "game", game, "backup", backupRef, "trash", trashRef)
+	return DeleteBackupResult{Deleted: backupRef, Trash: trashRef}, nil
+}
+
+// RestoreDeletedBackup recovers a soft-deleted backup within its trash window.
+func (c *ControllerService) RestoreDeletedBackup(ctx context.Context, game string, backupRef string) (restored string, err error) {
+	unlock, err := c.lockOp(ctx, domain.EventRestoreDeleted)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	ctx = c.beginOp(ctx, domain.EventRestoreDeleted, game)
+	defer func() {
+		c.emit(ctx, domain.Event{Kind: domain.EventRestoreDeleted, Game: game, Backup: backupRef}, &err)
+	}()
+
+	ad, ok := c.adapters[game]
+	if !ok {
+		return "", domain.ErrUnknownGameType
+	}
+	trasher, ok := ad.(backupTrasher)
+	if !ok {
+		return "", domain.ErrNotSupported
+	}
+
+	restored, err = trasher.RestoreDeletedBackup(ctx, backupRef)
+	if err != nil {
+		return "", err
+	}
+
+	c.log.Info("deleted backup restored", "game", game, "backup", restored)
+	return restored, nil
+}
+
+type AdoptResult struct {
+	Game   string `json:"game"`
+	Backup string `json:"backup"`
+}
+
+// Adopt takes a first backup of a world already on disk (a server migrated
+// into the controller) and records it as the game's latest, so a later Start
+// restores it instead of finding nothing.
+func (c *ControllerService) Adopt(ctx context.Context, game string) (res AdoptResult, err error) {
+	unlock, err := c.lockOp(ctx, domain.EventAdopt)
+	if err != nil {
+		return AdoptResult{}, err
+	}
+	defer unlock()
+	ctx = c.beginOp(ctx, domain.EventAdopt, game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventAdopt, Game: game, Backup: res.Backup}, &err) }()
+
+	ad, ok := c.adapters[game]
+	if !ok {
+		return AdoptResult{}, domain.ErrUnknownGameType
+	}
+	st, _ := c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	if st.ActiveGame == ad.Type() {
+		return AdoptResult{}, domain.ErrGameActive
+	}
+
+	backupKey, err := backupKeyOf(backup(ctx, ad, ""))
+	if err != nil {
+		return AdoptResult{}, err
+	}
+	st.LastBackups[game] = backupKey
+	_ = c.state.Set(ctx, st)
+
+	c.log.Info("adopt complete", "game", game, "backup", backupKey)
+	return AdoptResult{Game: game, Backup: backupKey}, nil
+}
+
+// ImportBackup registers an uploaded zip as the game's latest backup. It
+// fails fast with ErrAnotherInFlight rather than queueing a large upload
+// behind a running operation.
+func (c *ControllerService) ImportBackup(ctx context.Context, game, zipPath string) (backupKey string, err error) {
+	if !c.opMu.TryLock() {
+		return "", domain.ErrAnotherInFlight
+	}
+	defer c.opMu.Unlock()
+	ctx = c.beginOp(ctx, domain.EventImport, game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventImport, Game: game, Backup: backupKey}, &err) }()
+
+	ad, ok := c.adapters[game]
+	if !ok {
+		return "", domain.ErrUnknownGameType
+	}
+	importer, ok := ad.(backupImporter)
+	if !ok {
+		return "", domain.ErrNotSupported
+	}
+	backupKey, err = importer.ImportBackup(ctx, zipPath)
+	if err != nil {
+		return "", err
+	}
+
+	st, _ := c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	st.LastBackups[game] = backupKey
+	_ = c.state.Set(ctx, st)
+
+	c.log.Info("backup import complete", "game", game, "backup", backupKey)
+	return backupKey, nil
+}
+
+type BackupCopyRequest struct {
+	Game       string
+	Source     string // backup key or s3:// URI
+	DestBucket string // empty copies within the game's bucket
+	DestPrefix string // empty keeps the source's prefix
+	SetLatest  bool   // point the destination's latest marker at the copy
+}
+
+type BackupCopyResult struct {
+	Game      string `json:"game"`
+	Source    string `json:"source"`
+	Backup    string `json:"backup"`
+	SetLatest bool   `json:"set_latest"`
+}
+
+// CopyBackup server-side copies a backup between buckets for promoting worlds
+// between environments. Like ImportBackup it fails fast when another
+// operation is running.
+func (c *ControllerService) CopyBackup(ctx context.Context, req BackupCopyRequest) (res BackupCopyResult, err error) {
+	if !c.opMu.TryLock() {
+		return BackupCopyResult{}, domain.ErrAnotherInFlight
+	}
+	defer c.opMu.Unlock()
+	ctx = c.beginOp(ctx, domain.EventCopy, req.Game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventCopy, Game: req.Game, Backup: res.Backup}, &err) }()
+
+	ad, ok := c.adapters[req.Game]
+	if !ok {
+		return BackupCopyResult{}, domain.ErrUnknownGameType
+	}
+	copier, ok := ad.(backupCopier)
+	if !ok {
+		return BackupCopyResult{}, domain.ErrNotSupported
+	}
+	backupKey, err := copier.CopyBackup(ctx, req.Source, req.DestBucket, req.DestPrefix, req.SetLatest)
+	if err != nil {
+		return BackupCopyResult{}, err
+	}
+
+	if req.SetLatest {
+		// The copy may have replaced this game's own latest marker; drop the
+		// cached key so the next start or restore re-reads it.
+		st, _ := c.state.Get(ctx)
+		st = ensureStateMaps(st)
+		delete(st.LastBackups, req.Game)
+		_ = c.state.Set(ctx, st)
+	}
+
+	c.log.Info("backup copy complete", "game", req.Game, "source", req.Source, "backup", backupKey)
+	return BackupCopyResult{Game: req.Game, Source: req.Source, Backup: backupKey, SetLatest: req.SetLatest}, nil
+}
+
+// WithRetention sets the backup retention policy previewed by
+// PreviewRetention.
+func WithRetention(policy domain.RetentionPolicy) Option {
+	return func(c *ControllerService) { c.retention = policy }
+}
+
+// WithPreRestoreRetention sets the retention policy of the pre-restore
+// stream, which fills up with a snapshot per restore and is usually kept
+// for much less time than regular backups.
+func WithPreRestoreRetention(policy domain.RetentionPolicy) Option {
+	return func(c *ControllerService) { c.preRestoreRetention = policy }
+}
+
+// retentionFor returns the retention policy that applies to stream.
+func (c *ControllerService) retentionFor(stream string) domain.RetentionPolicy {
+	if stream == domain.PreRestoreStream {
+		return c.preRestoreRetention
+	}
+	return c.retention
+}
+
+// RetentionPreview is what the retention policy would do to a stream now.
+type RetentionPreview struct {
+	Game       string                     `json:"game"`
+	Stream     string                     `json:"stream"`
+	KeepLast   int                        `json:"keep_last"`
+	MaxAge     string                     `json:"max_age,omitempty"`
+	Latest     string                     `json:"latest,omitempty"`
+	Keep       []domain.RetentionDecision `json:"keep"`
+	Prune      []domain.RetentionDecision `json:"prune"`
+	PruneBytes int64                      `json:"prune_bytes"`
+
+	// Token confirms exactly this prune set to ApplyRetention until
+	// TokenExpiresAt.
+	Token          string    `json:"token"`
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+}
+
+// PreviewRetention lists a stream's backups and applies the retention policy
+// to them without deleting anything.
+func (c *ControllerService) PreviewRetention(ctx context.Context, game string, stream string) (RetentionPreview, error) {
+	backups, err := c.ListBackups(ctx, game, stream)
+	if err != nil {
+		return RetentionPreview{}, err
+	}
+	// A missing marker just means nothing is pinned as latest.
+	latest, _ := c.LatestBackup(ctx, game, stream, false)
+
+	policy := c.retentionFor(stream)
+	now := c.clock.Now()
+	keep, prune := policy.Plan(backups, latest, now)
+	preview := RetentionPreview{
+		Game:     game,
+		Stream:   stream,
+		KeepLast: policy.KeepLast,
+		Latest:   latest,
+		Keep:     keep,
+		Prune:    prune,
+	}
+	if policy.MaxAge > 0 {
+		preview.MaxAge = policy.MaxAge.String()
+	}
+	if preview.Keep == nil {
+		preview.Keep = []domain.RetentionDecision{}
+	}
+	if preview.Prune == nil {
+		preview.Prune = []domain.RetentionDecision{}
+	}
+	for _, d := range prune {
+		preview.PruneBytes += d.Size
+	}
+	preview.TokenExpiresAt = now.Add(retentionTokenTTL).UTC().Truncate(time.Second)
+	preview.Token = retentionToken(game, stream, preview.TokenExpiresAt, prune)
+	return preview, nil
+}
@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"maps"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/esuEdu/game-infra/controller/internal/domain"
 )
@@ -15,119 +18,370 @@ type latestBackupProvider interface {
 	LatestBackup(ctx context.Context) (string, error)
 }
 
+// latestBackupRefresher re-reads the latest backup from storage instead of
+// trusting a cached key.
+type latestBackupRefresher interface {
+	RefreshLatestBackup(ctx context.Context, stream string) (string, error)
+}
+
+type streamBackupProvider interface {
+	BackupStream(ctx context.Context, stream string) (string, error)
+	LatestBackupStream(ctx context.Context, stream string) (string, error)
+}
+
+type scaledStarter interface {
+	StartScaled(ctx context.Context, count int32) error
+}
+
+// preflighter checks an adapter's external dependencies before Start changes
+// any state (restore, scaling).
+type preflighter interface {
+	Preflight(ctx context.Context) error
+}
+
+// clockSetter is implemented by adapters and state stores that stamp times,
+// so WithClock reaches them too.
+type clockSetter interface {
+	SetClock(clock domain.Clock)
+}
+
+type StartRequest struct {
+	Game         string
+	DataURL      string
+	Stream       string // backup stream to restore from when no data_url is given
+	DesiredCount int32  // 0 uses the adapter's configured count
+	Force        bool   // skip data dir safety checks on seed/restore
+	DryRun       bool   // resolve the plan only; nothing is stopped, restored or started
+	// AllowCrossGame restores a backup whose key belongs to another game.
+	AllowCrossGame bool
+	// ECSTarget overrides the adapter's cluster/service for this game; it
+	// must be on the WithECSTargets allowlist.
+	ECSTarget domain.ECSTarget
+}
+
 type StartResult struct {
 	Started string `json:"started"`
 	Source  string `json:"source"` // data_url | backup
 	Backup  string `json:"backup,omitempty"`
+	Stream  string `json:"stream,omitempty"`
 	DataURL string `json:"data_url,omitempty"`
+	Commit  string `json:"commit,omitempty"` // source commit seeded from
+
+	DesiredCount int32 `json:"desired_count,omitempty"`
+
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+	// Timings breaks DurationMS down by phase: replace_ms (stopping the
+	// previous game), seed_ms or restore_ms, ecs_wait_ms and total_ms.
+	// readiness_ms is added to the state's last_start once a probe first
+	// sees the game listening.
+	Timings map[string]int64 `json:"timings,omitempty"`
+
+	// Replaced is the game that was active before: Start stops and backs it
+	// up, then syncs it to ReplacedSource when one is recorded.
+	Replaced       string `json:"replaced,omitempty"`
+	ReplacedSource string `json:"replaced_source,omitempty"`
+	DryRun         bool   `json:"dry_run,omitempty"`
+}
+
+// Stop backup orderings. Backing up first captures the world while the server
+// is still healthy and leaves it running if the backup fails; some games can
+// only be backed up once stopped, hence the after default.
+const (
+	BackupAfterStop  = "after"
+	BackupBeforeStop = "before"
+)
+
+// ValidBackupOrder reports whether order is a known ordering ("" means the
+// configured default).
+func ValidBackupOrder(order string) bool {
+	return order == "" || order == BackupAfterStop || order == BackupBeforeStop
+}
+
+type StopRequest struct {
+	BackupOrder string // before | after; "" uses the configured default
+	// ECSTarget overrides where the game is scaled down; zero uses the
+	// target it was started on.
+	ECSTarget domain.ECSTarget
 }
 
 type StopResult struct {
+	Game    string `json:"game"`
 	Stopped bool   `json:"stopped"`
 	Backup  string `json:"backup"`
 	Synced  bool   `json:"synced"`
 	DataURL string `json:"data_url,omitempty"`
+	Commit  string `json:"commit,omitempty"` // source commit after the sync
+
+	BackupOrder string `json:"backup_order"`
+
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
 }
 
 type ControllerService struct {
 	log      *slog.Logger
 	state    StateStore
 	adapters map[string]Adapter
+	history  *commandHistory
+	sinks    []*queuedSink
+
+	stopBackupOrder     string
+	clock               domain.Clock
+	ops                 *operationLog
+	health              healthErrors
+	opLimits            map[domain.EventKind]chan struct{}
+	ecsTargets          []domain.ECSTarget
+	retention           domain.RetentionPolicy
+	preRestoreRetention domain.RetentionPolicy
+	switchCooldown      time.Duration
+	statusCache         statusCache
+	unsafeHotRestore    bool
+	backupPrefix        string
+
+	// opMu serialises operations, granting them in arrival order.
+	opMu opQueue
+}
+
+// Option customises a ControllerService at construction.
+type Option func(*ControllerService)
+
+// WithCommandHistory keeps the last size commands; 0 disables the history.
+func WithCommandHistory(size int) Option {
+	return func(c *ControllerService) { c.history = newCommandHistory(size) }
+}
+
+// WithClock replaces the real clock, for the service and for every adapter
+// and state store that accepts one.
+func WithClock(clock domain.Clock) Option {
+	return func(c *ControllerService) { c.clock = clock }
+}
+
+// WithOperationLimits caps how many operations of each kind may be running
+// or waiting for the lock at once; kinds not listed are only serialised.
+func WithOperationLimits(limits map[domain.EventKind]int) Option {
+	return func(c *ControllerService) {
+		c.opLimits = map[domain.EventKind]chan struct{}{}
+		for kind, n := range limits {
+			c.opLimits[kind] = make(chan struct{}, n)
+		}
+	}
+}
 
-	opMu sync.Mutex
+// WithSwitchCooldown makes Switch wait d after stopping and backing up the
+// previous game before it starts the next, for games whose ports or
+// licences take a while to be released. 0 (the default) doesn't wait.
+func WithSwitchCooldown(d time.Duration) Option {
+	return func(c *ControllerService) { c.switchCooldown = max(d, 0) }
+}
+
+// WithStopBackupOrder sets the default backup ordering for Stop.
+func WithStopBackupOrder(order string) Option {
+	return func(c *ControllerService) {
+		if order != "" {
+			c.stopBackupOrder = order
+		}
+	}
 }
 
-func NewControllerService(log *slog.Logger, state StateStore, adapters map[string]Adapter) *ControllerService {
-	return &ControllerService{
+const defaultCommandHistory = 50
+
+func NewControllerService(log *slog.Logger, state StateStore, adapters map[string]Adapter, opts ...Option) *ControllerService {
+	c := &ControllerService{
 		log:      log,
 		state:    state,
 		adapters: adapters,
+		history:  newCommandHistory(defaultCommandHistory),
+
+		stopBackupOrder: BackupAfterStop,
+		clock:           domain.SystemClock{},
+		ops:             newOperationLog(defaultOperationHistory),
+		health:          healthErrors{last: map[string]healthFailure{}},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if cs, ok := state.(clockSetter); ok {
+		cs.SetClock(c.clock)
 	}
+	for _, ad := range adapters {
+		if cs, ok := ad.(clockSetter); ok {
+			cs.SetClock(c.clock)
+		}
+	}
+	return c
 }
 
-func (c *ControllerService) Start(ctx context.Context, game string, dataURL string) (StartResult, error) {
-	c.opMu.Lock()
-	defer c.opMu.Unlock()
+func (c *ControllerService) Start(ctx context.Context, req StartRequest) (res StartResult, err error) {
+	if req.DryRun {
+		// A preview shouldn't queue behind (or hold up) a real operation.
+		if !c.opMu.TryLock() {
+			return StartResult{}, domain.ErrAnotherInFlight
+		}
+		defer c.opMu.Unlock()
+	} else {
+		// Assign, don't declare: the deferred emit must see the named err.
+		var unlock func()
+		if unlock, err = c.lockOp(ctx, domain.EventStart); err != nil {
+			return StartResult{}, err
+		}
+		defer unlock()
+		ctx = c.beginOp(ctx, domain.EventStart, req.Game)
+		defer func() {
+			c.emit(ctx, domain.Event{Kind: domain.EventStart, Game: req.Game, Backup: res.Backup, Commit: res.Commit}, &err)
+		}()
+	}
+
+	begin := c.clock.Now()
+	game := req.Game
+	if err := domain.ValidateStream(req.Stream); err != nil {
+		return StartResult{}, err
+	}
+	if err := c.checkECSTarget(req.ECSTarget); err != nil {
+		return StartResult{}, err
+	}
+	targetCtx := domain.WithECSTarget(ctx, req.ECSTarget)
 
 	ad, ok := c.adapters[game]
 	if !ok {
 		return StartResult{}, domain.ErrUnknownGameType
 	}
+	var scaler scaledStarter
+	if req.DesiredCount != 0 {
+		if scaler, ok = ad.(scaledStarter); !ok {
+			return StartResult{}, domain.ErrNotSupported
+		}
+	}
+	if p, ok := ad.(preflighter); ok {
+		if err := p.Preflight(targetCtx); err != nil {
+			return StartResult{}, err
+		}
+		targetCtx = domain.WithPreflighted(targetCtx)
+	}
 
 	st, _ := c.state.Get(ctx)
 	st = ensureStateMaps(st)
 
+	result, err := c.planStart(ctx, ad, req, st)
+	if err == nil && result.Source == "backup" {
+		err = checkBackupGame(c.backupPrefix, game, result.Backup, req.AllowCrossGame)
+	}
+	if err != nil || req.DryRun {
+		return result, err
+	}
+
+	timings := map[string]int64{}
+	phase := func(name string, since time.Time) {
+		timings[name] = c.clock.Now().Sub(since).Milliseconds()
+	}
+
 	// If another game is active, stop it, backup it, and sync to existing source.
-	if st.ActiveGame != "" && st.ActiveGame != ad.Type() {
+	if result.Replaced != "" {
+		replaceBegin := c.clock.Now()
 		previous, err := c.adapterByType(st.ActiveGame)
 		if err != nil {
 			return StartResult{}, err
 		}
-		if err := previous.Stop(ctx); err != nil {
+		if err := previous.Stop(gameECSCtx(ctx, st, result.Replaced)); err != nil {
 			return StartResult{}, err
 		}
-		backupKey, err := previous.Backup(ctx)
+		// Record the scale-down before anything else can fail or be
+		// cancelled. The game stays active so a retry still backs it up.
+		st.Phase = "stopped"
+		_ = c.state.Set(ctx, st)
+		report, err := backup(ctx, previous, "")
 		if err != nil {
 			return StartResult{}, err
 		}
-		st.LastBackups[string(st.ActiveGame)] = backupKey
+		st.LastBackups[result.Replaced] = report.Backup
 
-		if sourceURL := st.SourceByGame[string(st.ActiveGame)]; sourceURL != "" {
-			if err := previous.SyncToSource(ctx, sourceURL); err != nil {
+		if result.ReplacedSource != "" {
+			report, err := syncSource(ctx, previous, result.ReplacedSource)
+			if err != nil {
 				return StartResult{}, err
 			}
+			recordSourceCommit(st, result.Replaced, report.Commit)
 		}
+		phase("replace_ms", replaceBegin)
 	}
 
-	result := StartResult{
-		Started: game,
-	}
-
-	dataURL = strings.TrimSpace(dataURL)
-	if dataURL != "" {
-		if err := ad.SeedFromSource(ctx, dataURL); err != nil {
+	prepareBegin := c.clock.Now()
+	if result.Source == "data_url" {
+		report, err := seed(targetCtx, ad, result.DataURL, domain.SeedOptions{Force: req.Force})
+		if err != nil {
 			return StartResult{}, err
 		}
-		st.SourceByGame[game] = dataURL
-		result.Source = "data_url"
-		result.DataURL = dataURL
+		st.SourceByGame[game] = result.DataURL
+		recordSourceCommit(st, game, report.Commit)
+		result.Commit = report.Commit
+		phase("seed_ms", prepareBegin)
 	} else {
-		backupKey, ok := st.LastBackups[game]
-		if !ok || strings.TrimSpace(backupKey) == "" {
-			provider, hasProvider := ad.(latestBackupProvider)
-			if !hasProvider {
-				return StartResult{}, domain.ErrNoBackupForGame
-			}
-			var err error
-			backupKey, err = provider.LatestBackup(ctx)
-			if err != nil || strings.TrimSpace(backupKey) == "" {
-				return StartResult{}, domain.ErrNoBackupForGame
-			}
-			st.LastBackups[game] = backupKey
-		}
-		if err := ad.Restore(ctx, backupKey); err != nil {
+		if err := restore(targetCtx, ad, result.Backup, domain.RestoreOptions{Force: req.Force}); err != nil {
 			return StartResult{}, err
 		}
-		result.Source = "backup"
-		result.Backup = backupKey
+		st.LastBackups[game] = result.Backup
+		phase("restore_ms", prepareBegin)
 	}
 
-	if err := ad.Start(ctx); err != nil {
-		return StartResult{}, err
+	// Only the game's own start feeds the recorded phases (ecs_wait_ms); the
+	// previous game's scale-down is part of replace_ms.
+	startCtx, recorded := domain.WithTimings(targetCtx)
+	var startErr error
+	if scaler != nil {
+		startErr = scaler.StartScaled(startCtx, req.DesiredCount)
+	} else {
+		startErr = ad.Start(startCtx)
 	}
+	if startErr != nil {
+		if cancelled(ctx) {
+			// ECS may already be bringing the game up. Point the state at it,
+			// not running, so a Stop can scale it back down.
+			st.ActiveGame = ad.Type()
+			st.Phase = "error"
+			recordECSTarget(st, game, req.ECSTarget)
+			_ = c.state.Set(ctx, st)
+		}
+		return StartResult{}, startErr
+	}
+
+	result.FinishedAt = c.clock.Now().UTC()
+	result.DurationMS = result.FinishedAt.Sub(begin).Milliseconds()
+	for name, ms := range recorded.Map() {
+		timings[name] = ms
+	}
+	timings["total_ms"] = result.DurationMS
+	result.Timings = timings
 
 	st.ActiveGame = ad.Type()
 	st.Phase = "running"
+	st.LastStart = &result
+	recordECSTarget(st, game, req.ECSTarget)
 	_ = c.state.Set(ctx, st)
 	return result, nil
 }
 
-func (c *ControllerService) Stop(ctx context.Context) (StopResult, error) {
-	c.opMu.Lock()
-	defer c.opMu.Unlock()
+func (c *ControllerService) Stop(ctx context.Context, req StopRequest) (res StopResult, err error) {
+	order := req.BackupOrder
+	if order == "" {
+		order = c.stopBackupOrder
+	}
+	if !ValidBackupOrder(order) {
+		return StopResult{}, fmt.Errorf("%w: unknown backup order %q", domain.ErrBadState, order)
+	}
+
+	unlock, err := c.lockOp(ctx, domain.EventStop)
+	if err != nil {
+		return StopResult{}, err
+	}
+	defer unlock()
 
 	st, _ := c.state.Get(ctx)
 	st = ensureStateMaps(st)
+	stopping := string(st.ActiveGame)
+	ctx = c.beginOp(ctx, domain.EventStop, stopping)
+	defer func() {
+		c.emit(ctx, domain.Event{Kind: domain.EventStop, Game: stopping, Backup: res.Backup, Commit: res.Commit}, &err)
+	}()
 	if st.ActiveGame == "" {
 		return StopResult{}, domain.ErrNoActiveGame
 	}
@@ -136,59 +390,130 @@ func (c *ControllerService) Stop(ctx context.Context) (StopResult, error) {
 	if err != nil {
 		return StopResult{}, err
 	}
-
-	if err := ad.Stop(ctx); err != nil {
+	if err := c.checkECSTarget(req.ECSTarget); err != nil {
 		return StopResult{}, err
 	}
+	if req.ECSTarget.IsZero() {
+		ctx = gameECSCtx(ctx, st, stopping)
+	} else {
+		ctx = domain.WithECSTarget(ctx, req.ECSTarget)
+	}
+	return c.stopGame(ctx, ad, stopping, order)
+}
 
-	backupKey, err := ad.Backup(ctx)
-	if err != nil {
-		return StopResult{}, err
+// stopGame scales game down, backs it up in the given order and syncs it to
+// its recorded source. The caller holds the operation lock. When game is the
+// active one the state is cleared; any other game only has its backup,
+// source commit and ECS target updated.
+func (c *ControllerService) stopGame(ctx context.Context, ad Adapter, game, order string) (StopResult, error) {
+	begin := c.clock.Now()
+	st, _ := c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	active := string(st.ActiveGame) == game
+
+	var (
+		backupKey string
+		err       error
+	)
+	if order == BackupBeforeStop {
+		// A failed backup leaves the server up rather than scaling down data
+		// we could not capture.
+		if backupKey, err = backupKeyOf(backup(ctx, ad, "")); err != nil {
+			return StopResult{}, fmt.Errorf("backup before stop (server left running): %w", err)
+		}
+		if err := ad.Stop(ctx); err != nil {
+			return StopResult{}, err
+		}
+	} else {
+		if err := ad.Stop(ctx); err != nil {
+			return StopResult{}, err
+		}
+		// Record the scale-down first so a failed or cancelled backup doesn't
+		// leave the state claiming the game is running.
+		if active {
+			st.Phase = "stopped"
+			_ = c.state.Set(ctx, st)
+		}
+		if backupKey, err = backupKeyOf(backup(ctx, ad, "")); err != nil {
+			return StopResult{}, err
+		}
 	}
 
-	gameKey := string(st.ActiveGame)
-	st.LastBackups[gameKey] = backupKey
+	st.LastBackups[game] = backupKey
+	if active {
+		st.Phase = "stopped"
+	}
+	_ = c.state.Set(ctx, st)
 
 	result := StopResult{
+		Game:    game,
 		Stopped: true,
 		Backup:  backupKey,
 		Synced:  false,
+
+		BackupOrder: order,
 	}
 
-	if sourceURL := st.SourceByGame[gameKey]; sourceURL != "" {
-		if err := ad.SyncToSource(ctx, sourceURL); err != nil {
+	if sourceURL := st.SourceByGame[game]; sourceURL != "" {
+		report, err := syncSource(ctx, ad, sourceURL)
+		if err != nil {
 			return StopResult{}, err
 		}
+		recordSourceCommit(st, game, report.Commit)
 		result.Synced = true
 		result.DataURL = sourceURL
+		result.Commit = report.Commit
 	}
 
-	st.ActiveGame = ""
-	st.Phase = "stopped"
+	result.FinishedAt = c.clock.Now().UTC()
+	result.DurationMS = result.FinishedAt.Sub(begin).Milliseconds()
+
+	if active {
+		st.ActiveGame = ""
+		st.Phase = "stopped"
+		st.LastStop = &result
+		c.history.reset()
+	}
+	delete(st.ECSTargets, game)
 	_ = c.state.Set(ctx, st)
 	return result, nil
 }
 
-func (c *ControllerService) Switch(ctx context.Context, game string) error {
-	c.opMu.Lock()
-	defer c.opMu.Unlock()
+// Switch stops the active game and starts game, on target when it is not
+// zero (see StartRequest.ECSTarget).
+func (c *ControllerService) Switch(ctx context.Context, game string, target domain.ECSTarget) (err error) {
+	unlock, err := c.lockOp(ctx, domain.EventSwitch)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	ctx = c.beginOp(ctx, domain.EventSwitch, game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventSwitch, Game: game}, &err) }()
 
-	target, ok := c.adapters[game]
+	to, ok := c.adapters[game]
 	if !ok {
 		return domain.ErrUnknownGameType
 	}
+	if err := c.checkECSTarget(target); err != nil {
+		return err
+	}
 
 	st, _ := c.state.Get(ctx)
 	st = ensureStateMaps(st)
-	if st.ActiveGame == target.Type() {
+	if st.ActiveGame == to.Type() {
 		return nil
 	}
 
 	st.Phase = "switching"
 	_ = c.state.Set(ctx, st)
 
-	backupKey, err := c.switchWorkflow(ctx, st.ActiveGame, target)
+	backupKey, err := c.switchWorkflow(ctx, st.ActiveGame, st.ECSTargets[string(st.ActiveGame)], to, target)
 	if err != nil {
+		// A failed start (or a cancelled cooldown) still leaves the previous
+		// game's backup behind.
+		if st.ActiveGame != "" && strings.TrimSpace(backupKey) != "" {
+			st.LastBackups[string(st.ActiveGame)] = backupKey
+		}
 		st.Phase = "error"
 		_ = c.state.Set(ctx, st)
 		return err
@@ -198,43 +523,152 @@ func (c *ControllerService) Switch(ctx context.Context, game string) error {
 		st.LastBackups[string(st.ActiveGame)] = backupKey
 	}
 
-	c.log.Info("switch complete", "from", st.ActiveGame, "to", target.Type(), "backup", backupKey)
-	st.ActiveGame = target.Type()
+	c.log.Info("switch complete", "from", st.ActiveGame, "to", to.Type(), "backup", backupKey)
+	delete(st.ECSTargets, string(st.ActiveGame))
+	recordECSTarget(st, game, target)
+	st.ActiveGame = to.Type()
 	st.Phase = "running"
 	_ = c.state.Set(ctx, st)
 	return nil
 }
 
-func (c *ControllerService) Backup(ctx context.Context) (string, error) {
-	c.opMu.Lock()
-	defer c.opMu.Unlock()
+func (c *ControllerService) Backup(ctx context.Context, stream string) (string, error) {
+	return backupKeyOf(c.BackupReport(ctx, stream))
+}
+
+// BackupReport backs up the active game into stream ("" for the default)
+// and reports how many files and bytes the backup captured.
+func (c *ControllerService) BackupReport(ctx context.Context, stream string) (report domain.BackupReport, err error) {
+	unlock, err := c.lockOp(ctx, domain.EventBackup)
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+	defer unlock()
+
+	if err := domain.ValidateStream(stream); err != nil {
+		return domain.BackupReport{}, err
+	}
 
 	st, _ := c.state.Get(ctx)
+	ctx = c.beginOp(ctx, domain.EventBackup, string(st.ActiveGame))
+	defer func() {
+		c.emit(ctx, domain.Event{Kind: domain.EventBackup, Game: string(st.ActiveGame), Backup: report.Backup}, &err)
+	}()
 	if st.ActiveGame == "" {
-		return "", domain.ErrNoActiveGame
+		return domain.BackupReport{}, domain.ErrNoActiveGame
 	}
 
 	ad, err := c.adapterByType(st.ActiveGame)
 	if err != nil {
-		return "", err
+		return domain.BackupReport{}, err
 	}
-	return ad.Backup(ctx)
+	return backup(ctx, ad, stream)
 }
 
-func (c *ControllerService) Command(ctx context.Context, cmd string) error {
-	c.opMu.Lock()
-	defer c.opMu.Unlock()
+func backupKeyOf(report domain.BackupReport, err error) (string, error) {
+	return report.Backup, err
+}
+
+type commandOutputter interface {
+	SendCommandOutput(ctx context.Context, command string) (string, error)
+}
+
+type CommandRequest struct {
+	Command  string
+	RemoteIP string // recorded in the command history
+}
+
+type CommandResult struct {
+	Sent   bool   `json:"sent"`
+	Output string `json:"output,omitempty"`
+}
+
+func (c *ControllerService) Command(ctx context.Context, req CommandRequest) (res CommandResult, err error) {
+	unlock, err := c.lockOp(ctx, domain.EventCommand)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer unlock()
 
 	st, _ := c.state.Get(ctx)
+	game := string(st.ActiveGame)
+	ctx = c.beginOp(ctx, domain.EventCommand, game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventCommand, Game: game}, &err) }()
 	if st.ActiveGame == "" {
-		return domain.ErrNoActiveGame
+		return CommandResult{}, domain.ErrNoActiveGame
 	}
 
 	ad, err := c.adapterByType(st.ActiveGame)
 	if err != nil {
-		return err
+		return CommandResult{}, err
+	}
+
+	var output string
+	if outputter, ok := ad.(commandOutputter); ok {
+		output, err = outputter.SendCommandOutput(ctx, req.Command)
+	} else {
+		err = ad.SendCommand(ctx, req.Command)
+	}
+
+	rec := CommandRecord{
+		At:       c.clock.Now().UTC(),
+		Game:     string(st.ActiveGame),
+		Command:  req.Command,
+		Output:   output,
+		RemoteIP: req.RemoteIP,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	c.history.add(rec)
+
+	if err != nil {
+		return CommandResult{}, err
+	}
+	return CommandResult{Sent: true, Output: output}, nil
+}
+
+// CommandHistory returns the most recent commands, newest first. The history
+// is cleared when the game is stopped.
+func (c *ControllerService) CommandHistory() []CommandRecord {
+	return c.history.list()
+}
+
+type redeployer interface {
+	Redeploy(ctx context.Context) (map[string]any, error)
+}
+
+// Redeploy rolls the active game's tasks in place (new image/config) without
+// going through stop/start.
+func (c *ControllerService) Redeploy(ctx context.Context, game string) (out map[string]any, err error) {
+	unlock, err := c.lockOp(ctx, domain.EventRedeploy)
+	if err != nil {
+		return nil, err
 	}
-	return ad.SendCommand(ctx, cmd)
+	defer unlock()
+
+	ctx = c.beginOp(ctx, domain.EventRedeploy, game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventRedeploy, Game: game}, &err) }()
+
+	ad, ok := c.adapters[game]
+	if !ok {
+		return nil, domain.ErrUnknownGameType
+	}
+	st, _ := c.state.Get(ctx)
+	if st.ActiveGame != ad.Type() {
+		return nil, domain.ErrGameNotActive
+	}
+	r, ok := ad.(redeployer)
+	if !ok {
+		return nil, domain.ErrNotSupported
+	}
+
+	out, err = r.Redeploy(gameECSCtx(ctx, ensureStateMaps(st), game))
+	if err != nil {
+		return nil, err
+	}
+	c.log.Info("redeploy complete", "game", game)
+	return out, nil
 }
 
 func (c *ControllerService) Status(ctx context.Context) (map[string]any, error) {
@@ -242,26 +676,83 @@ func (c *ControllerService) Status(ctx context.Context) (map[string]any, error)
 	st = ensureStateMaps(st)
 
 	out := map[string]any{
-		"active_game":    st.ActiveGame,
-		"phase":          st.Phase,
-		"last_backups":   st.LastBackups,
-		"source_by_game": st.SourceByGame,
-		"updated_at":     st.UpdatedAt,
+		"active_game":        st.ActiveGame,
+		"phase":              st.Phase,
+		"last_backups":       st.LastBackups,
+		"source_by_game":     st.SourceByGame,
+		"source_sha_by_game": st.SourceShaByGame,
+		"ecs_targets":        st.ECSTargets,
+		"last_start":         st.LastStart,
+		"last_stop":          st.LastStop,
+		"updated_at":         st.UpdatedAt,
+		"operation_queue":    c.opMu.snapshot(),
 	}
 
+	// degraded is set when any dependency lookup failed; the rest of the
+	// status is still returned.
+	degraded := false
 	if st.ActiveGame != "" {
-		ad, err := c.adapterByType(st.ActiveGame)
-		if err == nil {
-			adSt, err2 := ad.Status(ctx)
-			if err2 == nil {
-				out["game_status"] = adSt
+		live := c.liveStatus(ctx, st)
+		if live.hasAdapter {
+			if live.gameErr == nil {
+				// A copy, since the cache serves the same map to other callers.
+				out["game_status"] = maps.Clone(live.gameStatus)
+				if d, _ := live.gameStatus["degraded"].(bool); d {
+					degraded = true
+				}
+			} else {
+				out["game_status"] = map[string]any{"unavailable": true, "error": live.gameErr.Error()}
+				degraded = true
+			}
+		}
+		if probe := live.probe; probe != nil {
+			out["game_probe"] = probe
+			if probe.Listening {
+				c.recordReadiness(ctx)
 			}
 		}
 	}
+	out["degraded"] = degraded
 
 	return out, nil
 }
 
+// WaitForChange blocks until the state has been updated after since or ctx is
+// done. It reports whether a change was observed. Stores that don't implement
+// StateNotifier are polled once per second.
+func (c *ControllerService) WaitForChange(ctx context.Context, since time.Time) (bool, error) {
+	notifier, canNotify := c.state.(StateNotifier)
+
+	var poll <-chan time.Time
+	if !canNotify {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		var changed <-chan struct{}
+		if canNotify {
+			changed = notifier.Changes()
+		}
+
+		st, err := c.state.Get(ctx)
+		if err != nil {
+			return false, err
+		}
+		if st.UpdatedAt.After(since) {
+			return true, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case <-changed:
+		case <-poll:
+		}
+	}
+}
+
 func (c *ControllerService) adapterByType(t domain.GameType) (Adapter, error) {
 	for _, ad := range c.adapters {
 		if ad.Type() == t {
@@ -271,6 +762,58 @@ func (c *ControllerService) adapterByType(t domain.GameType) (Adapter, error) {
 	return nil, domain.ErrUnknownGameType
 }
 
+// planStart decides what Start will do for req without side effects: which
+// game it displaces and where the world comes from (the data_url, the
+// stream's latest backup, or the game's last known backup). Dry runs return
+// it as is.
+func (c *ControllerService) planStart(ctx context.Context, ad Adapter, req StartRequest, st State) (StartResult, error) {
+	result := StartResult{
+		Started:      req.Game,
+		DesiredCount: req.DesiredCount,
+		DryRun:       req.DryRun,
+	}
+	if st.ActiveGame != "" && st.ActiveGame != ad.Type() {
+		result.Replaced = string(st.ActiveGame)
+		result.ReplacedSource = st.SourceByGame[result.Replaced]
+	}
+
+	if dataURL := strings.TrimSpace(req.DataURL); dataURL != "" {
+		result.Source = "data_url"
+		result.DataURL = dataURL
+		return result, nil
+	}
+
+	result.Source = "backup"
+	if req.Stream != "" {
+		provider, ok := ad.(streamBackupProvider)
+		if !ok {
+			return StartResult{}, domain.ErrNotSupported
+		}
+		backupKey, err := provider.LatestBackupStream(ctx, req.Stream)
+		if err != nil || strings.TrimSpace(backupKey) == "" {
+			return StartResult{}, domain.ErrNoBackupForGame
+		}
+		result.Backup = backupKey
+		result.Stream = req.Stream
+		return result, nil
+	}
+
+	backupKey := st.LastBackups[req.Game]
+	if strings.TrimSpace(backupKey) == "" {
+		provider, ok := ad.(latestBackupProvider)
+		if !ok {
+			return StartResult{}, domain.ErrNoBackupForGame
+		}
+		var err error
+		backupKey, err = provider.LatestBackup(ctx)
+		if err != nil || strings.TrimSpace(backupKey) == "" {
+			return StartResult{}, domain.ErrNoBackupForGame
+		}
+	}
+	result.Backup = backupKey
+	return result, nil
+}
+
 func ensureStateMaps(st State) State {
 	if st.LastBackups == nil {
 		st.LastBackups = map[string]string{}
@@ -278,5 +821,58 @@ func ensureStateMaps(st State) State {
 	if st.SourceByGame == nil {
 		st.SourceByGame = map[string]string{}
 	}
+	if st.SourceShaByGame == nil {
+		st.SourceShaByGame = map[string]string{}
+	}
+	if st.ECSTargets == nil {
+		st.ECSTargets = map[string]domain.ECSTarget{}
+	}
 	return st
 }
+
+// capabilityReporter lets an adapter say which operations its configuration
+// supports.
+type capabilityReporter interface {
+	Capabilities() domain.Capabilities
+}
+
+type GameInfo struct {
+	Name         string              `json:"name"`
+	Type         domain.GameType     `json:"type"`
+	Active       bool                `json:"active"`
+	Capabilities domain.Capabilities `json:"capabilities"`
+}
+
+// Games lists the registered adapters, sorted by name.
+func (c *ControllerService) Games(ctx context.Context) []GameInfo {
+	st, _ := c.state.Get(ctx)
+	out := make([]GameInfo, 0, len(c.adapters))
+	for name, ad := range c.adapters {
+		out = append(out, gameInfo(name, ad, st))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Game describes one registered adapter.
+func (c *ControllerService) Game(ctx context.Context, name string) (GameInfo, error) {
+	ad, ok := c.adapters[name]
+	if !ok {
+		return GameInfo{}, domain.ErrUnknownGameType
+	}
+	st, _ := c.state.Get(ctx)
+	return gameInfo(name, ad, st), nil
+}
+
+func gameInfo(name string, ad Adapter, st State) GameInfo {
+	info := GameInfo{Name: name, Type: ad.Type(), Active: ad.Type() == st.ActiveGame}
+	if cr, ok := ad.(capabilityReporter); ok {
+		info.Capabilities = cr.Capabilities()
+	} else {
+		// Adapters that don't report are assumed to implement the whole
+		// GameAdapter interface for real.
+		_, lists := ad.(backupLister)
+		info.Capabilities = domain.Capabilities{Backup: true, Command: true, Seed: true, Sync: true, ListBackups: lists}
+	}
+	return info
+}
@@ -0,0 +1,480 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/service"
+	"github.com/esuEdu/game-infra/controller/internal/service/servicetest"
+)
+
+var errInjected = errors.New("injected")
+
+const (
+	minecraft = "minecraft"
+	terraria  = "terraria"
+)
+
+// fixture is a controller over two fake games sharing one recorder.
+type fixture struct {
+	svc   *service.ControllerService
+	state service.StateStore
+	rec   *servicetest.Recorder
+	games map[string]*servicetest.Adapter
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{state: service.NewMemoryState(), rec: &servicetest.Recorder{}, games: map[string]*servicetest.Adapter{}}
+	adapters := map[string]service.Adapter{}
+	for _, game := range []string{minecraft, terraria} {
+		f.games[game] = servicetest.New(domain.GameType(game), f.rec)
+		adapters[game] = f.games[game]
+	}
+	f.svc = service.NewControllerService(slog.New(slog.DiscardHandler), f.state, adapters, service.WithBackupPrefix("backups"))
+	return f
+}
+
+// activate makes game the running, active one, as a successful start would,
+// without recording any calls.
+func (f *fixture) activate(t *testing.T, game, source string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := f.games[game].Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	st, _ := f.state.Get(ctx)
+	st.ActiveGame = domain.GameType(game)
+	st.Phase = "running"
+	if source != "" {
+		st.SourceByGame = map[string]string{game: source}
+	}
+	if err := f.state.Set(ctx, st); err != nil {
+		t.Fatal(err)
+	}
+	f.rec.Reset()
+}
+
+func (f *fixture) get(t *testing.T) service.State {
+	t.Helper()
+	st, err := f.state.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func assertCalls(t *testing.T, rec *servicetest.Recorder, want []string) {
+	t.Helper()
+	if got := rec.Calls(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, f *fixture)
+		req   service.StartRequest
+
+		wantErr      error
+		wantCalls    []string
+		wantActive   string
+		wantPhase    string
+		wantReplaced string
+	}{
+		{
+			name:       "restores the latest backup",
+			setup:      func(t *testing.T, f *fixture) { f.games[minecraft].SetLatest("backups/minecraft/20250101-000000.zip") },
+			req:        service.StartRequest{Game: minecraft},
+			wantCalls:  []string{"minecraft.LatestBackup", "minecraft.Restore", "minecraft.Start"},
+			wantActive: minecraft,
+			wantPhase:  "running",
+		},
+		{
+			name:       "seeds from a data url",
+			req:        service.StartRequest{Game: minecraft, DataURL: "https://git.example.com/world.git"},
+			wantCalls:  []string{"minecraft.SeedFromSource", "minecraft.Start"},
+			wantActive: minecraft,
+			wantPhase:  "running",
+		},
+		{
+			name:      "no backup to restore",
+			req:       service.StartRequest{Game: minecraft},
+			wantErr:   domain.ErrNoBackupForGame,
+			wantCalls: []string{"minecraft.LatestBackup"},
+		},
+		{
+			name:      "backup of another game",
+			setup:     func(t *testing.T, f *fixture) { f.games[minecraft].SetLatest("backups/terraria/20250101-000000.zip") },
+			req:       service.StartRequest{Game: minecraft},
+			wantErr:   domain.ErrBackupOtherGame,
+			wantCalls: []string{"minecraft.LatestBackup"},
+		},
+		{
+			name:    "unknown game",
+			req:     service.StartRequest{Game: "valheim"},
+			wantErr: domain.ErrUnknownGameType,
+		},
+		{
+			name: "another game active is stopped, backed up and synced first",
+			setup: func(t *testing.T, f *fixture) {
+				f.activate(t, terraria, "https://git.example.com/terraria.git")
+				f.games[minecraft].SetLatest("backups/minecraft/20250101-000000.zip")
+			},
+			req: service.StartRequest{Game: minecraft},
+			wantCalls: []string{
+				"minecraft.LatestBackup",
+				"terraria.Stop", "terraria.Backup", "terraria.SyncToSource",
+				"minecraft.Restore", "minecraft.Start",
+			},
+			wantActive:   minecraft,
+			wantPhase:    "running",
+			wantReplaced: terraria,
+		},
+		{
+			name: "another game active whose backup fails",
+			setup: func(t *testing.T, f *fixture) {
+				f.activate(t, terraria, "")
+				f.games[minecraft].SetLatest("backups/minecraft/20250101-000000.zip")
+				f.games[terraria].Fail("Backup", errInjected)
+			},
+			req:        service.StartRequest{Game: minecraft},
+			wantErr:    errInjected,
+			wantCalls:  []string{"minecraft.LatestBackup", "terraria.Stop", "terraria.Backup"},
+			wantActive: terraria, // still active so a retry backs it up
+			wantPhase:  "stopped",
+		},
+		{
+			name:      "failed start leaves the state alone",
+			setup:     func(t *testing.T, f *fixture) { f.games[minecraft].Fail("Start", errInjected) },
+			req:       service.StartRequest{Game: minecraft, DataURL: "https://git.example.com/world.git"},
+			wantErr:   errInjected,
+			wantCalls: []string{"minecraft.SeedFromSource", "minecraft.Start"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.setup != nil {
+				tt.setup(t, f)
+			}
+			res, err := f.svc.Start(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Start error = %v, want %v", err, tt.wantErr)
+			}
+			assertCalls(t, f.rec, tt.wantCalls)
+			st := f.get(t)
+			if string(st.ActiveGame) != tt.wantActive {
+				t.Errorf("active game = %q, want %q", st.ActiveGame, tt.wantActive)
+			}
+			if tt.wantPhase != "" && st.Phase != tt.wantPhase {
+				t.Errorf("phase = %q, want %q", st.Phase, tt.wantPhase)
+			}
+			if err == nil {
+				if res.Replaced != tt.wantReplaced {
+					t.Errorf("replaced = %q, want %q", res.Replaced, tt.wantReplaced)
+				}
+				if !f.games[tt.req.Game].Running() {
+					t.Errorf("%s is not running", tt.req.Game)
+				}
+			}
+			if tt.wantReplaced != "" {
+				if f.games[tt.wantReplaced].Running() {
+					t.Errorf("%s is still running", tt.wantReplaced)
+				}
+				if st.LastBackups[tt.wantReplaced] == "" {
+					t.Errorf("no backup recorded for %s", tt.wantReplaced)
+				}
+			}
+		})
+	}
+}
+
+func TestStop(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, f *fixture)
+		order  string
+		source string
+
+		wantErr     error
+		wantCalls   []string
+		wantRunning bool
+		wantActive  string
+	}{
+		{
+			name:      "backs up after stopping by default",
+			wantCalls: []string{"minecraft.Stop", "minecraft.Backup"},
+		},
+		{
+			name:      "backs up before stopping",
+			order:     service.BackupBeforeStop,
+			wantCalls: []string{"minecraft.Backup", "minecraft.Stop"},
+		},
+		{
+			name:      "syncs to the recorded source",
+			source:    "https://git.example.com/world.git",
+			wantCalls: []string{"minecraft.Stop", "minecraft.Backup", "minecraft.SyncToSource"},
+		},
+		{
+			name:        "failed backup before stopping leaves the server up",
+			setup:       func(t *testing.T, f *fixture) { f.games[minecraft].Fail("Backup", errInjected) },
+			order:       service.BackupBeforeStop,
+			wantErr:     errInjected,
+			wantCalls:   []string{"minecraft.Backup"},
+			wantRunning: true,
+			wantActive:  minecraft,
+		},
+		{
+			name:       "failed backup after stopping keeps the game active",
+			setup:      func(t *testing.T, f *fixture) { f.games[minecraft].Fail("Backup", errInjected) },
+			wantErr:    errInjected,
+			wantCalls:  []string{"minecraft.Stop", "minecraft.Backup"},
+			wantActive: minecraft,
+		},
+		{
+			name:    "unknown backup order",
+			order:   "sideways",
+			wantErr: domain.ErrBadState,
+			// Checked before anything is touched.
+			wantRunning: true,
+			wantActive:  minecraft,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.activate(t, minecraft, tt.source)
+			if tt.setup != nil {
+				tt.setup(t, f)
+			}
+			res, err := f.svc.Stop(context.Background(), service.StopRequest{BackupOrder: tt.order})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Stop error = %v, want %v", err, tt.wantErr)
+			}
+			assertCalls(t, f.rec, tt.wantCalls)
+			if got := f.games[minecraft].Running(); got != tt.wantRunning {
+				t.Errorf("running = %v, want %v", got, tt.wantRunning)
+			}
+			st := f.get(t)
+			if string(st.ActiveGame) != tt.wantActive {
+				t.Errorf("active game = %q, want %q", st.ActiveGame, tt.wantActive)
+			}
+			if err == nil {
+				if st.LastBackups[minecraft] != res.Backup || res.Backup == "" {
+					t.Errorf("recorded backup = %q, result backup = %q", st.LastBackups[minecraft], res.Backup)
+				}
+				if res.Synced != (tt.source != "") {
+					t.Errorf("synced = %v with source %q", res.Synced, tt.source)
+				}
+			}
+		})
+	}
+}
+
+func TestStopWithoutActiveGame(t *testing.T) {
+	f := newFixture(t)
+	if _, err := f.svc.Stop(context.Background(), service.StopRequest{}); !errors.Is(err, domain.ErrNoActiveGame) {
+		t.Fatalf("Stop error = %v, want %v", err, domain.ErrNoActiveGame)
+	}
+	assertCalls(t, f.rec, nil)
+}
+
+func TestSwitch(t *testing.T) {
+	tests := []struct {
+		name   string
+		active string
+		setup  func(t *testing.T, f *fixture)
+		to     string
+
+		wantErr      error
+		wantCalls    []string
+		wantActive   string
+		wantPhase    string
+		wantBackupOf string
+	}{
+		{
+			name:       "nothing active",
+			to:         minecraft,
+			wantCalls:  []string{"minecraft.Start"},
+			wantActive: minecraft,
+			wantPhase:  "running",
+		},
+		{
+			name:         "another game active",
+			active:       terraria,
+			to:           minecraft,
+			wantCalls:    []string{"terraria.Stop", "terraria.Backup", "minecraft.Start"},
+			wantActive:   minecraft,
+			wantPhase:    "running",
+			wantBackupOf: terraria,
+		},
+		{
+			name:       "already active",
+			active:     minecraft,
+			to:         minecraft,
+			wantActive: minecraft,
+			wantPhase:  "running",
+		},
+		{
+			name:         "failed start keeps the old game's backup",
+			active:       terraria,
+			setup:        func(t *testing.T, f *fixture) { f.games[minecraft].Fail("Start", errInjected) },
+			to:           minecraft,
+			wantErr:      errInjected,
+			wantCalls:    []string{"terraria.Stop", "terraria.Backup", "minecraft.Start"},
+			wantActive:   terraria,
+			wantPhase:    "error",
+			wantBackupOf: terraria,
+		},
+		{
+			name:       "failed backup doesn't start the new game",
+			active:     terraria,
+			setup:      func(t *testing.T, f *fixture) { f.games[terraria].Fail("Backup", errInjected) },
+			to:         minecraft,
+			wantErr:    errInjected,
+			wantCalls:  []string{"terraria.Stop", "terraria.Backup"},
+			wantActive: terraria,
+			wantPhase:  "error",
+		},
+		{
+			name:      "unknown game",
+			to:        "valheim",
+			wantErr:   domain.ErrUnknownGameType,
+			wantPhase: "stopped",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			if tt.active != "" {
+				f.activate(t, tt.active, "")
+			}
+			if tt.setup != nil {
+				tt.setup(t, f)
+			}
+			err := f.svc.Switch(context.Background(), tt.to, domain.ECSTarget{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Switch error = %v, want %v", err, tt.wantErr)
+			}
+			assertCalls(t, f.rec, tt.wantCalls)
+			st := f.get(t)
+			if string(st.ActiveGame) != tt.wantActive {
+				t.Errorf("active game = %q, want %q", st.ActiveGame, tt.wantActive)
+			}
+			if st.Phase != tt.wantPhase {
+				t.Errorf("phase = %q, want %q", st.Phase, tt.wantPhase)
+			}
+			if tt.wantBackupOf != "" && st.LastBackups[tt.wantBackupOf] == "" {
+				t.Errorf("no backup recorded for %s", tt.wantBackupOf)
+			}
+		})
+	}
+}
+
+// preflightAdapter pre-flights in Start the way the ECS adapters do, and
+// counts the checks that actually ran.
+type preflightAdapter struct {
+	*servicetest.Adapter
+	checks int
+}
+
+func (a *preflightAdapter) Preflight(ctx context.Context) error {
+	if !domain.Preflighted(ctx) {
+		a.checks++
+	}
+	return nil
+}
+
+func (a *preflightAdapter) Start(ctx context.Context) error {
+	if err := a.Preflight(ctx); err != nil {
+		return err
+	}
+	return a.Adapter.Start(ctx)
+}
+
+func TestStartPreflightsOnce(t *testing.T) {
+	ad := &preflightAdapter{Adapter: servicetest.New(minecraft, &servicetest.Recorder{})}
+	svc := service.NewControllerService(slog.New(slog.DiscardHandler), service.NewMemoryState(),
+		map[string]service.Adapter{minecraft: ad}, service.WithBackupPrefix("backups"))
+
+	req := service.StartRequest{Game: minecraft, DataURL: "https://git.example.com/world.git"}
+	if _, err := svc.Start(context.Background(), req); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if ad.checks != 1 {
+		t.Fatalf("pre-flighted %d times, want 1", ad.checks)
+	}
+
+	// A start that doesn't go through Start still pre-flights in the adapter.
+	if _, err := svc.Stop(context.Background(), service.StopRequest{}); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	ad.checks = 0
+	if err := svc.Switch(context.Background(), minecraft, domain.ECSTarget{}); err != nil {
+		t.Fatalf("Switch: %v", err)
+	}
+	if ad.checks != 1 {
+		t.Fatalf("switch pre-flighted %d times, want 1", ad.checks)
+	}
+}
+
+// addrAdapter exposes a game address for readiness probes.
+type addrAdapter struct {
+	*servicetest.Adapter
+	addr string
+}
+
+func (a *addrAdapter) GameAddr() string { return a.addr }
+
+func TestReadyDoesNotDependOnGame(t *testing.T) {
+	// A port nothing listens on: the game is loading or has crashed.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	state := service.NewMemoryState()
+	ctx := context.Background()
+	if err := state.Set(ctx, service.State{ActiveGame: minecraft, Phase: "running"}); err != nil {
+		t.Fatal(err)
+	}
+	ad := &addrAdapter{Adapter: servicetest.New(minecraft, &servicetest.Recorder{}), addr: addr}
+	svc := service.NewControllerService(slog.New(slog.DiscardHandler), state, map[string]service.Adapter{minecraft: ad})
+	before, _ := state.Get(ctx)
+
+	report := svc.Ready(ctx, false)
+	if !report.Ready {
+		t.Error("controller not ready while the game is down")
+	}
+	if report.Game == nil || report.Game.Listening {
+		t.Errorf("game probe = %+v, want not listening", report.Game)
+	}
+	if svc.Ready(ctx, true).Ready {
+		t.Error("ready with requireGame while the game is down")
+	}
+	if after, _ := state.Get(ctx); !after.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Error("readiness probe wrote the state")
+	}
+}
+
+func TestCommandIsAnOperation(t *testing.T) {
+	f := newFixture(t)
+	f.activate(t, minecraft, "")
+
+	if _, err := f.svc.Command(context.Background(), service.CommandRequest{Command: "list"}); err != nil {
+		t.Fatal(err)
+	}
+	ops := f.svc.Operations(10)
+	if len(ops) != 1 || ops[0].Kind != domain.EventCommand || ops[0].Game != minecraft || ops[0].Status != service.OpSucceeded {
+		t.Fatalf("operations = %+v, want one finished command on %s", ops, minecraft)
+	}
+}
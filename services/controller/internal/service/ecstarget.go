@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// WithECSTargets lets start, stop and switch requests name one of targets
+// in place of the adapter's configured cluster/service. A target whose
+// Service is "*" allows any service in its cluster. Without any, overrides
+// are refused.
+func WithECSTargets(targets []domain.ECSTarget) Option {
+	return func(c *ControllerService) { c.ecsTargets = targets }
+}
+
+// checkECSTarget refuses an override that isn't on the allowlist. The zero
+// target (no override) is always fine.
+func (c *ControllerService) checkECSTarget(t domain.ECSTarget) error {
+	if t.IsZero() {
+		return nil
+	}
+	if t.Cluster == "" || t.Service == "" {
+		return fmt.Errorf("%w: cluster and service must be given together", domain.ErrECSTargetNotAllowed)
+	}
+	for _, allowed := range c.ecsTargets {
+		if allowed.Cluster == t.Cluster && (allowed.Service == "*" || allowed.Service == t.Service) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", domain.ErrECSTargetNotAllowed, t)
+}
+
+// gameECSCtx returns ctx carrying the ECS target game was started on, so
+// later calls for it (stop, status, restore) reach the same service.
+func gameECSCtx(ctx context.Context, st State, game string) context.Context {
+	return domain.WithECSTarget(ctx, st.ECSTargets[game])
+}
+
+// recordECSTarget remembers the target game now runs on; a zero target
+// clears it.
+func recordECSTarget(st State, game string, t domain.ECSTarget) {
+	if t.IsZero() {
+		delete(st.ECSTargets, game)
+		return
+	}
+	st.ECSTargets[game] = t
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/metrics"
+)
+
+// eventQueueSize bounds how far a slow sink may fall behind before its events
+// are dropped.
+const eventQueueSize = 256
+
+var eventsDropped = metrics.Default.Counter("controller_events_dropped_total", "Events dropped because a sink's queue was full.")
+
+// queuedSink feeds one sink from its own goroutine so Emit never blocks an
+// operation.
+type queuedSink struct {
+	sink domain.EventSink
+	ch   chan domain.Event
+}
+
+func newQueuedSink(sink domain.EventSink) *queuedSink {
+	q := &queuedSink{sink: sink, ch: make(chan domain.Event, eventQueueSize)}
+	go func() {
+		for ev := range q.ch {
+			q.sink.Emit(ev)
+		}
+	}()
+	return q
+}
+
+// WithEventSinks registers sinks that receive every operation event.
+func WithEventSinks(sinks ...domain.EventSink) Option {
+	return func(c *ControllerService) {
+		for _, s := range sinks {
+			c.sinks = append(c.sinks, newQueuedSink(s))
+		}
+	}
+}
+
+// emit stamps ev with the time, request id, current phase and *errp,
+// finishes the operation begun on ctx and hands ev to every sink without
+// blocking. When an operator cancelled the operation, *errp is wrapped in
+// domain.ErrOperationCancelled so callers can tell it from a failure.
+func (c *ControllerService) emit(ctx context.Context, ev domain.Event, errp *error) {
+	ev.Time = c.clock.Now().UTC()
+	ev.RequestID = domain.RequestID(ctx)
+	if err := *errp; err != nil {
+		if cancelled(ctx) && !errors.Is(err, domain.ErrOperationCancelled) {
+			*errp = fmt.Errorf("%w: %v", domain.ErrOperationCancelled, err)
+		}
+		ev.Error = (*errp).Error()
+	}
+	c.ops.finishOp(ctx, ev)
+	if len(c.sinks) == 0 {
+		return
+	}
+	if st, stErr := c.state.Get(ctx); stErr == nil {
+		ev.Phase = st.Phase
+	}
+	for _, q := range c.sinks {
+		select {
+		case q.ch <- ev:
+		default:
+			eventsDropped.Inc("kind", string(ev.Kind))
+		}
+	}
+}
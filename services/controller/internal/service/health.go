@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/buildinfo"
+)
+
+// healthChecker exposes the dependencies an adapter talks to (S3, ECS, RCON,
+// the git remote) as named checks. Dependencies that aren't configured are
+// left out. sourceURL is the game's recorded source repo, if any.
+type healthChecker interface {
+	HealthChecks(sourceURL string) map[string]func(ctx context.Context) error
+}
+
+// healthCheckTimeout bounds each check so one hung dependency can't stall
+// the report.
+const healthCheckTimeout = 3 * time.Second
+
+// HealthCheck is one subsystem's result. LastError stays set after the
+// subsystem recovers, so flapping dependencies are visible.
+type HealthCheck struct {
+	Name        string     `json:"name"`
+	OK          bool       `json:"ok"`
+	LatencyMS   int64      `json:"latency_ms"`
+	Error       string     `json:"error,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type HealthReport struct {
+	OK        bool           `json:"ok"`
+	CheckedAt time.Time      `json:"checked_at"`
+	Build     buildinfo.Info `json:"build"`
+	Checks    []HealthCheck  `json:"checks"`
+}
+
+// healthErrors remembers the last failure of each check across reports.
+type healthErrors struct {
+	mu   sync.Mutex
+	last map[string]healthFailure
+}
+
+type healthFailure struct {
+	err string
+	at  time.Time
+}
+
+// HealthDetail runs every subsystem check concurrently: the state store,
+// each adapter's dependencies (named "<game>.<check>") and any extra checks
+// from the transport layer.
+func (c *ControllerService) HealthDetail(ctx context.Context, extra map[string]func(ctx context.Context) error) HealthReport {
+	checks := map[string]func(ctx context.Context) error{
+		"state": func(ctx context.Context) error {
+			_, err := c.state.Get(ctx)
+			return err
+		},
+	}
+	for name, fn := range extra {
+		checks[name] = fn
+	}
+	st, _ := c.state.Get(ctx)
+	for game, ad := range c.adapters {
+		checker, ok := ad.(healthChecker)
+		if !ok {
+			continue
+		}
+		for name, fn := range checker.HealthChecks(st.SourceByGame[game]) {
+			checks[game+"."+name] = fn
+		}
+	}
+
+	results := make([]HealthCheck, 0, len(checks))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, fn := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			begin := time.Now()
+			err := fn(checkCtx)
+			res := HealthCheck{Name: name, OK: err == nil, LatencyMS: time.Since(begin).Milliseconds()}
+			if err != nil {
+				res.Error = err.Error()
+			}
+			mu.Lock()
+			results = append(results, res)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := HealthReport{OK: true, CheckedAt: c.clock.Now().UTC(), Build: buildinfo.Get(), Checks: results}
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+	for i := range report.Checks {
+		res := &report.Checks[i]
+		if !res.OK {
+			report.OK = false
+			c.health.last[res.Name] = healthFailure{err: res.Error, at: report.CheckedAt}
+		}
+		if last, ok := c.health.last[res.Name]; ok {
+			res.LastError, res.LastErrorAt = last.err, &last.at
+		}
+	}
+	return report
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCommandOutput caps the output kept per history entry.
+const maxCommandOutput = 2048
+
+type CommandRecord struct {
+	At        time.Time `json:"at"`
+	Game      string    `json:"game"`
+	Command   string    `json:"command"`
+	Output    string    `json:"output,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	RemoteIP  string    `json:"remote_ip,omitempty"`
+}
+
+// commandHistory is a fixed-size ring of the most recent commands.
+type commandHistory struct {
+	mu      sync.Mutex
+	entries []CommandRecord
+	next    int
+	full    bool
+}
+
+func newCommandHistory(size int) *commandHistory {
+	if size <= 0 {
+		return nil
+	}
+	return &commandHistory{entries: make([]CommandRecord, size)}
+}
+
+func (h *commandHistory) add(rec CommandRecord) {
+	if h == nil {
+		return
+	}
+	if len(rec.Output) > maxCommandOutput {
+		rec.Output = rec.Output[:maxCommandOutput]
+		rec.Truncated = true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries[h.next] = rec
+	h.next = (h.next + 1) % len(h.entries)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// list returns the recorded commands, newest first.
+func (h *commandHistory) list() []CommandRecord {
+	out := []CommandRecord{}
+	if h == nil {
+		return out
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.next
+	if h.full {
+		n = len(h.entries)
+	}
+	for i := 1; i <= n; i++ {
+		out = append(out, h.entries[(h.next-i+len(h.entries))%len(h.entries)])
+	}
+	return out
+}
+
+func (h *commandHistory) reset() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clear(h.entries)
+	h.next = 0
+	h.full = false
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+type logFileProvider interface {
+	LogFile() string
+}
+
+// LogFile returns the path of the active game's server log.
+func (c *ControllerService) LogFile(ctx context.Context) (string, error) {
+	st, _ := c.state.Get(ctx)
+	if st.ActiveGame == "" {
+		return "", domain.ErrNoActiveGame
+	}
+	ad, err := c.adapterByType(st.ActiveGame)
+	if err != nil {
+		return "", err
+	}
+	provider, ok := ad.(logFileProvider)
+	if !ok {
+		return "", domain.ErrNotSupported
+	}
+
+	path := provider.LogFile()
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrLogUnavailable, err)
+	}
+	_ = f.Close()
+	return path, nil
+}
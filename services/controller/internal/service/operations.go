@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/metrics"
+	"github.com/esuEdu/game-infra/controller/internal/tracing"
+)
+
+// Operation statuses.
+const (
+	OpRunning   = "running"
+	OpSucceeded = "succeeded"
+	OpFailed    = "failed"
+	OpCancelled = "cancelled"
+)
+
+// defaultOperationHistory is how many finished operations are kept for
+// /v1/operations.
+const defaultOperationHistory = 100
+
+// Operation is one controller operation (start, backup, ...), running or
+// finished, as shown in the activity feed.
+type Operation struct {
+	ID         int64            `json:"id"`
+	Kind       domain.EventKind `json:"kind"`
+	Game       string           `json:"game,omitempty"`
+	Status     string           `json:"status"`
+	RequestID  string           `json:"request_id,omitempty"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	DurationMS int64            `json:"duration_ms,omitempty"`
+	Backup     string           `json:"backup,omitempty"`
+	Error      string           `json:"error,omitempty"`
+
+	CancelRequested bool `json:"cancel_requested,omitempty"`
+
+	cancel context.CancelCauseFunc
+	span   trace.Span
+}
+
+// operationLog tracks in-flight operations and a ring of finished ones.
+type operationLog struct {
+	mu       sync.Mutex
+	nextID   int64
+	running  map[int64]*Operation
+	finished []Operation
+	next     int
+	full     bool
+}
+
+func newOperationLog(size int) *operationLog {
+	return &operationLog{running: map[int64]*Operation{}, finished: make([]Operation, size)}
+}
+
+var operationsRejected = metrics.Default.Counter("controller_operations_rejected_total", "Operations refused because their kind was at its OPERATION_LIMITS cap.")
+
+// lockOp takes the operation lock for an operation of kind, first claiming
+// one of kind's slots when WithOperationLimits capped it. A saturated kind
+// fails at once with ErrOperationLimit rather than queueing another copy of
+// the same work behind the lock; otherwise it waits its turn in the
+// operation queue until ctx ends.
+func (c *ControllerService) lockOp(ctx context.Context, kind domain.EventKind) (unlock func(), err error) {
+	slots := c.opLimits[kind]
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+		default:
+			operationsRejected.Inc("kind", string(kind))
+			return nil, fmt.Errorf("%w: %s (limit %d)", domain.ErrOperationLimit, kind, cap(slots))
+		}
+	}
+	if err := c.opMu.Lock(ctx); err != nil {
+		if slots != nil {
+			<-slots
+		}
+		return nil, err
+	}
+	return func() {
+		c.opMu.Unlock()
+		if slots != nil {
+			<-slots
+		}
+	}, nil
+}
+
+type opKey struct{}
+
+// beginOp records an operation as running and returns a context carrying it
+// and its span, cancellable through CancelOperation; the emit deferred by the
+// operation finishes both.
+func (c *ControllerService) beginOp(ctx context.Context, kind domain.EventKind, game string) context.Context {
+	ctx, span := tracing.Start(ctx, "operation "+string(kind),
+		attribute.String("operation.kind", string(kind)),
+		attribute.String("game", game),
+	)
+	ctx, cancel := context.WithCancelCause(ctx)
+	l := c.ops
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	op := &Operation{
+		ID:        l.nextID,
+		Kind:      kind,
+		Game:      game,
+		Status:    OpRunning,
+		RequestID: domain.RequestID(ctx),
+		StartedAt: c.clock.Now().UTC(),
+		cancel:    cancel,
+		span:      span,
+	}
+	l.running[op.ID] = op
+	return context.WithValue(ctx, opKey{}, op.ID)
+}
+
+// cancelled reports whether ctx belongs to an operation an operator
+// cancelled.
+func cancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), domain.ErrOperationCancelled)
+}
+
+// CancelOperation cancels a running operation's context, aborting its AWS and
+// git calls. The operation still finishes on its own (usually as cancelled,
+// but it may already be past its last cancellable step); the returned
+// snapshot only confirms the request.
+func (c *ControllerService) CancelOperation(id int64) (Operation, error) {
+	l := c.ops
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	op, ok := l.running[id]
+	if !ok {
+		return Operation{}, domain.ErrOperationNotFound
+	}
+	op.CancelRequested = true
+	op.cancel(domain.ErrOperationCancelled)
+	return *op, nil
+}
+
+// finishOp moves the operation begun on ctx, if any, to the finished ring.
+func (l *operationLog) finishOp(ctx context.Context, ev domain.Event) {
+	id, ok := ctx.Value(opKey{}).(int64)
+	if !ok {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	op, ok := l.running[id]
+	if !ok {
+		return
+	}
+	delete(l.running, id)
+
+	finished := ev.Time
+	op.FinishedAt = &finished
+	op.DurationMS = finished.Sub(op.StartedAt).Milliseconds()
+	op.Backup = ev.Backup
+	op.Error = ev.Error
+	op.Status = OpSucceeded
+	switch {
+	case ev.Error != "" && cancelled(ctx):
+		op.Status = OpCancelled
+	case ev.Error != "":
+		op.Status = OpFailed
+	}
+	op.cancel(nil)
+	if ev.Game != "" {
+		op.Game = ev.Game
+	}
+	op.span.SetAttributes(attribute.Int64("operation.id", op.ID), attribute.String("operation.status", op.Status))
+	var spanErr error
+	if ev.Error != "" {
+		spanErr = errors.New(ev.Error)
+	}
+	tracing.End(op.span, spanErr)
+	l.finished[l.next] = *op
+	l.next = (l.next + 1) % len(l.finished)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// list returns up to limit operations: running ones first, then finished,
+// each newest first.
+func (l *operationLog) list(limit int) []Operation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]Operation, 0, len(l.running))
+	for _, op := range l.running {
+		out = append(out, *op)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+
+	n := l.next
+	if l.full {
+		n = len(l.finished)
+	}
+	for i := 1; i <= n; i++ {
+		out = append(out, l.finished[(l.next-i+len(l.finished))%len(l.finished)])
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// Operations returns the in-flight and most recent operations, newest first.
+func (c *ControllerService) Operations(limit int) []Operation {
+	return c.ops.list(limit)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/metrics"
+)
+
+var operationQueueDepth = metrics.Default.Gauge("controller_operation_queue_depth", "Operations waiting for the operation lock.")
+
+// opQueue is the operation lock. Unlike sync.Mutex it grants the lock in
+// arrival order: Unlock hands it straight to the longest waiter, so a
+// client retrying in a tight loop can't overtake one that is already
+// waiting. A waiter whose context ends leaves the queue, and with a
+// maxWaiting above zero a full queue refuses new waiters with
+// ErrOperationQueueFull instead of growing without bound.
+type opQueue struct {
+	mu         sync.Mutex
+	held       bool
+	waiters    []chan struct{}
+	maxWaiting int
+}
+
+// Lock waits for the lock in FIFO order. It fails with ErrOperationQueueFull
+// when maxWaiting callers are already waiting, or with ctx's error when ctx
+// ends first.
+func (q *opQueue) Lock(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.held {
+		q.held = true
+		q.mu.Unlock()
+		return nil
+	}
+	if q.maxWaiting > 0 && len(q.waiters) >= q.maxWaiting {
+		q.mu.Unlock()
+		return fmt.Errorf("%w (%d waiting)", domain.ErrOperationQueueFull, q.maxWaiting)
+	}
+	granted := make(chan struct{})
+	q.waiters = append(q.waiters, granted)
+	operationQueueDepth.Set(float64(len(q.waiters)))
+	q.mu.Unlock()
+
+	select {
+	case <-granted:
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	if i := slices.Index(q.waiters, granted); i >= 0 {
+		q.waiters = slices.Delete(q.waiters, i, i+1)
+		operationQueueDepth.Set(float64(len(q.waiters)))
+		q.mu.Unlock()
+		return ctx.Err()
+	}
+	q.mu.Unlock()
+	// Unlock handed us the lock as ctx ended; pass it on.
+	<-granted
+	q.Unlock()
+	return ctx.Err()
+}
+
+// TryLock takes the lock only if it is free and nobody is waiting for it.
+func (q *opQueue) TryLock() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.held {
+		return false
+	}
+	q.held = true
+	return true
+}
+
+// Unlock releases the lock to the longest waiter, if any.
+func (q *opQueue) Unlock() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.held {
+		panic("service: unlock of unlocked operation queue")
+	}
+	if len(q.waiters) == 0 {
+		q.held = false
+		return
+	}
+	next := q.waiters[0]
+	q.waiters = q.waiters[1:]
+	operationQueueDepth.Set(float64(len(q.waiters)))
+	close(next)
+}
+
+// OperationQueue is the operation lock's state for /v1/status.
+type OperationQueue struct {
+	Busy       bool `json:"busy"`
+	Waiting    int  `json:"waiting"`
+	MaxWaiting int  `json:"max_waiting,omitempty"`
+}
+
+func (q *opQueue) snapshot() OperationQueue {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return OperationQueue{Busy: q.held, Waiting: len(q.waiters), MaxWaiting: q.maxWaiting}
+}
+
+// WithOperationQueueMax caps how many operations may wait for the operation
+// lock; more are refused with ErrOperationQueueFull. n <= 0 leaves the queue
+// unbounded.
+func WithOperationQueueMax(n int) Option {
+	return func(c *ControllerService) { c.opMu.maxWaiting = max(n, 0) }
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// gameAddresser exposes the host:port the game itself listens on, so
+// readiness can tell "ECS task running" apart from "game accepting
+// connections" (the two differ for minutes while a world loads).
+type gameAddresser interface {
+	GameAddr() string
+}
+
+const gameProbeTimeout = 3 * time.Second
+
+// GameProbe is the result of a TCP dial to the active game's port.
+type GameProbe struct {
+	Addr      string `json:"addr"`
+	Listening bool   `json:"listening"`
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ReadyReport struct {
+	Ready      bool       `json:"ready"`
+	ActiveGame string     `json:"active_game,omitempty"`
+	Game       *GameProbe `json:"game,omitempty"`
+}
+
+// Ready reports the controller ready, with whether the active game, if any,
+// is accepting TCP connections. The controller doesn't depend on the game:
+// it has to stay in rotation while a world loads or the game has crashed.
+// Only with requireGame does a game that isn't listening make it not ready.
+// It is a read-only probe and records nothing.
+func (c *ControllerService) Ready(ctx context.Context, requireGame bool) ReadyReport {
+	st, _ := c.state.Get(ctx)
+	report := ReadyReport{Ready: true, ActiveGame: string(st.ActiveGame)}
+	if probe := c.probeGame(ctx, st.ActiveGame); probe != nil {
+		report.Game = probe
+		if requireGame {
+			report.Ready = probe.Listening
+		}
+	}
+	return report
+}
+
+// recordReadiness adds readiness_ms, the time from the last start finishing
+// to the first status poll that found the game listening, to the state's
+// last_start. Its accuracy is the poll interval. Skipped while an
+// operation holds the lock, so it never overwrites an operation's state.
+func (c *ControllerService) recordReadiness(ctx context.Context) {
+	if !c.opMu.TryLock() {
+		return
+	}
+	defer c.opMu.Unlock()
+	st, err := c.state.Get(ctx)
+	if err != nil || st.Phase != "running" || st.LastStart == nil {
+		return
+	}
+	if _, ok := st.LastStart.Timings["readiness_ms"]; ok || st.LastStart.Started != string(st.ActiveGame) {
+		return
+	}
+	if st.LastStart.Timings == nil {
+		st.LastStart.Timings = map[string]int64{}
+	}
+	st.LastStart.Timings["readiness_ms"] = c.clock.Now().Sub(st.LastStart.FinishedAt).Milliseconds()
+	_ = c.state.Set(ctx, st)
+}
+
+// probeGame dials the game's port, returning nil when there is nothing to
+// probe.
+func (c *ControllerService) probeGame(ctx context.Context, game domain.GameType) *GameProbe {
+	if game == "" {
+		return nil
+	}
+	ad, err := c.adapterByType(game)
+	if err != nil {
+		return nil
+	}
+	addresser, ok := ad.(gameAddresser)
+	if !ok {
+		return nil
+	}
+	addr := addresser.GameAddr()
+	if addr == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gameProbeTimeout)
+	defer cancel()
+	probe := &GameProbe{Addr: addr}
+	begin := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	_ = conn.Close()
+	probe.Listening = true
+	probe.LatencyMS = time.Since(begin).Milliseconds()
+	return probe
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+// AdapterFactory builds a game adapter. Factories run once, when the
+// registry is built.
+type AdapterFactory func(log *slog.Logger) Adapter
+
+// AdapterRegistry collects the game adapters the controller serves, keyed by
+// the name used in API requests.
+type AdapterRegistry struct {
+	mu        sync.Mutex
+	factories map[string]AdapterFactory
+}
+
+func NewAdapterRegistry() *AdapterRegistry {
+	return &AdapterRegistry{factories: map[string]AdapterFactory{}}
+}
+
+// Register adds a named adapter factory. Names must be unique.
+func (r *AdapterRegistry) Register(name string, factory AdapterFactory) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("register adapter: name and factory are required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.factories[name]; exists {
+		return fmt.Errorf("register adapter: %q is already registered", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// Names returns the registered adapter names, sorted.
+func (r *AdapterRegistry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build instantiates every registered adapter.
+func (r *AdapterRegistry) Build(log *slog.Logger) map[string]Adapter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]Adapter, len(r.factories))
+	for name, factory := range r.factories {
+		out[name] = factory(log)
+	}
+	return out
+}
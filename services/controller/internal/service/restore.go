@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// hotRestorer swaps world files under a running server. Returning
+// domain.ErrNotSupported makes Restore fall back to a restart.
+type hotRestorer interface {
+	HotRestore(ctx context.Context, backupKey string) error
+}
+
+// Restore modes.
+const (
+	RestoreHot     = "hot"
+	RestoreRestart = "restart"
+)
+
+// Hot restores swap files under a server that still holds chunks and player
+// data in memory and may write them back over the restored world, so they
+// are refused unless WithUnsafeHotRestore is set and flagged in the result
+// when they run.
+const (
+	hotRestoreRisk     = "hot restore: chunks and player data still loaded in the server may be written back over the restored world"
+	hotRestoreDisabled = "hot restore is disabled (UNSAFE_HOT_RESTORE); the game was restarted instead"
+)
+
+// WithUnsafeHotRestore lets RestoreRequest.Hot swap the world under a
+// running server. Off by default: hot requests restart the game instead.
+func WithUnsafeHotRestore(on bool) Option {
+	return func(c *ControllerService) { c.unsafeHotRestore = on }
+}
+
+type RestoreRequest struct {
+	Backup string // backup key or s3:// URI; empty picks the latest
+	Stream string // stream to take the latest backup from
+	Hot    bool   // try an in-place swap before falling back to a restart; needs WithUnsafeHotRestore
+	Force  bool   // skip data dir safety checks on the restart path
+	// AllowCrossGame restores a backup whose key belongs to another game.
+	AllowCrossGame bool
+	// SkipSafetyBackup restores without first snapshotting the current world
+	// to the pre-restore stream.
+	SkipSafetyBackup bool
+	// AllowLocal permits Backup to be a file:// path on the controller's disk,
+	// bypassing S3 (see domain.LocalBackupScheme).
+	AllowLocal bool
+
+	// Refresh re-reads the latest marker instead of the keys cached in state
+	// and by the adapter, for backups uploaded by other tools.
+	Refresh bool
+	// OnlyIfNewer refuses a backup older than the current world unless Force
+	// is set.
+	OnlyIfNewer bool
+}
+
+type RestoreResult struct {
+	Game   string `json:"game"`
+	Backup string `json:"backup"`
+	Mode   string `json:"mode"` // hot | restart
+	// SafetyBackup is the snapshot of the world as it was before the
+	// restore; restore it to roll back.
+	SafetyBackup string `json:"safety_backup,omitempty"`
+	// AgeCheck is the comparison made for OnlyIfNewer.
+	AgeCheck *RestoreAgeCheck `json:"age_check,omitempty"`
+	// Warning explains a hot request that restarted instead, or the risk
+	// of one that ran hot.
+	Warning string `json:"warning,omitempty"`
+
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// Restore replaces the active game's world with a backup, either hot (when
+// requested and supported) or by stopping, restoring and starting it again.
+func (c *ControllerService) Restore(ctx context.Context, req RestoreRequest) (res RestoreResult, err error) {
+	if err := domain.ValidateStream(req.Stream); err != nil {
+		return RestoreResult{}, err
+	}
+
+	unlock, err := c.lockOp(ctx, domain.EventRestore)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	defer unlock()
+
+	begin := c.clock.Now()
+	st, _ := c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	ctx = c.beginOp(ctx, domain.EventRestore, string(st.ActiveGame))
+	defer func() {
+		c.emit(ctx, domain.Event{Kind: domain.EventRestore, Game: string(st.ActiveGame), Backup: res.Backup}, &err)
+	}()
+	if st.ActiveGame == "" {
+		return RestoreResult{}, domain.ErrNoActiveGame
+	}
+	ad, err := c.adapterByType(st.ActiveGame)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	ctx = gameECSCtx(ctx, st, string(st.ActiveGame))
+	backupKey, err := c.resolveBackup(ctx, ad, st, req)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	_, local := domain.LocalBackupPath(backupKey)
+	if local {
+		if err := checkLocalRestore(ad, backupKey, req.AllowLocal); err != nil {
+			return RestoreResult{}, err
+		}
+	} else if err := checkBackupGame(c.backupPrefix, string(st.ActiveGame), backupKey, req.AllowCrossGame); err != nil {
+		return RestoreResult{}, err
+	}
+
+	result := RestoreResult{Game: string(st.ActiveGame), Backup: backupKey, Mode: RestoreRestart}
+	if req.OnlyIfNewer {
+		check, err := c.checkRestoreAge(ctx, ad, st, backupKey)
+		if err != nil {
+			return RestoreResult{}, err
+		}
+		if !check.BackupNewer {
+			if !req.Force {
+				return RestoreResult{}, fmt.Errorf("%w: %s; pass force to restore anyway", domain.ErrBackupNotNewer, check)
+			}
+			check.Forced = true
+			c.log.Warn("restoring a backup older than the world (forced)", "game", st.ActiveGame, "backup", backupKey, "age", check.String())
+		}
+		result.AgeCheck = &check
+	}
+	if !req.SkipSafetyBackup {
+		// Taken before the server is touched, so a failure changes nothing.
+		if result.SafetyBackup, err = c.safetyBackup(ctx, ad); err != nil {
+			return RestoreResult{}, fmt.Errorf("safety backup before restore (nothing changed): %w", err)
+		}
+	}
+	hot := false
+	if req.Hot && !c.unsafeHotRestore {
+		c.log.Info("hot restore disabled, restarting instead", "game", st.ActiveGame)
+		result.Warning = hotRestoreDisabled
+	} else if req.Hot && local {
+		c.log.Info("hot restore unavailable for local backups, restarting instead", "game", st.ActiveGame)
+	} else if req.Hot {
+		err := error(domain.ErrNotSupported)
+		if hr, ok := ad.(hotRestorer); ok {
+			err = hr.HotRestore(ctx, backupKey)
+		}
+		switch {
+		case err == nil:
+			hot = true
+		case errors.Is(err, domain.ErrNotSupported):
+			c.log.Info("hot restore unavailable, restarting instead", "game", st.ActiveGame, "reason", err)
+		default:
+			return RestoreResult{}, err
+		}
+	}
+
+	if hot {
+		result.Mode = RestoreHot
+		result.Warning = hotRestoreRisk
+	} else {
+		if err := ad.Stop(ctx); err != nil {
+			return RestoreResult{}, err
+		}
+		if err := restore(ctx, ad, backupKey, domain.RestoreOptions{Force: req.Force, AllowLocal: req.AllowLocal}); err != nil {
+			st.Phase = "error"
+			_ = c.state.Set(ctx, st)
+			return RestoreResult{}, err
+		}
+		if err := ad.Start(ctx); err != nil {
+			st.Phase = "error"
+			_ = c.state.Set(ctx, st)
+			return RestoreResult{}, err
+		}
+	}
+
+	result.FinishedAt = c.clock.Now().UTC()
+	result.DurationMS = result.FinishedAt.Sub(begin).Milliseconds()
+	if !local {
+		// A local file is no use to later starts; they keep the last S3 backup.
+		st.LastBackups[result.Game] = backupKey
+	}
+	st.Phase = "running"
+	_ = c.state.Set(ctx, st)
+	c.log.Info("restore complete", "game", result.Game, "backup", backupKey, "mode", result.Mode, "safety_backup", result.SafetyBackup)
+	return result, nil
+}
+
+type worldAger interface {
+	WorldModTime(ctx context.Context) (time.Time, error)
+}
+
+// backupTimer reports when a backup was taken from what the store recorded
+// about it (source names where), or zero when it recorded nothing.
+type backupTimer interface {
+	BackupCreatedAt(ctx context.Context, backupKey string) (at time.Time, source string, err error)
+}
+
+// RestoreAgeCheck compares a backup's creation time with the current
+// world's: the newest file in the data dir, or for adapters that can't read
+// it the game's last recorded backup. The backup's time comes from the
+// adapter (its marker or object) and only falls back to the timestamp in its
+// key when the adapter has none. Keys only have seconds, so against a key a
+// world last written in the same second as the backup counts as older.
+type RestoreAgeCheck struct {
+	BackupTime   time.Time `json:"backup_time,omitzero"`
+	BackupSource string    `json:"backup_source,omitempty"` // marker | object | key; "" when unknown
+	WorldTime    time.Time `json:"world_time,omitzero"`
+	WorldSource  string    `json:"world_source,omitempty"` // data_dir | last_backup; "" when there is no world
+	BackupNewer  bool      `json:"backup_newer"`
+	Forced       bool      `json:"forced,omitempty"`
+}
+
+func (a RestoreAgeCheck) String() string {
+	backup := "backup time unknown"
+	if !a.BackupTime.IsZero() {
+		backup = "backup from " + a.BackupTime.Format(time.RFC3339)
+	}
+	if a.WorldSource == "" {
+		return backup + ", no current world"
+	}
+	return fmt.Sprintf("%s, world from %s (%s)", backup, a.WorldTime.Format(time.RFC3339), a.WorldSource)
+}
+
+// checkRestoreAge compares backupKey with the active game's current world.
+// With no world there is nothing to overwrite and any backup passes; a
+// backup of unknown age can't be shown to be newer.
+func (c *ControllerService) checkRestoreAge(ctx context.Context, ad Adapter, st State, backupKey string) (RestoreAgeCheck, error) {
+	var check RestoreAgeCheck
+	if timer, ok := ad.(backupTimer); ok {
+		t, source, err := timer.BackupCreatedAt(ctx, backupKey)
+		if err != nil {
+			return RestoreAgeCheck{}, fmt.Errorf("read backup age: %w", err)
+		}
+		if !t.IsZero() {
+			check.BackupTime, check.BackupSource = t.UTC(), source
+		}
+	}
+	if check.BackupTime.IsZero() {
+		if t, ok := domain.BackupTime(backupKey); ok {
+			check.BackupTime, check.BackupSource = t, "key"
+		}
+	}
+	if ager, ok := ad.(worldAger); ok {
+		t, err := ager.WorldModTime(ctx)
+		if err != nil {
+			return RestoreAgeCheck{}, fmt.Errorf("read world age: %w", err)
+		}
+		if !t.IsZero() {
+			check.WorldTime, check.WorldSource = t.UTC(), "data_dir"
+		}
+	} else if t, ok := domain.BackupTime(st.LastBackups[string(st.ActiveGame)]); ok {
+		check.WorldTime, check.WorldSource = t, "last_backup"
+	}
+
+	switch {
+	case check.WorldSource == "":
+		check.BackupNewer = true
+	case check.BackupSource == "key":
+		check.BackupNewer = check.BackupTime.After(check.WorldTime.Truncate(time.Second))
+	case !check.BackupTime.IsZero():
+		check.BackupNewer = check.BackupTime.After(check.WorldTime)
+	}
+	return check, nil
+}
+
+// safetyBackup snapshots ad's current world to the pre-restore stream. Games
+// without backup streams have nothing to snapshot to and are restored as
+// before, with "" as the key; so is an empty world, which has nothing to lose.
+func (c *ControllerService) safetyBackup(ctx context.Context, ad Adapter) (string, error) {
+	if _, ok := ad.(streamBackupProvider); !ok {
+		c.log.Info("safety backup skipped: adapter has no backup streams", "game", ad.Type())
+		return "", nil
+	}
+	key, err := backupKeyOf(backup(ctx, ad, domain.PreRestoreStream))
+	if errors.Is(err, domain.ErrEmptyBackup) {
+		c.log.Info("safety backup skipped: data dir is empty", "game", ad.Type())
+		return "", nil
+	}
+	return key, err
+}
+
+// checkBackupGame refuses to restore backupKey into game when the key isn't
+// under <prefix>/<game>/, which would overwrite one world with another.
+func checkBackupGame(prefix, game, backupKey string, allowCrossGame bool) error {
+	if allowCrossGame || domain.BackupForGame(backupKey, prefix, game) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not under %s/ (pass allow_cross_game to restore it anyway)", domain.ErrBackupOtherGame, backupKey, path.Join(prefix, game))
+}
+
+// WithBackupPrefix sets the key prefix (BACKUP_PREFIX[/ENV]) the adapters
+// store backups under, which restores check a backup's game against.
+func WithBackupPrefix(prefix string) Option {
+	return func(c *ControllerService) { c.backupPrefix = strings.Trim(prefix, "/") }
+}
+
+// checkLocalRestore refuses a file:// backup unless the caller asked for one
+// and the adapter can take restore options, which is how AllowLocal reaches
+// it.
+func checkLocalRestore(ad Adapter, backupKey string, allowLocal bool) error {
+	if !allowLocal {
+		return fmt.Errorf("%w: %s is a local path (pass allow_local to restore it)", domain.ErrLocalBackup, backupKey)
+	}
+	if _, ok := ad.(optionRestorer); !ok {
+		return fmt.Errorf("%w: %s can't restore local backups", domain.ErrNotSupported, ad.Type())
+	}
+	return nil
+}
+
+// resolveBackup picks the backup Restore should use: the explicit key, the
+// latest of the requested stream, or the game's latest backup.
+func (c *ControllerService) resolveBackup(ctx context.Context, ad Adapter, st State, req RestoreRequest) (string, error) {
+	if key := strings.TrimSpace(req.Backup); key != "" {
+		return key, nil
+	}
+	if refresher, ok := ad.(latestBackupRefresher); ok && req.Refresh {
+		key, err := refresher.RefreshLatestBackup(ctx, req.Stream)
+		if err != nil || strings.TrimSpace(key) == "" {
+			return "", domain.ErrNoBackupForGame
+		}
+		return key, nil
+	}
+	if req.Stream != "" {
+		provider, ok := ad.(streamBackupProvider)
+		if !ok {
+			return "", domain.ErrNotSupported
+		}
+		key, err := provider.LatestBackupStream(ctx, req.Stream)
+		if err != nil || strings.TrimSpace(key) == "" {
+			return "", domain.ErrNoBackupForGame
+		}
+		return key, nil
+	}
+	if key := st.LastBackups[string(ad.Type())]; strings.TrimSpace(key) != "" {
+		return key, nil
+	}
+	provider, ok := ad.(latestBackupProvider)
+	if !ok {
+		return "", domain.ErrNoBackupForGame
+	}
+	key, err := provider.LatestBackup(ctx)
+	if err != nil || strings.TrimSpace(key) == "" {
+		return "", domain.ErrNoBackupForGame
+	}
+	return key, nil
+}
+
+// pathRestorer restores selected files from a backup without clearing the
+// rest of the data dir.
+type pathRestorer interface {
+	RestorePaths(ctx context.Context, backupKey string, paths []string, opts domain.RestoreOptions) ([]string, error)
+}
+
+type PathRestoreRequest struct {
+	Game   string   // "" uses the active game
+	Backup string   // backup key or s3:// URI
+	Paths  []string // files or directories relative to the data dir
+	Force  bool     // skip the data dir in-use check
+	// AllowCrossGame restores from a backup whose key belongs to another game.
+	AllowCrossGame bool
+}
+
+type PathRestoreResult struct {
+	Game   string   `json:"game"`
+	Backup string   `json:"backup"`
+	Files  []string `json:"files"`
+}
+
+// RestorePaths recovers individual files (a corrupted region, a config) from
+// a backup. Unlike Restore nothing else in the data dir is touched and the
+// game is not restarted.
+func (c *ControllerService) RestorePaths(ctx context.Context, req PathRestoreRequest) (res PathRestoreResult, err error) {
+	unlock, err := c.lockOp(ctx, domain.EventRestorePath)
+	if err != nil {
+		return PathRestoreResult{}, err
+	}
+	defer unlock()
+
+	game := req.Game
+	if game == "" {
+		st, _ := c.state.Get(ctx)
+		game = string(st.ActiveGame)
+	}
+	ctx = c.beginOp(ctx, domain.EventRestorePath, game)
+	defer func() {
+		c.emit(ctx, domain.Event{Kind: domain.EventRestorePath, Game: game, Backup: res.Backup}, &err)
+	}()
+	if game == "" {
+		return PathRestoreResult{}, domain.ErrNoActiveGame
+	}
+	ad, ok := c.adapters[game]
+	if !ok {
+		return PathRestoreResult{}, domain.ErrUnknownGameType
+	}
+	pr, ok := ad.(pathRestorer)
+	if !ok {
+		return PathRestoreResult{}, domain.ErrNotSupported
+	}
+	if err := checkBackupGame(c.backupPrefix, game, req.Backup, req.AllowCrossGame); err != nil {
+		return PathRestoreResult{}, err
+	}
+
+	files, err := pr.RestorePaths(ctx, req.Backup, req.Paths, domain.RestoreOptions{Force: req.Force})
+	if err != nil {
+		return PathRestoreResult{}, err
+	}
+	c.log.Info("partial restore complete", "game", game, "backup", req.Backup, "files", len(files))
+	return PathRestoreResult{Game: game, Backup: req.Backup, Files: files}, nil
+}
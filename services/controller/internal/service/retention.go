@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// Retention deletes in two steps. PreviewRetention returns the prune list
+// with a token that digests the game, stream, expiry and every key in it;
+// ApplyRetention recomputes the plan and only deletes when the token still
+// matches, so backups that appeared, vanished or became latest since the
+// preview (or a policy change) stop the apply instead of surprising it.
+// Tokens only confirm; the route's auth decides who may apply.
+
+// retentionTokenTTL is how long a preview's token can be applied.
+const retentionTokenTTL = 10 * time.Minute
+
+// retentionToken is base64url("<game>\n<stream>\n<expiry>\n<digest>").
+func retentionToken(game, stream string, expires time.Time, prune []domain.RetentionDecision) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	raw := strings.Join([]string{game, stream, exp, pruneDigest(game, stream, exp, prune)}, "\n")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func pruneDigest(game, stream, exp string, prune []domain.RetentionDecision) string {
+	h := sha256.New()
+	for _, s := range []string{game, stream, exp} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	for _, d := range prune {
+		h.Write([]byte(d.Key))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type parsedRetentionToken struct {
+	game, stream string
+	expires      time.Time
+}
+
+func parseRetentionToken(token string) (parsedRetentionToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(token))
+	if err != nil {
+		return parsedRetentionToken{}, domain.ErrRetentionTokenInvalid
+	}
+	parts := strings.Split(string(raw), "\n")
+	if len(parts) != 4 || parts[0] == "" {
+		return parsedRetentionToken{}, domain.ErrRetentionTokenInvalid
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return parsedRetentionToken{}, domain.ErrRetentionTokenInvalid
+	}
+	return parsedRetentionToken{game: parts[0], stream: parts[1], expires: time.Unix(exp, 0)}, nil
+}
+
+// RetentionApplyEntry is one pruned backup: where it went in the trash, or
+// why it is still there.
+type RetentionApplyEntry struct {
+	Backup string `json:"backup"`
+	Trash  string `json:"trash,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type RetentionApplyResult struct {
+	Game    string                `json:"game"`
+	Stream  string                `json:"stream"`
+	Results []RetentionApplyEntry `json:"results"`
+	Deleted int                   `json:"deleted"`
+	Failed  int                   `json:"failed"`
+}
+
+// ApplyRetention soft-deletes the backups a preview listed for pruning,
+// provided token still matches the plan as it stands now. One backup failing
+// doesn't stop the rest; the returned error joins every failure. Like
+// CopyBackup it fails fast when another operation is running.
+func (c *ControllerService) ApplyRetention(ctx context.Context, token string) (res RetentionApplyResult, err error) {
+	parsed, err := parseRetentionToken(token)
+	if err != nil {
+		return RetentionApplyResult{}, err
+	}
+	// The expiry is covered by the digest, but a hand-made token could still
+	// claim one far off.
+	now := c.clock.Now()
+	if !now.Before(parsed.expires) || parsed.expires.Sub(now) > retentionTokenTTL {
+		return RetentionApplyResult{}, domain.ErrRetentionTokenStale
+	}
+
+	if !c.opMu.TryLock() {
+		return RetentionApplyResult{}, domain.ErrAnotherInFlight
+	}
+	defer c.opMu.Unlock()
+	ctx = c.beginOp(ctx, domain.EventRetention, parsed.game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventRetention, Game: parsed.game}, &err) }()
+
+	ad, ok := c.adapters[parsed.game]
+	if !ok {
+		return RetentionApplyResult{}, domain.ErrUnknownGameType
+	}
+	trasher, ok := ad.(backupTrasher)
+	if !ok {
+		return RetentionApplyResult{}, domain.ErrNotSupported
+	}
+	preview, err := c.PreviewRetention(ctx, parsed.game, parsed.stream)
+	if err != nil {
+		return RetentionApplyResult{}, err
+	}
+	if retentionToken(parsed.game, parsed.stream, parsed.expires, preview.Prune) != strings.TrimSpace(token) {
+		return RetentionApplyResult{}, domain.ErrRetentionTokenStale
+	}
+
+	res = RetentionApplyResult{Game: parsed.game, Stream: parsed.stream, Results: []RetentionApplyEntry{}}
+	var errs []error
+	for _, d := range preview.Prune {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		trashRef, err := trasher.DeleteBackup(ctx, d.Key)
+		if err != nil {
+			res.Failed++
+			res.Results = append(res.Results, RetentionApplyEntry{Backup: d.Key, Error: err.Error()})
+			errs = append(errs, fmt.Errorf("%s: %w", d.Key, err))
+			continue
+		}
+		res.Deleted++
+		res.Results = append(res.Results, RetentionApplyEntry{Backup: d.Key, Trash: trashRef})
+	}
+
+	if res.Deleted > 0 && parsed.stream == "" {
+		st, _ := c.state.Get(ctx)
+		st = ensureStateMaps(st)
+		for _, r := range res.Results {
+			if r.Trash != "" && sameBackup(ad, st.LastBackups[parsed.game], r.Backup) {
+				delete(st.LastBackups, parsed.game)
+				_ = c.state.Set(ctx, st)
+				break
+			}
+		}
+	}
+
+	c.log.Info("retention applied", "game", parsed.game, "stream", parsed.stream, "deleted", res.Deleted, "failed", res.Failed)
+	return res, errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// Jitter modes for the backup scheduler.
+const (
+	JitterRandom   = "random"   // fresh random offset before every run
+	JitterInstance = "instance" // fixed offset derived from the instance id
+)
+
+// BackupSchedule configures RunBackupScheduler. Each run waits Interval plus
+// an offset in [0, Jitter) so replicas started together don't hit S3/ECS on
+// the same boundary.
+type BackupSchedule struct {
+	Interval   time.Duration // 0 disables the scheduler
+	Jitter     time.Duration
+	JitterMode string // random | instance
+	InstanceID string // seeds the instance mode, e.g. the hostname
+}
+
+// offset returns the jitter to add before the next run.
+func (s BackupSchedule) offset() time.Duration {
+	if s.Jitter <= 0 {
+		return 0
+	}
+	if s.JitterMode == JitterInstance {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(s.InstanceID))
+		return time.Duration(h.Sum64() % uint64(s.Jitter))
+	}
+	return rand.N(s.Jitter)
+}
+
+// RunBackupScheduler backs up the active game every Interval (plus jitter,
+// including before the first run) until ctx is done. Runs with no active
+// game are skipped quietly.
+func (c *ControllerService) RunBackupScheduler(ctx context.Context, sched BackupSchedule) {
+	if sched.Interval <= 0 {
+		return
+	}
+	first := sched.offset()
+	c.log.Info("backup scheduler started", "interval", sched.Interval, "jitter", sched.Jitter, "mode", sched.JitterMode, "first_in", first)
+
+	timer := time.NewTimer(first)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// Checked up front so idle periods don't emit a failed event per tick.
+		if st, _ := c.state.Get(ctx); st.ActiveGame != "" {
+			key, err := c.Backup(ctx, "")
+			switch {
+			case errors.Is(err, domain.ErrNoActiveGame):
+			case err != nil:
+				c.log.Error("scheduled backup failed", "err", err)
+			default:
+				c.log.Info("scheduled backup complete", "backup", key)
+			}
+		}
+		timer.Reset(sched.Interval + sched.offset())
+	}
+}
@@ -0,0 +1,204 @@
+// Package servicetest provides an in-memory game adapter for exercising
+// ControllerService workflows (start, stop, switch, restore) without AWS,
+// git or a game server.
+package servicetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// Recorder collects the calls made to one or more Adapters in order, so a
+// test can assert on the sequence across games (e.g. that a switch backs
+// up and syncs the old game before it restores the new one).
+type Recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *Recorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+// Calls returns the recorded calls as "<game>.<Method>", oldest first.
+func (r *Recorder) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// Reset forgets the recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// Adapter is a domain.GameAdapter that keeps its world in memory. It also
+// implements the optional latest-backup and desired-count capabilities, so
+// the service's fallbacks can be driven from a test.
+type Adapter struct {
+	rec  *Recorder
+	game domain.GameType
+
+	mu      sync.Mutex
+	errs    map[string]error
+	running bool
+	latest  string
+	backups int
+	source  string
+}
+
+// New returns an adapter for game recording into rec. Adapters sharing a
+// Recorder interleave their calls in it.
+func New(game domain.GameType, rec *Recorder) *Adapter {
+	return &Adapter{rec: rec, game: game, errs: map[string]error{}}
+}
+
+// Fail makes every later call to method (e.g. "Backup") return err; a nil
+// err clears it.
+func (a *Adapter) Fail(method string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err == nil {
+		delete(a.errs, method)
+		return
+	}
+	a.errs[method] = err
+}
+
+// SetLatest sets the key LatestBackup returns; "" means no backup yet.
+func (a *Adapter) SetLatest(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.latest = key
+}
+
+// Running reports whether the fake server is up.
+func (a *Adapter) Running() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.running
+}
+
+// Source returns the URL the adapter was last seeded from or synced to.
+func (a *Adapter) Source() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.source
+}
+
+// call records method and returns its injected error. The caller holds no
+// lock.
+func (a *Adapter) call(method string) error {
+	a.rec.record(string(a.game) + "." + method)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.errs[method]
+}
+
+func (a *Adapter) Type() domain.GameType { return a.game }
+
+func (a *Adapter) Start(ctx context.Context) error {
+	if err := a.call("Start"); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.running = true
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *Adapter) Stop(ctx context.Context) error {
+	if err := a.call("Stop"); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.running = false
+	a.mu.Unlock()
+	return nil
+}
+
+// Backup returns a key laid out like the real adapters'
+// (backups/<game>/<name>) and makes it the latest.
+func (a *Adapter) Backup(ctx context.Context) (string, error) {
+	if err := a.call("Backup"); err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.backups++
+	// The counter keeps keys distinct and ordered within one second.
+	at := time.Date(2025, 1, 1, 0, 0, a.backups, 0, time.UTC)
+	a.latest = fmt.Sprintf("backups/%s/%s", a.game, domain.BackupName(at, ".zip"))
+	return a.latest, nil
+}
+
+func (a *Adapter) Restore(ctx context.Context, backupKey string) error {
+	return a.call("Restore")
+}
+
+func (a *Adapter) SeedFromSource(ctx context.Context, sourceURL string) error {
+	if err := a.call("SeedFromSource"); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.source = sourceURL
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *Adapter) SyncToSource(ctx context.Context, sourceURL string) error {
+	if err := a.call("SyncToSource"); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.source = sourceURL
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *Adapter) SendCommand(ctx context.Context, command string) error {
+	return a.call("SendCommand")
+}
+
+func (a *Adapter) Status(ctx context.Context) (map[string]any, error) {
+	if err := a.call("Status"); err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return map[string]any{"adapter": string(a.game), "running": a.running, "last_backup": a.latest}, nil
+}
+
+// LatestBackup is the fallback Start uses when the state has no backup
+// recorded for the game.
+func (a *Adapter) LatestBackup(ctx context.Context) (string, error) {
+	if err := a.call("LatestBackup"); err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.latest == "" {
+		return "", domain.ErrNoBackupForGame
+	}
+	return a.latest, nil
+}
+
+// DesiredCount reports 1 while the fake server is running.
+func (a *Adapter) DesiredCount(ctx context.Context) (int32, error) {
+	if err := a.call("DesiredCount"); err != nil {
+		return 0, err
+	}
+	if a.Running() {
+		return 1, nil
+	}
+	return 0, nil
+}
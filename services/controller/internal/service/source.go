@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+	"github.com/esuEdu/game-infra/controller/internal/metrics"
+)
+
+type committedSyncer interface {
+	SyncToSourceCommitted(ctx context.Context, sourceURL string) (bool, error)
+}
+
+type syncReporter interface {
+	SyncToSourceReport(ctx context.Context, sourceURL string) (domain.SyncReport, error)
+}
+
+type seedReporter interface {
+	SeedFromSourceReport(ctx context.Context, sourceURL string, opts domain.SeedOptions) (domain.SeedReport, error)
+}
+
+type optionRestorer interface {
+	RestoreWithOptions(ctx context.Context, backupKey string, opts domain.RestoreOptions) error
+}
+
+type backupReporter interface {
+	BackupReport(ctx context.Context) (domain.BackupReport, error)
+}
+
+type streamBackupReporter interface {
+	BackupStreamReport(ctx context.Context, stream string) (domain.BackupReport, error)
+}
+
+var (
+	backupFiles     = metrics.Default.Counter("controller_backup_files_total", "Files captured by backups, by game.")
+	backupBytes     = metrics.Default.Counter("controller_backup_bytes_total", "Uncompressed bytes captured by backups, by game.")
+	lastBackupFiles = metrics.Default.Gauge("controller_last_backup_files", "Files captured by the game's most recent backup.")
+	lastBackupBytes = metrics.Default.Gauge("controller_last_backup_bytes", "Uncompressed bytes captured by the game's most recent backup.")
+	seedFiles       = metrics.Default.Counter("controller_seed_files_total", "Files copied into data dirs by seeds, by game.")
+	seedBytes       = metrics.Default.Counter("controller_seed_bytes_total", "Bytes copied into data dirs by seeds, by game.")
+)
+
+// seed seeds ad from sourceURL, using the adapter's reporting/option-aware
+// variant when available.
+func seed(ctx context.Context, ad Adapter, sourceURL string, opts domain.SeedOptions) (domain.SeedReport, error) {
+	if reporter, ok := ad.(seedReporter); ok {
+		report, err := reporter.SeedFromSourceReport(ctx, sourceURL, opts)
+		if err == nil {
+			seedFiles.Add(float64(report.Files), "game", string(ad.Type()))
+			seedBytes.Add(float64(report.Bytes), "game", string(ad.Type()))
+		}
+		return report, err
+	}
+	if err := ad.SeedFromSource(ctx, sourceURL); err != nil {
+		return domain.SeedReport{}, err
+	}
+	return domain.SeedReport{Source: sourceURL}, nil
+}
+
+// syncSource pushes ad's data dir to sourceURL, using the most detailed
+// variant the adapter has.
+func syncSource(ctx context.Context, ad Adapter, sourceURL string) (domain.SyncReport, error) {
+	if reporter, ok := ad.(syncReporter); ok {
+		return reporter.SyncToSourceReport(ctx, sourceURL)
+	}
+	if syncer, ok := ad.(committedSyncer); ok {
+		committed, err := syncer.SyncToSourceCommitted(ctx, sourceURL)
+		return domain.SyncReport{Committed: committed}, err
+	}
+	return domain.SyncReport{}, ad.SyncToSource(ctx, sourceURL)
+}
+
+// recordSourceCommit keeps the commit a game's data dir was last seeded from
+// or synced to. An adapter that can't tell clears the old one, so it never
+// describes a later seed or sync.
+func recordSourceCommit(st State, game, commit string) {
+	if commit == "" {
+		delete(st.SourceShaByGame, game)
+		return
+	}
+	st.SourceShaByGame[game] = commit
+}
+
+// restore restores ad from backupKey, passing opts through when the adapter
+// supports them.
+func restore(ctx context.Context, ad Adapter, backupKey string, opts domain.RestoreOptions) error {
+	if r, ok := ad.(optionRestorer); ok {
+		return r.RestoreWithOptions(ctx, backupKey, opts)
+	}
+	return ad.Restore(ctx, backupKey)
+}
+
+// backup backs ad up into stream ("" for the default) and records what the
+// backup captured in the metrics.
+func backup(ctx context.Context, ad Adapter, stream string) (domain.BackupReport, error) {
+	report, err := backupReport(ctx, ad, stream)
+	if err != nil {
+		return domain.BackupReport{}, err
+	}
+	game := string(ad.Type())
+	backupFiles.Add(float64(report.Files), "game", game)
+	backupBytes.Add(float64(report.Bytes), "game", game)
+	lastBackupFiles.Set(float64(report.Files), "game", game)
+	lastBackupBytes.Set(float64(report.Bytes), "game", game)
+	return report, nil
+}
+
+// backupReport runs the most detailed backup variant the adapter has. One
+// that only returns a key reports zero files and bytes.
+func backupReport(ctx context.Context, ad Adapter, stream string) (domain.BackupReport, error) {
+	if stream == "" {
+		if reporter, ok := ad.(backupReporter); ok {
+			return reporter.BackupReport(ctx)
+		}
+		key, err := ad.Backup(ctx)
+		return domain.BackupReport{Backup: key}, err
+	}
+	if reporter, ok := ad.(streamBackupReporter); ok {
+		return reporter.BackupStreamReport(ctx, stream)
+	}
+	provider, ok := ad.(streamBackupProvider)
+	if !ok {
+		return domain.BackupReport{}, domain.ErrNotSupported
+	}
+	key, err := provider.BackupStream(ctx, stream)
+	return domain.BackupReport{Backup: key}, err
+}
+
+type SyncResult struct {
+	Game      string `json:"game"`
+	DataURL   string `json:"data_url"`
+	Committed bool   `json:"committed"`
+	Commit    string `json:"commit,omitempty"` // the source's head after the sync
+}
+
+// Sync pushes a game's current data dir to its recorded source without
+// stopping the server.
+func (c *ControllerService) Sync(ctx context.Context, game string) (res SyncResult, err error) {
+	unlock, err := c.lockOp(ctx, domain.EventSync)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	defer unlock()
+	ctx = c.beginOp(ctx, domain.EventSync, game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventSync, Game: game, Commit: res.Commit}, &err) }()
+
+	ad, ok := c.adapters[game]
+	if !ok {
+		return SyncResult{}, domain.ErrUnknownGameType
+	}
+
+	st, _ := c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	sourceURL := st.SourceByGame[game]
+	if sourceURL == "" {
+		return SyncResult{}, domain.ErrNoSourceForGame
+	}
+
+	report, err := syncSource(ctx, ad, sourceURL)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	result := SyncResult{Game: game, DataURL: sourceURL, Committed: report.Committed, Commit: report.Commit}
+
+	st, _ = c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	recordSourceCommit(st, game, report.Commit)
+	_ = c.state.Set(ctx, st)
+
+	c.log.Info("sync complete", "game", game, "source", sourceURL, "committed", result.Committed, "commit", result.Commit)
+	return result, nil
+}
+
+// Seed resets a stopped game's data dir from a source URL so the world can be
+// prepared ahead of a start. The source is recorded for later syncs.
+func (c *ControllerService) Seed(ctx context.Context, game string, dataURL string, force bool) (report domain.SeedReport, err error) {
+	unlock, err := c.lockOp(ctx, domain.EventSeed)
+	if err != nil {
+		return domain.SeedReport{}, err
+	}
+	defer unlock()
+	ctx = c.beginOp(ctx, domain.EventSeed, game)
+	defer func() { c.emit(ctx, domain.Event{Kind: domain.EventSeed, Game: game, Commit: report.Commit}, &err) }()
+
+	ad, ok := c.adapters[game]
+	if !ok {
+		return domain.SeedReport{}, domain.ErrUnknownGameType
+	}
+
+	st, _ := c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	if st.ActiveGame == ad.Type() {
+		return domain.SeedReport{}, domain.ErrGameActive
+	}
+
+	dataURL = strings.TrimSpace(dataURL)
+	report, err = seed(ctx, ad, dataURL, domain.SeedOptions{Force: force})
+	if err != nil {
+		return domain.SeedReport{}, err
+	}
+
+	st.SourceByGame[game] = dataURL
+	recordSourceCommit(st, game, report.Commit)
+	_ = c.state.Set(ctx, st)
+
+	c.log.Info("seed complete", "game", game, "source", dataURL, "commit", report.Commit, "files", report.Files, "bytes", report.Bytes)
+	return report, nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"maps"
 	"sync"
 	"time"
 
@@ -13,7 +14,17 @@ type State struct {
 	Phase        string            `json:"phase"`
 	LastBackups  map[string]string `json:"last_backups"`
 	SourceByGame map[string]string `json:"source_by_game"`
+	LastStart    *StartResult      `json:"last_start,omitempty"`
+	LastStop     *StopResult       `json:"last_stop,omitempty"`
 	UpdatedAt    time.Time         `json:"updated_at"`
+
+	// SourceShaByGame is the commit each game's data dir was last seeded
+	// from or synced to, when the adapter reports one.
+	SourceShaByGame map[string]string `json:"source_sha_by_game,omitempty"`
+
+	// ECSTargets holds the cluster/service override each game was started
+	// on, if any, so it is stopped and inspected there too.
+	ECSTargets map[string]domain.ECSTarget `json:"ecs_targets,omitempty"`
 }
 
 type StateStore interface {
@@ -21,20 +32,38 @@ type StateStore interface {
 	Set(ctx context.Context, s State) error
 }
 
+// StateNotifier is implemented by stores that can signal state changes.
+// Changes returns a channel that is closed on the next Set.
+type StateNotifier interface {
+	Changes() <-chan struct{}
+}
+
 type memoryState struct {
-	mu sync.Mutex
-	s  State
+	mu      sync.Mutex
+	s       State
+	changed chan struct{}
+	clock   domain.Clock
 }
 
 func NewMemoryState() StateStore {
-	return &memoryState{
-		s: State{
-			ActiveGame:   "",
-			Phase:        "stopped",
-			LastBackups:  map[string]string{},
-			SourceByGame: map[string]string{},
-			UpdatedAt:    time.Now().UTC(),
-		},
+	clock := domain.SystemClock{}
+	return &memoryState{s: defaultState(clock.Now()), changed: make(chan struct{}), clock: clock}
+}
+
+func (m *memoryState) SetClock(clock domain.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+	m.s.UpdatedAt = clock.Now().UTC()
+}
+
+func defaultState(now time.Time) State {
+	return State{
+		ActiveGame:   "",
+		Phase:        "stopped",
+		LastBackups:  map[string]string{},
+		SourceByGame: map[string]string{},
+		UpdatedAt:    now.UTC(),
 	}
 }
 
@@ -47,11 +76,19 @@ func (m *memoryState) Get(ctx context.Context) (State, error) {
 func (m *memoryState) Set(ctx context.Context, s State) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	s.UpdatedAt = time.Now().UTC()
+	s.UpdatedAt = m.clock.Now().UTC()
 	m.s = cloneState(s)
+	close(m.changed)
+	m.changed = make(chan struct{})
 	return nil
 }
 
+func (m *memoryState) Changes() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.changed
+}
+
 func cloneState(s State) State {
 	cp := s
 
@@ -65,5 +102,18 @@ func cloneState(s State) State {
 		cp.SourceByGame[k] = v
 	}
 
+	cp.SourceShaByGame = maps.Clone(s.SourceShaByGame)
+	cp.ECSTargets = maps.Clone(s.ECSTargets)
+
+	if s.LastStart != nil {
+		last := *s.LastStart
+		last.Timings = maps.Clone(last.Timings)
+		cp.LastStart = &last
+	}
+	if s.LastStop != nil {
+		last := *s.LastStop
+		cp.LastStop = &last
+	}
+
 	return cp
 }
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/esuEdu/game-infra/controller/internal/metrics"
+)
+
+// Status polls would otherwise describe the ECS service (and dial the game)
+// on every call. The live part of a status, the adapter's Status and the
+// game probe, is kept for WithStatusCacheTTL and shared: concurrent pollers
+// that miss wait on a single lookup. The cache is keyed on the active game,
+// its ECS target and the state's updated_at, so a start, stop or switch is
+// seen on the next poll rather than after the TTL.
+
+const statusLookupTimeout = 30 * time.Second
+
+var statusCacheLookups = metrics.Default.Counter("controller_status_cache_total", "Live status lookups by result: hit (cached), miss (looked up) or shared (waited on another caller's lookup).")
+
+// liveStatus is the part of a status that calls out of the controller.
+type liveStatus struct {
+	hasAdapter bool
+	gameStatus map[string]any
+	gameErr    error
+	probe      *GameProbe
+}
+
+type statusCache struct {
+	ttl   time.Duration
+	group singleflight.Group
+
+	mu  sync.Mutex
+	key string
+	at  time.Time
+	val liveStatus
+}
+
+// WithStatusCacheTTL shares live status lookups for d; 0 looks up on every
+// call.
+func WithStatusCacheTTL(d time.Duration) Option {
+	return func(c *ControllerService) { c.statusCache.ttl = max(d, 0) }
+}
+
+// liveStatus returns the active game's live status, from the cache when a
+// lookup for the same game and state is younger than the TTL.
+func (c *ControllerService) liveStatus(ctx context.Context, st State) liveStatus {
+	s := &c.statusCache
+	if s.ttl <= 0 {
+		return c.lookupLiveStatus(ctx, st)
+	}
+
+	key := statusCacheKey(st)
+	s.mu.Lock()
+	if s.key == key && c.clock.Now().Sub(s.at) < s.ttl {
+		val := s.val
+		s.mu.Unlock()
+		statusCacheLookups.Inc("result", "hit")
+		return val
+	}
+	s.mu.Unlock()
+
+	// The lookup outlives a caller that gives up, since others may be
+	// waiting on it, but not statusLookupTimeout.
+	ran := false
+	v, _, _ := s.group.Do(key, func() (any, error) {
+		ran = true
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), statusLookupTimeout)
+		defer cancel()
+		val := c.lookupLiveStatus(ctx, st)
+		s.mu.Lock()
+		s.key, s.at, s.val = key, c.clock.Now(), val
+		s.mu.Unlock()
+		return val, nil
+	})
+	if ran {
+		statusCacheLookups.Inc("result", "miss")
+	} else {
+		statusCacheLookups.Inc("result", "shared")
+	}
+	return v.(liveStatus)
+}
+
+func (c *ControllerService) lookupLiveStatus(ctx context.Context, st State) liveStatus {
+	var live liveStatus
+	ad, err := c.adapterByType(st.ActiveGame)
+	if err == nil {
+		live.hasAdapter = true
+		live.gameStatus, live.gameErr = ad.Status(gameECSCtx(ctx, st, string(st.ActiveGame)))
+	}
+	live.probe = c.probeGame(ctx, st.ActiveGame)
+	return live
+}
+
+func statusCacheKey(st State) string {
+	target := st.ECSTargets[string(st.ActiveGame)]
+	return strings.Join([]string{
+		string(st.ActiveGame), target.Cluster, target.Service, st.UpdatedAt.Format(time.RFC3339Nano),
+	}, "\n")
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// StopAllEntry is one game's outcome in a StopAll: its StopResult when it
+// stopped, Error when it didn't.
+type StopAllEntry struct {
+	StopResult
+	Error string `json:"error,omitempty"`
+}
+
+type StopAllResult struct {
+	Results []StopAllEntry `json:"results"`
+	Failed  int            `json:"failed"`
+}
+
+// StopAll stops, backs up and syncs every game that is running: the active
+// one first, then any other whose ECS service still wants tasks. One game
+// failing doesn't stop the rest; the returned error joins every failure and
+// each is also reported on its entry. Each game is a separate stop
+// operation and event, all under one hold of the operation lock.
+func (c *ControllerService) StopAll(ctx context.Context, order string) (StopAllResult, error) {
+	if order == "" {
+		order = c.stopBackupOrder
+	}
+	if !ValidBackupOrder(order) {
+		return StopAllResult{}, fmt.Errorf("%w: unknown backup order %q", domain.ErrBadState, order)
+	}
+
+	unlock, err := c.lockOp(ctx, domain.EventStop)
+	if err != nil {
+		return StopAllResult{}, err
+	}
+	defer unlock()
+
+	res := StopAllResult{Results: []StopAllEntry{}}
+	var errs []error
+	fail := func(game string, err error) {
+		res.Results = append(res.Results, StopAllEntry{StopResult: StopResult{Game: game, BackupOrder: order}, Error: err.Error()})
+		res.Failed++
+		errs = append(errs, fmt.Errorf("%s: %w", game, err))
+	}
+
+	games, lookupErrs := c.runningGames(ctx)
+	for _, game := range slices.Sorted(maps.Keys(lookupErrs)) {
+		fail(game, lookupErrs[game])
+	}
+	for _, game := range games {
+		out, err := c.stopOne(ctx, game, order)
+		if err != nil {
+			fail(game, err)
+			continue
+		}
+		res.Results = append(res.Results, StopAllEntry{StopResult: out})
+	}
+
+	c.log.Info("stop all complete", "stopped", len(res.Results)-res.Failed, "failed", res.Failed)
+	return res, errors.Join(errs...)
+}
+
+// runningGames lists the games StopAll should stop, the active game first
+// and the rest by name. A game whose desired count couldn't be read is
+// returned in lookupErrs instead.
+func (c *ControllerService) runningGames(ctx context.Context) (games []string, lookupErrs map[string]error) {
+	st, _ := c.state.Get(ctx)
+	st = ensureStateMaps(st)
+	lookupErrs = map[string]error{}
+	if st.ActiveGame != "" {
+		games = append(games, string(st.ActiveGame))
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.adapters)) {
+		ad := c.adapters[name]
+		game := string(ad.Type())
+		if ad.Type() == st.ActiveGame {
+			continue
+		}
+		reporter, ok := ad.(desiredCountReporter)
+		if !ok {
+			continue
+		}
+		desired, err := reporter.DesiredCount(gameECSCtx(ctx, st, game))
+		if err != nil {
+			lookupErrs[game] = err
+			continue
+		}
+		if desired > 0 {
+			games = append(games, game)
+		}
+	}
+	return games, lookupErrs
+}
+
+// stopOne runs stopGame for one game of a StopAll as its own operation.
+func (c *ControllerService) stopOne(ctx context.Context, game, order string) (res StopResult, err error) {
+	ctx = c.beginOp(ctx, domain.EventStop, game)
+	defer func() {
+		c.emit(ctx, domain.Event{Kind: domain.EventStop, Game: game, Backup: res.Backup, Commit: res.Commit}, &err)
+	}()
+
+	ad, err := c.adapterByType(domain.GameType(game))
+	if err != nil {
+		return StopResult{}, err
+	}
+	st, _ := c.state.Get(ctx)
+	return c.stopGame(gameECSCtx(ctx, ensureStateMaps(st), game), ad, game, order)
+}
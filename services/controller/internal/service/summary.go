@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+// playerCounter reports the game's current player count, false while it
+// isn't known.
+type playerCounter interface {
+	PlayerCount() (int, bool)
+}
+
+// StatusSummary is the flat, adapter-independent status for CLI tooling.
+// Its fields are a stable contract: new ones may be added, but none are
+// renamed, removed or change type.
+type StatusSummary struct {
+	ActiveGame string `json:"active_game"`
+	Phase      string `json:"phase"`
+	Running    bool   `json:"running"`
+	// LastBackupAgeSeconds is -1 when the active game has no backup with a
+	// timestamped name.
+	LastBackupAgeSeconds int64 `json:"last_backup_age_seconds"`
+	// PlayerCount is null when the adapter doesn't sample players or has no
+	// sample yet.
+	PlayerCount *int `json:"player_count"`
+}
+
+// StatusSummary reads only controller state and cached adapter values, so
+// unlike Status it makes no ECS/S3 calls.
+func (c *ControllerService) StatusSummary(ctx context.Context) (StatusSummary, error) {
+	st, err := c.state.Get(ctx)
+	if err != nil {
+		return StatusSummary{}, err
+	}
+	st = ensureStateMaps(st)
+
+	game := string(st.ActiveGame)
+	out := StatusSummary{
+		ActiveGame:           game,
+		Phase:                st.Phase,
+		Running:              game != "" && st.Phase == "running",
+		LastBackupAgeSeconds: -1,
+	}
+	if at, ok := domain.BackupTime(st.LastBackups[game]); ok && game != "" {
+		out.LastBackupAgeSeconds = max(int64(c.clock.Now().Sub(at).Seconds()), 0)
+	}
+	if out.Running {
+		if ad, err := c.adapterByType(st.ActiveGame); err == nil {
+			if pc, ok := ad.(playerCounter); ok {
+				if n, ok := pc.PlayerCount(); ok {
+					out.PlayerCount = &n
+				}
+			}
+		}
+	}
+	return out, nil
+}
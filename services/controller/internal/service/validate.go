@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+type adapterValidator interface {
+	Validate() error
+}
+
+// ValidateAdapters checks every registered adapter that implements Validate.
+// Degraded adapters are logged as warnings; the returned error joins the
+// hard failures.
+func (c *ControllerService) ValidateAdapters() error {
+	names := make([]string, 0, len(c.adapters))
+	for name := range c.adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		v, ok := c.adapters[name].(adapterValidator)
+		if !ok {
+			continue
+		}
+		err := v.Validate()
+		if err == nil {
+			continue
+		}
+		if isDegraded(err) {
+			c.log.Warn("adapter degraded", "game", name, "err", err)
+			continue
+		}
+		c.log.Error("adapter misconfigured", "game", name, "err", err)
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// isDegraded reports whether every error joined in err is ErrAdapterDegraded.
+func isDegraded(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if !isDegraded(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return errors.Is(err, domain.ErrAdapterDegraded)
+}
@@ -2,30 +2,95 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/esuEdu/game-infra/controller/internal/domain"
 )
 
-func (c *ControllerService) switchWorkflow(ctx context.Context, from domain.GameType, to Adapter) (backupKey string, err error) {
+// switchWorkflow stops and backs up from on fromTarget, then starts to on
+// toTarget; zero targets use the adapters' configured services.
+func (c *ControllerService) switchWorkflow(ctx context.Context, from domain.GameType, fromTarget domain.ECSTarget, to Adapter, toTarget domain.ECSTarget) (backupKey string, err error) {
 	if from != "" {
+		fromCtx := domain.WithECSTarget(ctx, fromTarget)
 		fromAd, err := c.adapterByType(from)
 		if err != nil {
 			return "", err
 		}
 
-		if err := fromAd.Stop(ctx); err != nil {
+		if err := fromAd.Stop(fromCtx); err != nil {
 			return "", err
 		}
 
-		backupKey, err = fromAd.Backup(ctx)
+		backupKey, err = backupKeyOf(backup(fromCtx, fromAd, ""))
 		if err != nil {
 			return "", err
 		}
+
+		if err := c.cooldown(ctx, c.switchCooldown); err != nil {
+			return backupKey, fmt.Errorf("switch cooldown: %w", err)
+		}
 	}
 
-	if err := to.Start(ctx); err != nil {
+	if err := to.Start(domain.WithECSTarget(ctx, toTarget)); err != nil {
 		return backupKey, err
 	}
 
 	return backupKey, nil
 }
+
+// cooldown waits d, or until ctx ends.
+func (c *ControllerService) cooldown(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	c.log.Info("switch cooldown", "wait", d)
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SwitchPreview is what Switch would do right now. Switch never restores or
+// syncs: the target starts on whatever its data dir holds, so Restore is
+// always false and LastBackup is only the newest backup it has on record
+// (use Start to restore one).
+type SwitchPreview struct {
+	From       string           `json:"from,omitempty"`
+	To         string           `json:"to"`
+	NoOp       bool             `json:"no_op"` // to is already active
+	Stop       bool             `json:"stop"`
+	Backup     bool             `json:"backup"`
+	Sync       bool             `json:"sync"`
+	FromTarget domain.ECSTarget `json:"from_ecs_target,omitzero"`
+	Restore    bool             `json:"restore"`
+	LastBackup string           `json:"last_backup,omitempty"`
+}
+
+// PreviewSwitch reports what Switch(game) would do without side effects.
+func (c *ControllerService) PreviewSwitch(ctx context.Context, game string) (SwitchPreview, error) {
+	to, ok := c.adapters[game]
+	if !ok {
+		return SwitchPreview{}, domain.ErrUnknownGameType
+	}
+	st, err := c.state.Get(ctx)
+	if err != nil {
+		return SwitchPreview{}, err
+	}
+	st = ensureStateMaps(st)
+	p := SwitchPreview{
+		From:       string(st.ActiveGame),
+		To:         game,
+		NoOp:       st.ActiveGame == to.Type(),
+		LastBackup: st.LastBackups[game],
+	}
+	if p.From != "" && !p.NoOp {
+		p.Stop, p.Backup = true, true
+		p.FromTarget = st.ECSTargets[p.From]
+	}
+	return p, nil
+}
@@ -0,0 +1,126 @@
+// Package tracing exports the controller's spans over OTLP. Setup installs
+// an exporter only when the standard OTEL_* variables ask for one; until
+// then (and when they don't) the global tracer provider is otel's no-op, so
+// Start is free to call everywhere.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/esuEdu/game-infra/controller/internal/domain"
+)
+
+const (
+	tracerName         = "github.com/esuEdu/game-infra/controller"
+	defaultServiceName = "game-infra-controller"
+)
+
+// Enabled reports whether the environment configures a trace exporter:
+// an OTLP endpoint is set and OTEL_TRACES_EXPORTER isn't "none".
+func Enabled() bool {
+	if exporter := strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER")); exporter != "" && exporter != "otlp" {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup installs a batching OTLP tracer provider and W3C trace-context
+// propagation. The exporter reads the standard variables itself (endpoint,
+// headers, timeout, TLS); OTEL_EXPORTER_OTLP_[TRACES_]PROTOCOL picks grpc or
+// http/protobuf (the default), and OTEL_SERVICE_NAME / OTEL_RESOURCE_ATTRIBUTES
+// describe the process. When tracing isn't enabled it does nothing and the
+// returned shutdown is a no-op; otherwise shutdown flushes pending spans.
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+	if !Enabled() {
+		return shutdown, nil
+	}
+
+	var client otlptrace.Client
+	switch protocol() {
+	case "grpc":
+		client = otlptracegrpc.NewClient()
+	case "http/protobuf":
+		client = otlptracehttp.NewClient()
+	default:
+		return shutdown, fmt.Errorf("unsupported OTLP protocol %q (want grpc or http/protobuf)", protocol())
+	}
+	exporter, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return shutdown, fmt.Errorf("create otlp exporter: %w", err)
+	}
+
+	// Later options win, so the environment overrides the default name.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", defaultServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return shutdown, fmt.Errorf("build otel resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+func protocol() string {
+	for _, key := range []string{"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+	}
+	return "http/protobuf"
+}
+
+// Extract continues the caller's trace from carrier (traceparent and
+// baggage headers or gRPC metadata).
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// Start opens a span named name as a child of any span in ctx. The request
+// id, when ctx carries one, is added so traces can be matched to logs.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if rid := domain.RequestID(ctx); rid != "" {
+		attrs = append(attrs, attribute.String("request.id", rid))
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer is Start for the span covering one inbound request.
+func StartServer(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if rid := domain.RequestID(ctx); rid != "" {
+		attrs = append(attrs, attribute.String("request.id", rid))
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}